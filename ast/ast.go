@@ -21,6 +21,9 @@ type Module struct {
 	NativeImports []string
 	Decls         []Decl
 	Scope         *ModuleScope
+	// Comments contains all the comments in the module in the order in
+	// which they appear in the source code.
+	Comments []*CommentGroup
 }
 
 func (f *Module) Pos() token.Pos { return f.Module.Pos() }
@@ -36,6 +39,27 @@ func (f *Module) End() token.Pos {
 	return f.Module.End()
 }
 
+// Comment is a single line or a multi line comment.
+type Comment struct {
+	// Position of the comment.
+	Position *token.Position
+	// Text of the comment, including the delimiters.
+	Text string
+}
+
+func (c *Comment) Pos() token.Pos { return c.Position.Offset }
+func (c *Comment) End() token.Pos { return c.Pos() + token.Pos(len(c.Text)) }
+
+// CommentGroup is a sequence of comments with no other tokens and no empty
+// lines between them. A comment in the same line as the token before it and
+// a documentation comment are always in a group on their own.
+type CommentGroup struct {
+	List []*Comment
+}
+
+func (g *CommentGroup) Pos() token.Pos { return g.List[0].Pos() }
+func (g *CommentGroup) End() token.Pos { return g.List[len(g.List)-1].End() }
+
 // Package is the set of modules with a certain order of resolution that
 // conform a package.
 type Package struct {
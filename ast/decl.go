@@ -71,13 +71,26 @@ func (e *ExposedUnion) End() token.Pos { return e.Ctors.End() }
 type ModuleDecl struct {
 	// Name of the module.
 	Name Expr
+	// IsPort reports whether the module is a port module.
+	IsPort bool
+	// Port is the position of the "port" keyword, if the module is a port
+	// module.
+	Port token.Pos
 	// Module is the position of the "module" keyword.
 	Module token.Pos
 	// Exposing is the list of exposed identifiers, if any.
 	Exposing ExposedList
+	// Doc is the documentation comment of the module, if any. Unlike the
+	// rest of documentation comments, it comes after the module declaration.
+	Doc *CommentGroup
 }
 
-func (d *ModuleDecl) Pos() token.Pos { return d.Module }
+func (d *ModuleDecl) Pos() token.Pos {
+	if d.IsPort {
+		return d.Port
+	}
+	return d.Module
+}
 func (d *ModuleDecl) End() token.Pos { return d.Exposing.End() }
 func (d *ModuleDecl) isDecl()        {}
 
@@ -156,6 +169,8 @@ type AliasDecl struct {
 	Args []*Ident
 	// Type is the type definition of the alias type.
 	Type Type
+	// Doc is the documentation comment of the alias type, if any.
+	Doc *CommentGroup
 }
 
 func (d AliasDecl) isDecl()        {}
@@ -176,6 +191,8 @@ type UnionDecl struct {
 	Args []*Ident
 	// Ctors is the list of constructors for the union type.
 	Ctors []*Constructor
+	// Doc is the documentation comment of the union type, if any.
+	Doc *CommentGroup
 }
 
 func (d UnionDecl) isDecl()        {}
@@ -212,6 +229,24 @@ func (c Constructor) End() token.Pos {
 	return c.Name.End()
 }
 
+// PortDecl is a node representing the declaration of a port, which is the
+// way Elm code communicates with the outside world. It contains the name of
+// the port and its type.
+type PortDecl struct {
+	// Port is the position of the "port" keyword.
+	Port token.Pos
+	// Name of the port.
+	Name *Ident
+	// Colon is the position of the ":" token.
+	Colon token.Pos
+	// Type of the port.
+	Type Type
+}
+
+func (*PortDecl) isDecl()          {}
+func (d *PortDecl) Pos() token.Pos { return d.Port }
+func (d *PortDecl) End() token.Pos { return d.Type.End() }
+
 // DestructuringAssignment represents a declaration using pattern matching on
 // the expression.
 type DestructuringAssignment struct {
@@ -227,6 +262,17 @@ func (a *DestructuringAssignment) Pos() token.Pos { return a.Pattern.Pos() }
 func (a *DestructuringAssignment) End() token.Pos { return a.Expr.End() }
 func (*DestructuringAssignment) isDecl()          {}
 
+// BadDecl is a malformed declaration, which contains all the code skipped
+// by the parser after a syntax error.
+type BadDecl struct {
+	StartPos token.Pos
+	EndPos   token.Pos
+}
+
+func (d *BadDecl) Pos() token.Pos { return d.StartPos }
+func (d *BadDecl) End() token.Pos { return d.EndPos }
+func (*BadDecl) isDecl()          {}
+
 // Definition is a node representing a definition of a value. A definition can
 // also be annotated with a type annotation.
 type Definition struct {
@@ -241,6 +287,8 @@ type Definition struct {
 	Args []Pattern
 	// Body of the definition.
 	Body Expr
+	// Doc is the documentation comment of the definition, if any.
+	Doc *CommentGroup
 }
 
 func (*Definition) isDecl() {}
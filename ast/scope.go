@@ -4,6 +4,9 @@ type Scope interface {
 	Lookup(string, ObjKind) *Object
 	Resolve(string, *Ident, ObjKind)
 	Add(*Object) bool
+	// Object returns the object with the given name added to the scope, if
+	// any, without looking it up in the parent scopes.
+	Object(string) *Object
 	AddChildren(*NodeScope)
 	Children() []*NodeScope
 }
@@ -128,6 +131,10 @@ func (s *NodeScope) Add(obj *Object) bool {
 	return true
 }
 
+func (s *NodeScope) Object(name string) *Object {
+	return s.Objects[name]
+}
+
 func (s *NodeScope) Resolve(name string, id *Ident, kind ObjKind) {
 	if obj := s.Lookup(name, kind); obj != nil {
 		id.Obj = obj
@@ -52,6 +52,11 @@ type RecordType struct {
 	Lbrace token.Pos
 	// Rbrace is the position of the closing brace.
 	Rbrace token.Pos
+	// Extended is the type variable of the record being extended, if any.
+	// For example, `a` in `{ a | name : String }`.
+	Extended *VarType
+	// Pipe is the position of the "|" token, if the record is extended.
+	Pipe token.Pos
 	// Fields contains the list of fields and their types in the record.
 	Fields []*RecordField
 }
@@ -113,6 +113,10 @@ func Walk(v Visitor, node Node) {
 			Walk(v, a)
 		}
 
+	case *PortDecl:
+		Walk(v, node.Name)
+		Walk(v, node.Type)
+
 	case *DestructuringAssignment:
 		Walk(v, node.Pattern)
 		Walk(v, node.Expr)
@@ -151,6 +155,10 @@ func Walk(v Visitor, node Node) {
 		Walk(v, node.Return)
 
 	case *RecordType:
+		if node.Extended != nil {
+			Walk(v, node.Extended)
+		}
+
 		for _, f := range node.Fields {
 			Walk(v, f)
 		}
@@ -261,7 +269,7 @@ func Walk(v Visitor, node Node) {
 	case *AccessorExpr:
 		Walk(v, node.Field)
 
-	case *TupleCtor:
+	case *TupleCtor, *BadExpr, *BadDecl:
 		// nothing to do
 
 	case *ParensExpr:
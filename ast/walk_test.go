@@ -138,6 +138,7 @@ var testFile = &Module{
 
 		// type UnionT a b = Foo (List a)
 		//                 | Bar { x: b, y : b }
+		//                 | Qux { a | z : b }
 		//                 | Baz
 		mkUnionDecl(
 			mkIdent("UnionT"),
@@ -165,9 +166,31 @@ var testFile = &Module{
 					),
 				),
 			),
+			mkConstructor(
+				mkIdent("Qux"),
+				mkExtendedRecordType(
+					mkVarType(mkIdent("a")),
+					mkRecordField(
+						mkIdent("z"),
+						mkVarType(mkIdent("b")),
+					),
+				),
+			),
 			mkConstructor(mkIdent("Baz")),
 		),
 
+		// port send : String -> Cmd msg
+		mkPortDecl(
+			mkIdent("send"),
+			mkFuncType(
+				[]Type{mkNamedType(mkIdent("String"))},
+				mkNamedType(
+					mkIdent("Cmd"),
+					mkVarType(mkIdent("msg")),
+				),
+			),
+		),
+
 		// ( x, y ) = point
 		mkDestructuringAssignment(
 			mkTuplePattern(
@@ -439,6 +462,11 @@ func mkConstructor(name *Ident, args ...Type) *Constructor {
 	return &Constructor{Name: name, Args: args}
 }
 
+func mkPortDecl(name *Ident, typ Type) *PortDecl {
+	inc("*ast.PortDecl")
+	return &PortDecl{Name: name, Type: typ}
+}
+
 func mkDestructuringAssignment(pat Pattern, expr Expr) *DestructuringAssignment {
 	inc("*ast.DestructuringAssignment")
 	return &DestructuringAssignment{Pattern: pat, Expr: expr}
@@ -446,7 +474,7 @@ func mkDestructuringAssignment(pat Pattern, expr Expr) *DestructuringAssignment
 
 func mkDefinition(ann *TypeAnnotation, name *Ident, args []Pattern, body Expr) *Definition {
 	inc("*ast.Definition")
-	return &Definition{ann, name, token.NoPos, args, body}
+	return &Definition{ann, name, token.NoPos, args, body, nil}
 }
 
 func mkTypeAnnotation(name *Ident, typ Type) *TypeAnnotation {
@@ -479,6 +507,11 @@ func mkRecordType(fields ...*RecordField) *RecordType {
 	return &RecordType{Fields: fields}
 }
 
+func mkExtendedRecordType(ext *VarType, fields ...*RecordField) *RecordType {
+	inc("*ast.RecordType")
+	return &RecordType{Extended: ext, Fields: fields}
+}
+
 func mkRecordField(name *Ident, typ Type) *RecordField {
 	inc("*ast.RecordField")
 	return &RecordField{Name: name, Type: typ}
@@ -0,0 +1,228 @@
+// Package cache implements a persistent build cache that stores the modules
+// parsed in previous builds, so the modules that did not change since then
+// do not need to be scanned and parsed again.
+//
+// Every module has an entry in the cache. The header of a module, which is
+// what the first pass of the parser needs to build the dependency graph,
+// only depends on its content, so it is identified by the hash of the
+// content. The fully parsed module also depends on the modules it imports,
+// whose operator fixities change how its expressions are parsed, so it is
+// identified by a key made of the hash of the content and the interfaces of
+// all the imported modules. When a module changes, only the modules that
+// depend on its interface need to be parsed again.
+//
+// The modules are cached before being resolved. Resolving links the
+// identifiers of all the modules in a package between them, so it is
+// always done in memory.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/elm-tangram/tangram/ast"
+	"github.com/elm-tangram/tangram/operator"
+)
+
+// Dir is the path of the directory, relative to the root of a package, in
+// which its build cache is stored.
+var Dir = filepath.Join("elm-stuff", "tangram-cache")
+
+// version is the version of the format of the cache entries. It must be
+// increased every time the AST changes, so the entries written by previous
+// versions are discarded.
+const version = 1
+
+// Hash is the SHA-256 hash of some data.
+type Hash [sha256.Size]byte
+
+// Sum returns the hash of the given parts. Parts are delimited, so moving
+// data from one part to the next one changes the hash.
+func Sum(parts ...[]byte) Hash {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write(p)
+		h.Write([]byte{0})
+	}
+
+	var hash Hash
+	copy(hash[:], h.Sum(nil))
+	return hash
+}
+
+func (h Hash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+// Entry is the cached data of a single module.
+type Entry struct {
+	// Hash is the hash of the content of the module.
+	Hash Hash
+	// Header is the header of the module, which is valid as long as the
+	// content of the module has the same hash.
+	Header *Header
+	// Key identifies the content of the module and the interfaces of the
+	// modules it imports at the moment it was parsed.
+	Key Hash
+	// Module is the module fully parsed but not resolved, which is valid as
+	// long as the module has the same key.
+	Module *ast.Module
+}
+
+// Header contains everything the first pass of the parser needs to know of
+// a module.
+type Header struct {
+	// Name is the name of the module.
+	Name string
+	// Imports are the names of the modules imported, including the default
+	// imports.
+	Imports []string
+	// Fixities are the fixity declarations of the module.
+	Fixities []Fixity
+	// Interface is the hash of the parts of the module the modules that
+	// import it depend on to be parsed.
+	Interface Hash
+}
+
+// Fixity is the declaration of the associativity and precedence of an
+// operator.
+type Fixity struct {
+	Op         string
+	Assoc      operator.Associativity
+	Precedence uint
+}
+
+// Cache is a build cache stored in a directory.
+type Cache struct {
+	dir string
+}
+
+// Open opens the cache stored in the given directory, which is created if it
+// does not exist.
+func Open(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Cache{dir}, nil
+}
+
+// file is the content of the file of a cache entry.
+type file struct {
+	Version int
+	// Path is the path of the module, stored to detect collisions between
+	// the names of the files.
+	Path  string
+	Entry *Entry
+}
+
+// Get returns the entry of the module at the given path. Entries that can
+// not be read or were written by a different version are reported as not
+// found.
+func (c *Cache) Get(path string) (*Entry, bool) {
+	f, err := os.Open(c.file(path))
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+
+	var content file
+	if err := gob.NewDecoder(f).Decode(&content); err != nil {
+		return nil, false
+	}
+
+	if content.Version != version || content.Path != path || content.Entry == nil {
+		return nil, false
+	}
+
+	return content.Entry, true
+}
+
+// Put stores the entry of the module at the given path, replacing the
+// previous one. The entry is written atomically, so other processes using
+// the cache at the same time never read half-written entries.
+func (c *Cache) Put(path string, entry *Entry) error {
+	tmp, err := ioutil.TempFile(c.dir, "tmp")
+	if err != nil {
+		return err
+	}
+
+	err = gob.NewEncoder(tmp).Encode(&file{version, path, entry})
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.file(path))
+	}
+
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+func (c *Cache) file(path string) string {
+	return filepath.Join(c.dir, Sum([]byte(path)).String())
+}
+
+func init() {
+	// all the types that can be found in the interfaces of the AST need to
+	// be registered to be encoded
+	for _, node := range []ast.Node{
+		new(ast.ClosedList),
+		new(ast.OpenList),
+		new(ast.ExposedVar),
+		new(ast.ExposedUnion),
+		new(ast.ModuleDecl),
+		new(ast.ImportDecl),
+		new(ast.InfixDecl),
+		new(ast.AliasDecl),
+		new(ast.UnionDecl),
+		new(ast.Constructor),
+		new(ast.PortDecl),
+		new(ast.DestructuringAssignment),
+		new(ast.Definition),
+		new(ast.BadDecl),
+		new(ast.TypeAnnotation),
+		new(ast.Ident),
+		new(ast.SelectorExpr),
+		new(ast.BasicLit),
+		new(ast.TupleLit),
+		new(ast.FuncApp),
+		new(ast.RecordLit),
+		new(ast.FieldAssign),
+		new(ast.RecordUpdate),
+		new(ast.LetExpr),
+		new(ast.IfExpr),
+		new(ast.CaseExpr),
+		new(ast.CaseBranch),
+		new(ast.ListLit),
+		new(ast.UnaryOp),
+		new(ast.BinaryOp),
+		new(ast.AccessorExpr),
+		new(ast.TupleCtor),
+		new(ast.Lambda),
+		new(ast.ParensExpr),
+		new(ast.BadExpr),
+		new(ast.VarPattern),
+		new(ast.AnythingPattern),
+		new(ast.LiteralPattern),
+		new(ast.AliasPattern),
+		new(ast.CtorPattern),
+		new(ast.TuplePattern),
+		new(ast.RecordPattern),
+		new(ast.ListPattern),
+		new(ast.NamedType),
+		new(ast.VarType),
+		new(ast.FuncType),
+		new(ast.RecordType),
+		new(ast.RecordField),
+		new(ast.TupleType),
+	} {
+		gob.Register(node)
+	}
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/elm-tangram/tangram/ast"
+	"github.com/elm-tangram/tangram/operator"
+	"github.com/elm-tangram/tangram/token"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	require := require.New(t)
+	dir, err := ioutil.TempDir("", "cache")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	c, err := Open(filepath.Join(dir, "cache"))
+	require.NoError(err)
+
+	_, ok := c.Get("Foo.elm")
+	require.False(ok)
+
+	pos := &token.Position{Source: "Foo.elm", Offset: 7, Line: 1, Column: 8}
+	entry := &Entry{
+		Hash: Sum([]byte("module Foo exposing (..)")),
+		Header: &Header{
+			Name:     "Foo",
+			Imports:  []string{"Basics"},
+			Fixities: []Fixity{{"?", operator.Left, 2}},
+		},
+		Key: Sum([]byte("key")),
+		Module: &ast.Module{
+			Path: "Foo.elm",
+			Module: &ast.ModuleDecl{
+				Name:     ast.NewIdent("Foo", pos),
+				Exposing: &ast.OpenList{},
+			},
+			Decls: []ast.Decl{
+				&ast.Definition{
+					Name: ast.NewIdent("foo", pos),
+					Body: &ast.BasicLit{Type: ast.Int, Value: "1"},
+				},
+			},
+		},
+	}
+
+	require.NoError(c.Put("Foo.elm", entry))
+	result, ok := c.Get("Foo.elm")
+	require.True(ok)
+	require.Equal(entry, result)
+
+	_, ok = c.Get("Bar.elm")
+	require.False(ok)
+
+	require.NoError(ioutil.WriteFile(c.file("Foo.elm"), []byte("not gob"), 0644))
+	_, ok = c.Get("Foo.elm")
+	require.False(ok)
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, "cache"))
+	require.NoError(err)
+	require.Len(files, 1)
+}
+
+func TestSum(t *testing.T) {
+	require := require.New(t)
+	require.Equal(Sum([]byte("a"), []byte("b")), Sum([]byte("a"), []byte("b")))
+	require.NotEqual(Sum([]byte("ab"), []byte("")), Sum([]byte("a"), []byte("b")))
+}
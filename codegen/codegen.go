@@ -0,0 +1,234 @@
+// Package codegen generates Go code from type checked Elm packages.
+//
+// Every Elm module is turned into a Go package, every top-level definition
+// into a Go function and every union type into a struct. Native modules are
+// Go files that are copied to their own package. The generated code is a Go
+// module with no dependencies that also contains the runtime package
+// needed by the rest of packages and a main package that runs the main
+// definition of the program, if any.
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	goast "go/ast"
+	"go/format"
+	goparser "go/parser"
+	gotoken "go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/elm-tangram/tangram/ast"
+	"github.com/elm-tangram/tangram/native"
+	"github.com/elm-tangram/tangram/types"
+)
+
+// ModulePath is the path of the Go module generated. Native modules can
+// import the runtime package using ModulePath + "/rt".
+const ModulePath = "elm"
+
+const header = "// Code generated by elmc. DO NOT EDIT.\n\n"
+
+// Generate writes the Go code for all the modules in the given package,
+// which must have been type checked, to the given directory. The package
+// main of the generated code will run the main definition of the last
+// module in the package order, if it has one.
+func Generate(pkg *ast.Package, info *types.Info, dir string) error {
+	g := newGenerator(pkg, info)
+	for _, name := range pkg.Order {
+		mod := pkg.Modules[name]
+		if mod == nil {
+			continue
+		}
+
+		file, err := g.module(mod)
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(dir, moduleDir(name), packageName(name)+".go")
+		if err := writeFile(path, file); err != nil {
+			return err
+		}
+
+		if err := g.natives(mod, dir); err != nil {
+			return err
+		}
+	}
+
+	err := writeSource(filepath.Join(dir, "go.mod"), []byte("module "+ModulePath+"\n\ngo 1.16\n"))
+	if err != nil {
+		return err
+	}
+
+	err = writeSource(filepath.Join(dir, runtimePkg, runtimePkg+".go"), []byte(header+runtimeSource))
+	if err != nil {
+		return err
+	}
+
+	if len(pkg.Order) > 0 {
+		main := pkg.Order[len(pkg.Order)-1]
+		if g.hasMain(main) {
+			return writeFile(filepath.Join(dir, "main.go"), mainFile(main))
+		}
+	}
+	return nil
+}
+
+// topLevel is a top-level value or constructor declared in a module.
+type topLevel struct {
+	// module in which it is declared.
+	module string
+	// name of the Go function.
+	name string
+	// arity is the number of arguments of the function.
+	arity int
+}
+
+type generator struct {
+	pkg  *ast.Package
+	info *types.Info
+	// tops contains all the top-level values and constructors by the node
+	// of their object.
+	tops map[ast.Node]*topLevel
+}
+
+func newGenerator(pkg *ast.Package, info *types.Info) *generator {
+	g := &generator{pkg, info, make(map[ast.Node]*topLevel)}
+	for _, name := range pkg.Order {
+		if mod := pkg.Modules[name]; mod != nil {
+			g.collect(mod)
+		}
+	}
+	return g
+}
+
+// collect registers all the top-level values and constructors of the given
+// module.
+func (g *generator) collect(mod *ast.Module) {
+	for _, decl := range mod.Decls {
+		switch decl := decl.(type) {
+		case *ast.Definition:
+			g.tops[decl.Name] = &topLevel{mod.Name, valueName(decl.Name.Name), len(decl.Args)}
+		case *ast.PortDecl:
+			g.tops[decl.Name] = &topLevel{mod.Name, valueName(decl.Name.Name), 0}
+		case *ast.DestructuringAssignment:
+			for _, node := range boundNodes(decl.Pattern) {
+				g.tops[node] = &topLevel{mod.Name, valueName(boundName(node)), 0}
+			}
+		case *ast.UnionDecl:
+			for _, ctor := range decl.Ctors {
+				g.tops[ctor] = &topLevel{mod.Name, ctorName(ctor.Name.Name), len(ctor.Args)}
+			}
+		}
+	}
+}
+
+// hasMain reports whether the given module has a main definition.
+func (g *generator) hasMain(module string) bool {
+	mod := g.pkg.Modules[module]
+	if mod == nil {
+		return false
+	}
+
+	for _, decl := range mod.Decls {
+		if def, ok := decl.(*ast.Definition); ok && def.Name.Name == "main" {
+			return len(def.Args) == 0
+		}
+	}
+	return false
+}
+
+// natives copies all the native modules imported by the given module to
+// their own package in the given directory.
+func (g *generator) natives(mod *ast.Module, dir string) error {
+	for _, imp := range mod.Imports {
+		name := imp.ModuleName()
+		if !strings.HasPrefix(name, "Native.") {
+			continue
+		}
+
+		path := native.FindPath(name, mod.NativeImports)
+		if path == "" {
+			return fmt.Errorf("codegen: unable to find the source of native module %s imported by %s", name, mod.Name)
+		}
+
+		fset := gotoken.NewFileSet()
+		file, err := goparser.ParseFile(fset, path, nil, goparser.ParseComments)
+		if err != nil {
+			return fmt.Errorf("codegen: unable to parse native module %s: %s", name, err)
+		}
+
+		file.Name = ident(packageName(name))
+		var buf bytes.Buffer
+		if err := format.Node(&buf, fset, file); err != nil {
+			return fmt.Errorf("codegen: unable to format native module %s: %s", name, err)
+		}
+
+		target := filepath.Join(dir, moduleDir(name), filepath.Base(path))
+		if err := writeSource(target, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mainFile returns the file of the main package, which runs the main
+// definition of the given module.
+func mainFile(module string) *goast.File {
+	return &goast.File{
+		Name: ident("main"),
+		Decls: []goast.Decl{
+			importDecl([]string{runtimePkg, module}),
+			&goast.FuncDecl{
+				Name: ident("main"),
+				Type: &goast.FuncType{Params: new(goast.FieldList)},
+				Body: &goast.BlockStmt{List: []goast.Stmt{
+					&goast.ExprStmt{X: call(
+						runtime("Run"),
+						call(sel(ident(packageName(module)), valueName("main"))),
+					)},
+				}},
+			},
+		},
+	}
+}
+
+// importDecl returns the declaration importing the Go packages of the given
+// modules, or the runtime package.
+func importDecl(modules []string) *goast.GenDecl {
+	decl := &goast.GenDecl{Tok: gotoken.IMPORT, Lparen: 1}
+	for _, m := range modules {
+		var spec = &goast.ImportSpec{}
+		if m == runtimePkg {
+			spec.Path = stringLit(ModulePath + "/" + runtimePkg)
+		} else {
+			spec.Name = ident(packageName(m))
+			spec.Path = stringLit(packagePath(m))
+		}
+		decl.Specs = append(decl.Specs, spec)
+	}
+	return decl
+}
+
+func writeFile(path string, file *goast.File) error {
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	if err := format.Node(&buf, gotoken.NewFileSet(), file); err != nil {
+		return fmt.Errorf("codegen: unable to format %s: %s", path, err)
+	}
+	return writeSource(path, buf.Bytes())
+}
+
+func writeSource(path string, src []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("codegen: unable to create directory for %s: %s", path, err)
+	}
+
+	if err := ioutil.WriteFile(path, src, 0644); err != nil {
+		return fmt.Errorf("codegen: unable to write %s: %s", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+package codegen
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/elm-tangram/tangram/ast"
+	"github.com/elm-tangram/tangram/package"
+	"github.com/elm-tangram/tangram/parser"
+	"github.com/elm-tangram/tangram/report"
+	"github.com/elm-tangram/tangram/source"
+	"github.com/elm-tangram/tangram/types"
+	"github.com/stretchr/testify/require"
+)
+
+var projectDir = filepath.Join("..", "types", "_testdata", "project")
+
+func TestGenerateProject(t *testing.T) {
+	require := require.New(t)
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	pkg, info := check(t, filepath.Join(projectDir, "src", "Main.elm"))
+	require.NoError(Generate(pkg, info, dir))
+
+	files := []string{
+		"go.mod",
+		"main.go",
+		filepath.Join("rt", "rt.go"),
+		filepath.Join("Main", "Main.go"),
+		filepath.Join("Shape", "Shape.go"),
+		filepath.Join("Basics", "Basics.go"),
+		filepath.Join("List", "List.go"),
+		filepath.Join("Native", "Basics", "Basics.go"),
+		filepath.Join("Native", "List", "List.go"),
+	}
+
+	for _, f := range files {
+		_, err := os.Stat(filepath.Join(dir, f))
+		require.NoError(err, "file %s should have been generated", f)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "Shape", "Shape.go"))
+	require.NoError(err)
+	require.Contains(string(content), "type T_Shape struct")
+	require.Contains(string(content), "func C_Circle(arg0 interface{}) interface{}")
+
+	content, err = ioutil.ReadFile(filepath.Join(dir, "Native", "Basics", "Basics.go"))
+	require.NoError(err)
+	require.True(strings.HasPrefix(string(content), "package Native_Basics"))
+
+	assertOutput(t, dir, "shapes named figures\n")
+}
+
+func TestGenerate(t *testing.T) {
+	cases := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{"int", `main = 1 + (2 * 3)`, "7"},
+		{"float", `main = 1.5 * 2`, "3"},
+		{"string", `main = "foo" ++ "bar"`, "foobar"},
+		{"char", `main = 'a'`, "'a'"},
+		{"list", `main = List.map (\x -> x * 2) [ 1, 2, 3 ]`, "[2,4,6]"},
+		{"cons", `main = 1 :: [ 2 ]`, "[1,2]"},
+		{"tuple", `main = (1, "a")`, `(1,"a")`},
+		{"tuple ctor", `main = (,) 1 2`, "(1,2)"},
+		{"record", `main = { x = 1, y = "a" }`, `{ x = 1, y = "a" }`},
+		{"record update", `f r = { r | x = 2 }
+
+main = f { x = 1, y = 1 }`, "{ x = 2, y = 1 }"},
+		{"accessor", `x = .x
+
+main = List.map x [ { x = 1 } ]`, "[1]"},
+		{"field access", `r = { a = { b = 1 } }
+
+main = r.a.b`, "1"},
+		{"if", `main = if 1 < 2 then "yes" else "no"`, "yes"},
+		{"negate", `main = -(1 + 1)`, "-2"},
+		{"let", `main =
+    let
+        double x =
+            x * 2
+
+        (a, b) =
+            (1, 2)
+    in
+        double a + b`, "4"},
+		{"recursive let", `main =
+    let
+        count n =
+            if n > 0 then
+                1 + count (n - 1)
+            else
+                0
+    in
+        count 3`, "3"},
+		{"partial application", `add a b = a + b
+
+main = List.map (add 1) [ 1, 2 ]`, "[2,3]"},
+		{"over application", `main = always identity 1 2`, "2"},
+		{"operator section", `main = List.foldl (+) 0 [ 1, 2, 3 ]`, "6"},
+		{"union", `type Color = Red | Rgb Int Int Int
+
+main = [ Red, Rgb 1 2 3 ]`, "[Red,Rgb 1 2 3]"},
+		{"case union", `type Color = Red | Rgb Int Int Int
+
+main = case Rgb 1 2 3 of
+    Red ->
+        0
+
+    Rgb r g b ->
+        r + g + b`, "6"},
+		{"case list", `f l =
+    case l of
+        [] ->
+            "empty"
+
+        [ x ] ->
+            "one"
+
+        x :: y :: [] ->
+            "two"
+
+        _ ->
+            "many"
+
+main = List.map f [ [], [ 1 ], [ 1, 2 ], [ 1, 2, 3 ] ]`, `["empty","one","two","many"]`},
+		{"case literal", `main = case 'b' of
+    'a' ->
+        1
+
+    'b' ->
+        2
+
+    _ ->
+        3`, "2"},
+		{"case tuple and alias", `main = case (Just 1, 2) of
+    ((Just x) as m, y) ->
+        (m, x + y)
+
+    (_, y) ->
+        (Nothing, y)`, "(Just 1,3)"},
+		{"record pattern", `f { x, y } = x + y
+
+main = f { x = 1, y = 2 }`, "3"},
+		{"pattern arguments", `f (a, b) = a + b
+
+main = f (1, 2)`, "3"},
+		{"destructuring", `(a, b) = (1, 2)
+
+main = a + b`, "3"},
+		{"port", `main = 1
+
+port foo : Int -> Int`, "1"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			root := tempDir(t)
+			defer os.RemoveAll(root)
+
+			dir := filepath.Join(root, "build")
+			pkg, info := checkSource(t, filepath.Join(root, "project"), c.source)
+			require.NoError(t, Generate(pkg, info, dir))
+			assertOutput(t, dir, c.expected+"\n")
+		})
+	}
+}
+
+func TestNames(t *testing.T) {
+	require := require.New(t)
+	require.Equal("V_foo", valueName("foo"))
+	require.Equal("V_opPlusPlus", valueName("++"))
+	require.Equal("V_opPipeGt", valueName("|>"))
+	require.Equal("T_Maybe", typeName("Maybe"))
+	require.Equal("C_Just", ctorName("Just"))
+	require.Equal("Tag_Just", tagName("Just"))
+	require.Equal("type_", localName("type"))
+	require.Equal("Json_Decode", packageName("Json.Decode"))
+	require.Equal("elm/Json/Decode", packagePath("Json.Decode"))
+	require.Equal("Json/Decode", moduleDir("Json.Decode"))
+}
+
+// assertOutput runs the program generated in the given directory and checks
+// its output. It is skipped if the go tool is not available.
+func assertOutput(t *testing.T, dir, expected string) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go tool is not available to run the generated code")
+	}
+
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off", "GO111MODULE=on")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "output: %s", out)
+	require.Equal(t, expected, string(out))
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "tangram-codegen")
+	require.NoError(t, err)
+	return dir
+}
+
+// checkSource type checks the given source code, which will be the content
+// of the Main module in a copy of the test project made in the given
+// directory.
+func checkSource(t *testing.T, root, src string) (*ast.Package, *types.Info) {
+	require.NoError(t, copyDir(projectDir, root))
+
+	path := filepath.Join(root, "src", "Main.elm")
+	header := "module Main exposing (..)\n\n"
+	if strings.Contains(src, "port ") {
+		header = "port " + header
+	}
+
+	src = header + src + "\n"
+	require.NoError(t, ioutil.WriteFile(path, []byte(src), 0644))
+	return check(t, path)
+}
+
+func check(t *testing.T, path string) (*ast.Package, *types.Info) {
+	p, err := pkg.Load(filepath.Dir(path))
+	require.NoError(t, err)
+
+	cm := source.NewCodeMap(source.NewFsLoader(p))
+	defer cm.Close()
+
+	reporter := report.NewReporter(cm, report.Errors(true))
+	sess := parser.NewSession(reporter, cm, parser.NewOperatorTable(parser.FullParse))
+	result := parser.ParsePackage(sess, p, path, parser.FullParse)
+	require.NotNil(t, result, "%v", sess.Emit())
+	require.False(t, reporter.HasErrors(), "%v", sess.Emit())
+
+	info, ok := types.Check(result, reporter)
+	require.True(t, ok, "%v", sess.Emit())
+	return result, info
+}
+
+func copyDir(from, to string) error {
+	return filepath.Walk(from, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(to, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, content, 0644)
+	})
+}
@@ -0,0 +1,452 @@
+package codegen
+
+import (
+	goast "go/ast"
+	gotoken "go/token"
+	"strconv"
+	"strings"
+
+	"github.com/elm-tangram/tangram/ast"
+	"github.com/elm-tangram/tangram/native"
+	"github.com/elm-tangram/tangram/types"
+)
+
+// expr returns the Go expression that evaluates the given Elm expression.
+func (m *moduleGen) expr(expr ast.Expr) goast.Expr {
+	switch expr := expr.(type) {
+	case *ast.BasicLit:
+		return m.literal(expr)
+	case *ast.Ident:
+		return m.ident(expr)
+	case *ast.SelectorExpr:
+		return m.selector(expr)
+	case *ast.TupleLit:
+		return &goast.CompositeLit{Type: runtime("Tuple"), Elts: m.exprs(expr.Elems)}
+	case *ast.TupleCtor:
+		if expr.Elems == 0 {
+			return &goast.CompositeLit{Type: runtime("Tuple")}
+		}
+		return call(runtime("TupleCtor"), intLit(expr.Elems))
+	case *ast.ListLit:
+		return call(runtime("ListOf"), m.exprs(expr.Elems)...)
+	case *ast.FuncApp:
+		return m.apply(expr.Func, expr.Args)
+	case *ast.BinaryOp:
+		return m.apply(expr.Op, []ast.Expr{expr.Lhs, expr.Rhs})
+	case *ast.UnaryOp:
+		return call(runtime("Negate"), m.expr(expr.Expr))
+	case *ast.RecordLit:
+		return m.record(expr.Fields)
+	case *ast.RecordUpdate:
+		return call(runtime("Update"), m.ident(expr.Record), m.record(expr.Fields))
+	case *ast.AccessorExpr:
+		return call(runtime("Accessor"), stringLit(expr.Field.Name))
+	case *ast.LetExpr:
+		return m.let(expr)
+	case *ast.IfExpr:
+		return immediate([]goast.Stmt{
+			&goast.IfStmt{
+				Cond: &goast.TypeAssertExpr{X: m.expr(expr.Cond), Type: ident("bool")},
+				Body: &goast.BlockStmt{List: []goast.Stmt{ret(m.expr(expr.ThenExpr))}},
+			},
+			ret(m.expr(expr.ElseExpr)),
+		})
+	case *ast.CaseExpr:
+		return m.caseExpr(expr)
+	case *ast.Lambda:
+		return m.lambda(expr.Args, expr.Expr)
+	case *ast.ParensExpr:
+		return m.expr(expr.Expr)
+	}
+
+	m.error("unable to generate code for expression of type %T in module %s", expr, m.mod.Name)
+	return ident("nil")
+}
+
+func (m *moduleGen) exprs(exprs []ast.Expr) []goast.Expr {
+	var result = make([]goast.Expr, len(exprs))
+	for i, e := range exprs {
+		result[i] = m.expr(e)
+	}
+	return result
+}
+
+func (m *moduleGen) literal(lit *ast.BasicLit) goast.Expr {
+	switch lit.Type {
+	case ast.Int:
+		n := &goast.BasicLit{Kind: gotoken.INT, Value: lit.Value}
+		if isFloat(m.info.TypeOf(lit)) {
+			return call(ident("float64"), n)
+		}
+		return n
+	case ast.Float:
+		return &goast.BasicLit{Kind: gotoken.FLOAT, Value: lit.Value}
+	case ast.String:
+		return stringLit(unquote(lit.Value))
+	case ast.Char:
+		r := []rune(unquote(lit.Value))
+		if len(r) != 1 {
+			m.error("invalid character literal %s in module %s", lit.Value, m.mod.Name)
+			return ident("nil")
+		}
+		return &goast.BasicLit{Kind: gotoken.CHAR, Value: strconv.QuoteRune(r[0])}
+	case ast.Bool:
+		return ident(strings.ToLower(lit.Value))
+	}
+
+	m.error("unable to generate code for literal %s in module %s", lit.Value, m.mod.Name)
+	return ident("nil")
+}
+
+// ident returns a reference to the value or constructor of the given
+// identifier.
+func (m *moduleGen) ident(id *ast.Ident) goast.Expr {
+	if id.Obj == nil {
+		m.error("unresolved identifier %s in module %s", id.Name, m.mod.Name)
+		return ident("nil")
+	}
+
+	if t, ok := m.tops[id.Obj.Node]; ok {
+		if t.arity == 0 {
+			return call(m.top(t))
+		}
+		return call(runtime("Curry"), m.top(t))
+	}
+
+	return ident(localName(id.Name))
+}
+
+// selector returns the Go expression of a qualified name, an access to a
+// field of a record or both.
+func (m *moduleGen) selector(expr *ast.SelectorExpr) goast.Expr {
+	idents := selectorIdents(expr)
+	for i, id := range idents {
+		if id.Obj != nil {
+			switch id.Obj.Kind {
+			case ast.Mod:
+				continue
+			case ast.NativeMod:
+				return m.native(idents[len(idents)-1])
+			}
+		}
+
+		var result = m.ident(id)
+		for _, field := range idents[i+1:] {
+			result = call(runtime("Get"), result, stringLit(field.Name))
+		}
+		return result
+	}
+
+	m.error("invalid qualified name %s in module %s", expr, m.mod.Name)
+	return ident("nil")
+}
+
+// native returns a reference to the given function of a native module.
+// Native functions with no parameters are values, so they are called right
+// away.
+func (m *moduleGen) native(id *ast.Ident) goast.Expr {
+	var fn *native.Func
+	if id.Obj != nil {
+		fn, _ = id.Obj.Data.(*native.Func)
+	}
+
+	if fn == nil {
+		m.error("unresolved native function %s in module %s", id.Name, m.mod.Name)
+		return ident("nil")
+	}
+
+	ref := m.qualified(fn.Module, fn.GoName)
+	if fn.Arity() == 0 {
+		return call(ref)
+	}
+	return call(runtime("Curry"), ref)
+}
+
+// apply returns the application of the given arguments to a function. If
+// the function is a top-level value or constructor, it will be called
+// directly with as many arguments as it needs.
+func (m *moduleGen) apply(fn ast.Expr, args []ast.Expr) goast.Expr {
+	if t := m.topLevelOf(fn); t != nil && t.arity > 0 && len(args) >= t.arity {
+		result := call(m.top(t), m.exprs(args[:t.arity])...)
+		if len(args) == t.arity {
+			return result
+		}
+		return call(runtime("Apply"), append([]goast.Expr{result}, m.exprs(args[t.arity:])...)...)
+	}
+
+	return call(runtime("Apply"), append([]goast.Expr{m.expr(fn)}, m.exprs(args)...)...)
+}
+
+// topLevelOf returns the top-level value or constructor referenced by the
+// given expression, if it is a reference to one.
+func (m *moduleGen) topLevelOf(expr ast.Expr) *topLevel {
+	var id *ast.Ident
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		id = expr
+	case *ast.SelectorExpr:
+		idents := selectorIdents(expr)
+		for _, i := range idents[:len(idents)-1] {
+			if i.Obj == nil || i.Obj.Kind != ast.Mod {
+				return nil
+			}
+		}
+		id = idents[len(idents)-1]
+	case *ast.ParensExpr:
+		return m.topLevelOf(expr.Expr)
+	}
+
+	if id == nil || id.Obj == nil {
+		return nil
+	}
+	return m.tops[id.Obj.Node]
+}
+
+func (m *moduleGen) record(fields []*ast.FieldAssign) goast.Expr {
+	var elts = make([]goast.Expr, len(fields))
+	for i, f := range fields {
+		elts[i] = &goast.KeyValueExpr{
+			Key:   stringLit(f.Field.Name),
+			Value: m.expr(f.Expr),
+		}
+	}
+	return &goast.CompositeLit{Type: runtime("Record"), Elts: elts}
+}
+
+// lambda returns a curried function with the given arguments and body.
+func (m *moduleGen) lambda(args []ast.Pattern, body ast.Expr) goast.Expr {
+	params, stmts := m.function(args, body)
+	var fn goast.Expr
+	for i := len(params) - 1; i >= 0; i-- {
+		fn = call(runtime("Func"), funcLit(params[i:i+1], stmts))
+		stmts = []goast.Stmt{ret(fn)}
+	}
+	return fn
+}
+
+// let returns the Go expression of a let expression. All the definitions
+// are declared before being assigned, so they can reference themselves and
+// the definitions after them.
+func (m *moduleGen) let(expr *ast.LetExpr) goast.Expr {
+	var names []*goast.Ident
+	for _, decl := range expr.Decls {
+		if def, ok := decl.(*ast.Definition); ok {
+			names = append(names, ident(localName(def.Name.Name)))
+		}
+	}
+
+	var stmts []goast.Stmt
+	if len(names) > 0 {
+		stmts = append(stmts, &goast.DeclStmt{Decl: &goast.GenDecl{
+			Tok:   gotoken.VAR,
+			Specs: []goast.Spec{&goast.ValueSpec{Names: names, Type: value()}},
+		}})
+	}
+
+	for _, decl := range expr.Decls {
+		switch decl := decl.(type) {
+		case *ast.Definition:
+			name := localName(decl.Name.Name)
+			var value goast.Expr
+			if len(decl.Args) > 0 {
+				value = m.lambda(decl.Args, decl.Body)
+			} else {
+				value = m.expr(decl.Body)
+			}
+
+			stmts = append(stmts,
+				assign(name, gotoken.ASSIGN, value),
+				assign("_", gotoken.ASSIGN, ident(name)),
+			)
+		case *ast.DestructuringAssignment:
+			subject := m.tmp()
+			stmts = append(stmts, define(subject, m.expr(decl.Expr))...)
+			_, binds := m.match(decl.Pattern, ident(subject))
+			stmts = append(stmts, binds...)
+		}
+	}
+
+	return immediate(append(stmts, ret(m.expr(expr.Body))))
+}
+
+// caseExpr returns the Go expression of a case expression, which is a
+// switch with a clause for every branch.
+func (m *moduleGen) caseExpr(expr *ast.CaseExpr) goast.Expr {
+	subject := m.tmp()
+	stmts := define(subject, m.expr(expr.Expr))
+
+	var clauses []goast.Stmt
+	for _, b := range expr.Branches {
+		cond, binds := m.match(b.Pattern, ident(subject))
+		if cond == nil {
+			cond = ident("true")
+		}
+
+		clauses = append(clauses, &goast.CaseClause{
+			List: []goast.Expr{cond},
+			Body: append(binds, ret(m.expr(b.Expr))),
+		})
+	}
+
+	stmts = append(stmts,
+		&goast.SwitchStmt{Body: &goast.BlockStmt{List: clauses}},
+		&goast.ExprStmt{X: call(ident("panic"), call(runtime("NoMatch"), stringLit(m.mod.Name)))},
+	)
+	return immediate(stmts)
+}
+
+// match returns the condition the given subject needs to satisfy to match
+// the given pattern, which is nil if it always matches, and the statements
+// defining all the variables of the pattern.
+func (m *moduleGen) match(pattern ast.Pattern, subject goast.Expr) (goast.Expr, []goast.Stmt) {
+	switch pattern := pattern.(type) {
+	case *ast.AnythingPattern:
+		return nil, nil
+	case *ast.VarPattern:
+		return nil, define(localName(pattern.Name.Name), subject)
+	case *ast.LiteralPattern:
+		return call(runtime("Eq"), subject, m.literal(pattern.Literal)), nil
+	case *ast.AliasPattern:
+		cond, binds := m.match(pattern.Pattern, subject)
+		return cond, append(binds, define(localName(pattern.Name.Name), subject)...)
+	case *ast.TuplePattern:
+		var subjects = make([]goast.Expr, len(pattern.Elems))
+		for i := range pattern.Elems {
+			subjects[i] = call(runtime("Elem"), subject, intLit(i))
+		}
+		return m.matchAll(pattern.Elems, subjects)
+	case *ast.ListPattern:
+		var subjects = make([]goast.Expr, len(pattern.Elems))
+		for i := range pattern.Elems {
+			subjects[i] = call(runtime("Index"), subject, intLit(i))
+		}
+
+		cond, binds := m.matchAll(pattern.Elems, subjects)
+		length := &goast.BinaryExpr{
+			X:  call(runtime("Len"), subject),
+			Op: gotoken.EQL,
+			Y:  intLit(len(pattern.Elems)),
+		}
+		return and(length, cond), binds
+	case *ast.RecordPattern:
+		var binds []goast.Stmt
+		for _, f := range pattern.Fields {
+			if v, ok := f.(*ast.VarPattern); ok {
+				field := call(runtime("Get"), subject, stringLit(v.Name.Name))
+				binds = append(binds, define(localName(v.Name.Name), field)...)
+			}
+		}
+		return nil, binds
+	case *ast.CtorPattern:
+		return m.matchCtor(pattern, subject)
+	}
+
+	m.error("unable to generate code for pattern of type %T in module %s", pattern, m.mod.Name)
+	return nil, nil
+}
+
+func (m *moduleGen) matchAll(patterns []ast.Pattern, subjects []goast.Expr) (goast.Expr, []goast.Stmt) {
+	var cond goast.Expr
+	var binds []goast.Stmt
+	for i, p := range patterns {
+		c, b := m.match(p, subjects[i])
+		cond = and(cond, c)
+		binds = append(binds, b...)
+	}
+	return cond, binds
+}
+
+func (m *moduleGen) matchCtor(pattern *ast.CtorPattern, subject goast.Expr) (goast.Expr, []goast.Stmt) {
+	id := lastIdent(pattern.Ctor)
+	if id == nil {
+		m.error("invalid constructor pattern in module %s", m.mod.Name)
+		return nil, nil
+	}
+
+	if id.Name == "::" && len(pattern.Args) == 2 {
+		notEmpty := &goast.UnaryExpr{Op: gotoken.NOT, X: call(runtime("IsEmpty"), subject)}
+		cond, binds := m.matchAll(pattern.Args, []goast.Expr{
+			call(runtime("Head"), subject),
+			call(runtime("Tail"), subject),
+		})
+		return and(notEmpty, cond), binds
+	}
+
+	if id.Obj == nil {
+		m.error("unresolved constructor %s in module %s", id.Name, m.mod.Name)
+		return nil, nil
+	}
+
+	t, ok := m.tops[id.Obj.Node]
+	if !ok {
+		m.error("unknown constructor %s in module %s", id.Name, m.mod.Name)
+		return nil, nil
+	}
+
+	var subjects = make([]goast.Expr, len(pattern.Args))
+	for i := range pattern.Args {
+		subjects[i] = call(runtime("Arg"), subject, intLit(i))
+	}
+
+	tag := &goast.BinaryExpr{
+		X:  call(runtime("Tag"), subject),
+		Op: gotoken.EQL,
+		Y:  m.qualified(t.module, tagName(id.Name)),
+	}
+	cond, binds := m.matchAll(pattern.Args, subjects)
+	return and(tag, cond), binds
+}
+
+// isFloat reports whether the given type is Float.
+func isFloat(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Decl == nil && named.Name == types.Float.Name
+}
+
+// unquote returns the content of a string or character literal.
+func unquote(lit string) string {
+	if strings.HasPrefix(lit, `"""`) && strings.HasSuffix(lit, `"""`) && len(lit) >= 6 {
+		return lit[3 : len(lit)-3]
+	}
+
+	if lit[0] == '\'' {
+		// Go does not allow escaped double quotes in character literals
+		if lit == `'\"'` {
+			return `"`
+		}
+	}
+
+	if s, err := strconv.Unquote(lit); err == nil {
+		return s
+	}
+	return lit[1 : len(lit)-1]
+}
+
+// selectorIdents returns all the identifiers of a selector expression, in
+// the same order they are written.
+func selectorIdents(expr *ast.SelectorExpr) []*ast.Ident {
+	var idents = []*ast.Ident{expr.Selector}
+	for {
+		switch e := expr.Expr.(type) {
+		case *ast.Ident:
+			return append(idents, e)
+		case *ast.SelectorExpr:
+			idents = append(idents, e.Selector)
+			expr = e
+		default:
+			return idents
+		}
+	}
+}
+
+// lastIdent returns the last identifier of a qualified name.
+func lastIdent(expr ast.Expr) *ast.Ident {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e
+	case *ast.SelectorExpr:
+		idents := selectorIdents(e)
+		return idents[len(idents)-1]
+	}
+	return nil
+}
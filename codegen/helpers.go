@@ -0,0 +1,108 @@
+package codegen
+
+import (
+	goast "go/ast"
+	gotoken "go/token"
+	"strconv"
+)
+
+// runtimePkg is the name of the runtime package in the generated code.
+const runtimePkg = "rt"
+
+func ident(name string) *goast.Ident {
+	return goast.NewIdent(name)
+}
+
+func sel(x goast.Expr, name string) *goast.SelectorExpr {
+	return &goast.SelectorExpr{X: x, Sel: ident(name)}
+}
+
+// runtime returns a reference to the given name of the runtime package.
+func runtime(name string) *goast.SelectorExpr {
+	return sel(ident(runtimePkg), name)
+}
+
+func call(fn goast.Expr, args ...goast.Expr) *goast.CallExpr {
+	return &goast.CallExpr{Fun: fn, Args: args}
+}
+
+func stringLit(s string) *goast.BasicLit {
+	return &goast.BasicLit{Kind: gotoken.STRING, Value: strconv.Quote(s)}
+}
+
+func intLit(n int) *goast.BasicLit {
+	return &goast.BasicLit{Kind: gotoken.INT, Value: strconv.Itoa(n)}
+}
+
+// value returns the interface{} type, which is the type of all Elm values.
+// It is an identifier instead of an interface type because the printer puts
+// the braces of interfaces without positions in different lines.
+func value() goast.Expr {
+	return ident("interface{}")
+}
+
+// funcType returns the type of a function with the given parameters that
+// returns an Elm value.
+func funcType(params []*goast.Ident) *goast.FuncType {
+	var fields = new(goast.FieldList)
+	if len(params) > 0 {
+		fields.List = []*goast.Field{{Names: params, Type: value()}}
+	}
+
+	return &goast.FuncType{
+		Params:  fields,
+		Results: &goast.FieldList{List: []*goast.Field{{Type: value()}}},
+	}
+}
+
+func funcLit(params []*goast.Ident, body []goast.Stmt) *goast.FuncLit {
+	return &goast.FuncLit{
+		Type: funcType(params),
+		Body: &goast.BlockStmt{List: body},
+	}
+}
+
+// immediate returns an expression that evaluates the given statements in a
+// function that is called right away, so statements can be used as
+// expressions.
+func immediate(body []goast.Stmt) goast.Expr {
+	return call(funcLit(nil, body))
+}
+
+func ret(expr goast.Expr) goast.Stmt {
+	return &goast.ReturnStmt{Results: []goast.Expr{expr}}
+}
+
+func assign(name string, tok gotoken.Token, value goast.Expr) goast.Stmt {
+	return &goast.AssignStmt{
+		Lhs: []goast.Expr{ident(name)},
+		Tok: tok,
+		Rhs: []goast.Expr{value},
+	}
+}
+
+// define returns the statements defining a new variable with the given
+// value. The variable is marked as used, as Go does not allow unused
+// variables and there is no way to know beforehand if it will be used.
+func define(name string, value goast.Expr) []goast.Stmt {
+	return []goast.Stmt{
+		assign(name, gotoken.DEFINE, value),
+		assign("_", gotoken.ASSIGN, ident(name)),
+	}
+}
+
+// and returns the conjunction of all the given conditions that are not nil.
+// If all of them are nil, the result is nil as well.
+func and(conds ...goast.Expr) goast.Expr {
+	var result goast.Expr
+	for _, c := range conds {
+		switch {
+		case c == nil:
+		case result == nil:
+			result = c
+		default:
+			result = &goast.BinaryExpr{X: result, Op: gotoken.LAND, Y: c}
+		}
+	}
+	return result
+}
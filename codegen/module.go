@@ -0,0 +1,273 @@
+package codegen
+
+import (
+	"fmt"
+	goast "go/ast"
+	gotoken "go/token"
+	"sort"
+
+	"github.com/elm-tangram/tangram/ast"
+)
+
+// moduleGen generates the Go file of a single module.
+type moduleGen struct {
+	*generator
+	mod *ast.Module
+	// imports contains the modules whose packages are used by the file.
+	imports map[string]struct{}
+	decls   []goast.Decl
+	// tmps is the number of temporary variables already created.
+	tmps int
+	err  error
+}
+
+func (g *generator) module(mod *ast.Module) (*goast.File, error) {
+	m := &moduleGen{
+		generator: g,
+		mod:       mod,
+		imports:   make(map[string]struct{}),
+	}
+
+	for _, decl := range mod.Decls {
+		switch decl := decl.(type) {
+		case *ast.Definition:
+			m.definition(decl)
+		case *ast.DestructuringAssignment:
+			m.destructuring(decl)
+		case *ast.UnionDecl:
+			m.union(decl)
+		case *ast.PortDecl:
+			m.port(decl)
+		}
+	}
+
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if usesRuntime(m.decls) {
+		m.imports[runtimePkg] = struct{}{}
+	}
+
+	file := &goast.File{Name: ident(packageName(mod.Name))}
+	if len(m.imports) > 0 {
+		var modules = make([]string, 0, len(m.imports))
+		for name := range m.imports {
+			modules = append(modules, name)
+		}
+		sort.Strings(modules)
+		file.Decls = append(file.Decls, importDecl(modules))
+	}
+
+	file.Decls = append(file.Decls, m.decls...)
+	return file, nil
+}
+
+// definition generates a function for the given top-level definition.
+func (m *moduleGen) definition(def *ast.Definition) {
+	params, body := m.function(def.Args, def.Body)
+	m.decls = append(m.decls, &goast.FuncDecl{
+		Name: ident(valueName(def.Name.Name)),
+		Type: funcType(params),
+		Body: &goast.BlockStmt{List: body},
+	})
+}
+
+// destructuring generates a function for every variable defined by the
+// given top-level destructuring assignment.
+func (m *moduleGen) destructuring(decl *ast.DestructuringAssignment) {
+	for _, node := range boundNodes(decl.Pattern) {
+		subject := m.tmp()
+		body := define(subject, m.expr(decl.Expr))
+		_, binds := m.match(decl.Pattern, ident(subject))
+		body = append(body, binds...)
+		body = append(body, ret(ident(localName(boundName(node)))))
+
+		m.decls = append(m.decls, &goast.FuncDecl{
+			Name: ident(valueName(boundName(node))),
+			Type: funcType(nil),
+			Body: &goast.BlockStmt{List: body},
+		})
+	}
+}
+
+// union generates a struct for the given union type, a constant to identify
+// each one of its constructors and a function for each constructor.
+func (m *moduleGen) union(decl *ast.UnionDecl) {
+	name := typeName(decl.Name.Name)
+	m.decls = append(m.decls, &goast.GenDecl{
+		Tok: gotoken.TYPE,
+		Specs: []goast.Spec{&goast.TypeSpec{
+			Name: ident(name),
+			Type: &goast.StructType{Fields: &goast.FieldList{
+				List: []*goast.Field{{Type: runtime("Tagged")}},
+			}},
+		}},
+	})
+
+	if len(decl.Ctors) == 0 {
+		return
+	}
+
+	tags := &goast.GenDecl{Tok: gotoken.CONST, Lparen: 1}
+	for i, ctor := range decl.Ctors {
+		spec := &goast.ValueSpec{Names: []*goast.Ident{ident(tagName(ctor.Name.Name))}}
+		if i == 0 {
+			spec.Values = []goast.Expr{ident("iota")}
+		}
+		tags.Specs = append(tags.Specs, spec)
+	}
+	m.decls = append(m.decls, tags)
+
+	for _, ctor := range decl.Ctors {
+		var params = make([]*goast.Ident, len(ctor.Args))
+		var args = make([]goast.Expr, len(ctor.Args))
+		for i := range ctor.Args {
+			params[i] = ident(fmt.Sprintf("arg%d", i))
+			args[i] = params[i]
+		}
+
+		var argsExpr goast.Expr = ident("nil")
+		if len(args) > 0 {
+			argsExpr = &goast.CompositeLit{
+				Type: &goast.ArrayType{Elt: value()},
+				Elts: args,
+			}
+		}
+
+		tagged := &goast.CompositeLit{
+			Type: runtime("Tagged"),
+			Elts: []goast.Expr{
+				&goast.KeyValueExpr{Key: ident("Tag"), Value: ident(tagName(ctor.Name.Name))},
+				&goast.KeyValueExpr{Key: ident("Ctor"), Value: stringLit(ctor.Name.Name)},
+				&goast.KeyValueExpr{Key: ident("Args"), Value: argsExpr},
+			},
+		}
+
+		m.decls = append(m.decls, &goast.FuncDecl{
+			Name: ident(ctorName(ctor.Name.Name)),
+			Type: funcType(params),
+			Body: &goast.BlockStmt{List: []goast.Stmt{
+				ret(&goast.CompositeLit{Type: ident(name), Elts: []goast.Expr{tagged}}),
+			}},
+		})
+	}
+}
+
+// port generates a function for the given port. Ports cannot be used yet
+// in the generated code, so the function panics.
+func (m *moduleGen) port(decl *ast.PortDecl) {
+	msg := fmt.Sprintf("port %s.%s is not supported in Go yet", m.mod.Name, decl.Name.Name)
+	m.decls = append(m.decls, &goast.FuncDecl{
+		Name: ident(valueName(decl.Name.Name)),
+		Type: funcType(nil),
+		Body: &goast.BlockStmt{List: []goast.Stmt{
+			&goast.ExprStmt{X: call(ident("panic"), stringLit(msg))},
+		}},
+	})
+}
+
+// function returns the parameters and the body of a function with the given
+// arguments and body. Arguments that are not variables are destructured at
+// the start of the body.
+func (m *moduleGen) function(args []ast.Pattern, body ast.Expr) ([]*goast.Ident, []goast.Stmt) {
+	var params = make([]*goast.Ident, len(args))
+	var stmts []goast.Stmt
+	for i, arg := range args {
+		if v, ok := arg.(*ast.VarPattern); ok {
+			params[i] = ident(localName(v.Name.Name))
+			continue
+		}
+
+		params[i] = ident(fmt.Sprintf("arg%d", i))
+		_, binds := m.match(arg, params[i])
+		stmts = append(stmts, binds...)
+	}
+
+	return params, append(stmts, ret(m.expr(body)))
+}
+
+// tmp returns the name of a new temporary variable.
+func (m *moduleGen) tmp() string {
+	m.tmps++
+	return fmt.Sprintf("tmp%d", m.tmps)
+}
+
+// top returns a reference to the Go function of the given top-level value
+// or constructor.
+func (m *moduleGen) top(t *topLevel) goast.Expr {
+	return m.qualified(t.module, t.name)
+}
+
+// qualified returns a reference to the given name in the package of the
+// given module.
+func (m *moduleGen) qualified(module, name string) goast.Expr {
+	if module == m.mod.Name {
+		return ident(name)
+	}
+
+	m.imports[module] = struct{}{}
+	return sel(ident(packageName(module)), name)
+}
+
+func (m *moduleGen) error(format string, args ...interface{}) {
+	if m.err == nil {
+		m.err = fmt.Errorf("codegen: "+format, args...)
+	}
+}
+
+// usesRuntime reports whether the given declarations reference the runtime
+// package.
+func usesRuntime(decls []goast.Decl) bool {
+	var found bool
+	for _, decl := range decls {
+		goast.Inspect(decl, func(n goast.Node) bool {
+			if s, ok := n.(*goast.SelectorExpr); ok {
+				if id, ok := s.X.(*goast.Ident); ok && id.Name == runtimePkg {
+					found = true
+				}
+			}
+			return !found
+		})
+	}
+	return found
+}
+
+// boundNodes returns the nodes of all the variables defined by a pattern.
+func boundNodes(pattern ast.Pattern) []ast.Node {
+	switch pattern := pattern.(type) {
+	case *ast.VarPattern:
+		return []ast.Node{pattern}
+	case *ast.AliasPattern:
+		return append(boundNodes(pattern.Pattern), pattern)
+	case *ast.TuplePattern:
+		return boundNodesOf(pattern.Elems)
+	case *ast.ListPattern:
+		return boundNodesOf(pattern.Elems)
+	case *ast.RecordPattern:
+		return boundNodesOf(pattern.Fields)
+	case *ast.CtorPattern:
+		return boundNodesOf(pattern.Args)
+	}
+	return nil
+}
+
+func boundNodesOf(patterns []ast.Pattern) []ast.Node {
+	var nodes []ast.Node
+	for _, p := range patterns {
+		nodes = append(nodes, boundNodes(p)...)
+	}
+	return nodes
+}
+
+// boundName returns the name of the variable defined by a node returned by
+// boundNodes.
+func boundName(node ast.Node) string {
+	switch node := node.(type) {
+	case *ast.VarPattern:
+		return node.Name.Name
+	case *ast.AliasPattern:
+		return node.Name.Name
+	}
+	return "_"
+}
@@ -0,0 +1,103 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// All the names given to Elm declarations in Go have a prefix depending on
+// what they are, because Elm has different namespaces for values, types and
+// constructors, which can have the same name. Local variables have an
+// underscore as suffix so they never conflict with Go keywords, package
+// names or the variables added by the code generator.
+
+// valueName returns the Go name of a top-level value.
+func valueName(name string) string {
+	if isOp(name) {
+		return "V_op" + opName(name)
+	}
+	return "V_" + name
+}
+
+// typeName returns the Go name of an union type.
+func typeName(name string) string {
+	return "T_" + name
+}
+
+// ctorName returns the Go name of the function that creates values with the
+// constructor of the given name.
+func ctorName(name string) string {
+	return "C_" + name
+}
+
+// tagName returns the Go name of the constant identifying a constructor.
+func tagName(name string) string {
+	return "Tag_" + name
+}
+
+// localName returns the Go name of a local variable.
+func localName(name string) string {
+	return name + "_"
+}
+
+// packageName returns the name of the Go package of an Elm module.
+func packageName(module string) string {
+	return strings.Replace(module, ".", "_", -1)
+}
+
+// packagePath returns the import path of the Go package of an Elm module.
+func packagePath(module string) string {
+	return path.Join(ModulePath, moduleDir(module))
+}
+
+// moduleDir returns the directory, relative to the root of the generated
+// code, in which the Go package of an Elm module is.
+func moduleDir(module string) string {
+	return path.Join(strings.Split(module, ".")...)
+}
+
+var opNames = map[rune]string{
+	'+':  "Plus",
+	'-':  "Minus",
+	'*':  "Star",
+	'/':  "Slash",
+	'=':  "Eq",
+	'.':  "Dot",
+	'$':  "Dollar",
+	'<':  "Lt",
+	'>':  "Gt",
+	':':  "Colon",
+	'&':  "Amp",
+	'|':  "Pipe",
+	'^':  "Caret",
+	'?':  "Question",
+	'%':  "Percent",
+	'#':  "Hash",
+	'@':  "At",
+	'~':  "Tilde",
+	'!':  "Bang",
+	'\\': "Backslash",
+}
+
+// opName returns a name for the given operator that is a valid Go
+// identifier.
+func opName(op string) string {
+	var buf bytes.Buffer
+	for _, r := range op {
+		if name, ok := opNames[r]; ok {
+			buf.WriteString(name)
+		} else {
+			fmt.Fprintf(&buf, "U%x", r)
+		}
+	}
+	return buf.String()
+}
+
+func isOp(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return !unicode.IsLetter(r) && r != '_'
+}
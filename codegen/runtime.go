@@ -0,0 +1,445 @@
+package codegen
+
+// runtimeSource is the source code of the runtime package used by all the
+// generated code. It contains the representation of Elm values in Go and
+// the helpers needed to work with them.
+const runtimeSource = `// Package rt is the runtime of the Go code generated from Elm code.
+//
+// Elm values are represented with the following Go values:
+//
+//	Int          int
+//	Float        float64
+//	Bool         bool
+//	String       string
+//	Char         rune
+//	List a       *List, being nil the empty list
+//	tuples       Tuple
+//	records      Record
+//	union types  structs embedding Tagged
+//	functions    Func, curried if they have more than one argument
+package rt
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Func is an Elm function. Functions with more than one argument are
+// functions returning other functions.
+type Func func(interface{}) interface{}
+
+// Apply applies all the given arguments to the function f, one by one.
+func Apply(f interface{}, args ...interface{}) interface{} {
+	for _, arg := range args {
+		f = f.(Func)(arg)
+	}
+	return f
+}
+
+// Curry returns a curried function that calls the given Go function once
+// it has received all the arguments it needs. Arguments are converted to
+// the types of the parameters of fn if they are numbers.
+func Curry(fn interface{}) Func {
+	return curry(reflect.ValueOf(fn), nil)
+}
+
+func curry(fn reflect.Value, args []reflect.Value) Func {
+	typ := fn.Type()
+	return func(arg interface{}) interface{} {
+		var next = make([]reflect.Value, len(args)+1)
+		copy(next, args)
+		next[len(args)] = convert(arg, typ.In(len(args)))
+		if len(next) < typ.NumIn() {
+			return curry(fn, next)
+		}
+
+		out := fn.Call(next)
+		if len(out) == 0 {
+			return Tuple{}
+		}
+		return out[0].Interface()
+	}
+}
+
+func convert(arg interface{}, typ reflect.Type) reflect.Value {
+	if arg == nil {
+		return reflect.Zero(typ)
+	}
+
+	v := reflect.ValueOf(arg)
+	if v.Type().AssignableTo(typ) {
+		return v
+	}
+
+	if isNumber(v.Kind()) && isNumber(typ.Kind()) {
+		return v.Convert(typ)
+	}
+
+	panic(fmt.Sprintf("rt: cannot use value of type %s as %s", v.Type(), typ))
+}
+
+func isNumber(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// List is a non-empty Elm list. The empty list is a nil *List.
+type List struct {
+	Head interface{}
+	Tail *List
+}
+
+// ListOf returns a list with the given elements.
+func ListOf(elems ...interface{}) interface{} {
+	var l *List
+	for i := len(elems) - 1; i >= 0; i-- {
+		l = &List{elems[i], l}
+	}
+	return l
+}
+
+// Cons returns a new list with the given head and tail.
+func Cons(head, tail interface{}) interface{} {
+	return &List{head, list(tail)}
+}
+
+// IsEmpty reports whether the given list is empty.
+func IsEmpty(l interface{}) bool {
+	return list(l) == nil
+}
+
+// Head returns the first element of a non-empty list.
+func Head(l interface{}) interface{} {
+	return list(l).Head
+}
+
+// Tail returns the list after the first element of a non-empty list.
+func Tail(l interface{}) interface{} {
+	return list(l).Tail
+}
+
+// Len returns the number of elements in the given list.
+func Len(l interface{}) int {
+	var n int
+	for l := list(l); l != nil; l = l.Tail {
+		n++
+	}
+	return n
+}
+
+// Index returns the i-th element of the given list.
+func Index(l interface{}, i int) interface{} {
+	elems := list(l)
+	for ; i > 0; i-- {
+		elems = elems.Tail
+	}
+	return elems.Head
+}
+
+// list returns the given value as a list. A nil interface is the empty list
+// as well, which is what native functions receive for empty lists.
+func list(l interface{}) *List {
+	if l == nil {
+		return nil
+	}
+	return l.(*List)
+}
+
+// Tuple is an Elm tuple. The empty tuple is the unit value.
+type Tuple []interface{}
+
+// Elem returns the i-th element of the given tuple.
+func Elem(t interface{}, i int) interface{} {
+	return t.(Tuple)[i]
+}
+
+// TupleCtor returns a function that creates tuples of n elements.
+func TupleCtor(n int) Func {
+	var ctor func(Tuple) Func
+	ctor = func(elems Tuple) Func {
+		return func(arg interface{}) interface{} {
+			var next = append(append(Tuple{}, elems...), arg)
+			if len(next) == n {
+				return next
+			}
+			return ctor(next)
+		}
+	}
+	return ctor(nil)
+}
+
+// Record is an Elm record.
+type Record map[string]interface{}
+
+// Get returns the value of the given field in a record.
+func Get(r interface{}, field string) interface{} {
+	return r.(Record)[field]
+}
+
+// Update returns a copy of the given record with the given fields updated.
+func Update(r interface{}, fields Record) Record {
+	var result = make(Record)
+	for k, v := range r.(Record) {
+		result[k] = v
+	}
+
+	for k, v := range fields {
+		result[k] = v
+	}
+	return result
+}
+
+// Accessor returns a function that returns the given field of a record.
+func Accessor(field string) Func {
+	return func(r interface{}) interface{} {
+		return Get(r, field)
+	}
+}
+
+// Tagged is the value created by a constructor of an union type. Every union
+// type is a different struct embedding Tagged.
+type Tagged struct {
+	// Tag identifies the constructor used to create the value.
+	Tag int
+	// Ctor is the name of the constructor used to create the value.
+	Ctor string
+	// Args are the arguments given to the constructor.
+	Args []interface{}
+}
+
+func (t Tagged) tagged() Tagged { return t }
+
+type union interface {
+	tagged() Tagged
+}
+
+// Tag returns the tag of the constructor used to create the given value of
+// an union type.
+func Tag(v interface{}) int {
+	return v.(union).tagged().Tag
+}
+
+// Arg returns the i-th argument given to the constructor of the given value
+// of an union type.
+func Arg(v interface{}, i int) interface{} {
+	return v.(union).tagged().Args[i]
+}
+
+// Negate returns the given number negated.
+func Negate(n interface{}) interface{} {
+	switch n := n.(type) {
+	case int:
+		return -n
+	case float64:
+		return -n
+	}
+	panic(fmt.Sprintf("rt: cannot negate value of type %T", n))
+}
+
+// Eq reports whether the two given values are equal.
+func Eq(a, b interface{}) bool {
+	// the only nil values are empty lists
+	if a == nil {
+		a, b = b, a
+	}
+
+	switch a := a.(type) {
+	case nil:
+		return true
+	case int:
+		if b, ok := b.(float64); ok {
+			return float64(a) == b
+		}
+	case float64:
+		if b, ok := b.(int); ok {
+			return a == float64(b)
+		}
+	case *List:
+		b := list(b)
+		for a != nil && b != nil {
+			if !Eq(a.Head, b.Head) {
+				return false
+			}
+			a, b = a.Tail, b.Tail
+		}
+		return a == nil && b == nil
+	case Tuple:
+		b := b.(Tuple)
+		if len(a) != len(b) {
+			return false
+		}
+
+		for i := range a {
+			if !Eq(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case Record:
+		b := b.(Record)
+		if len(a) != len(b) {
+			return false
+		}
+
+		for k, v := range a {
+			if !Eq(v, b[k]) {
+				return false
+			}
+		}
+		return true
+	case union:
+		ta, tb := a.tagged(), b.(union).tagged()
+		return ta.Tag == tb.Tag && Eq(Tuple(ta.Args), Tuple(tb.Args))
+	case Func:
+		panic("rt: functions cannot be compared")
+	}
+
+	return a == b
+}
+
+// Compare returns an integer comparing two comparable values. The result is
+// 0 if a == b, a negative number if a < b and a positive number if a > b.
+func Compare(a, b interface{}) int {
+	switch a := a.(type) {
+	case nil:
+		return compareInts(0, Len(b))
+	case int:
+		if b, ok := b.(int); ok {
+			return compareInts(a, b)
+		}
+		return compareFloats(float64(a), toFloat(b))
+	case float64:
+		return compareFloats(a, toFloat(b))
+	case rune:
+		return compareInts(int(a), int(b.(rune)))
+	case string:
+		return strings.Compare(a, b.(string))
+	case *List:
+		b := list(b)
+		for a != nil && b != nil {
+			if c := Compare(a.Head, b.Head); c != 0 {
+				return c
+			}
+			a, b = a.Tail, b.Tail
+		}
+		return compareInts(Len(a), Len(b))
+	case Tuple:
+		b := b.(Tuple)
+		for i := range a {
+			if c := Compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+	panic(fmt.Sprintf("rt: cannot compare values of type %T", a))
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func toFloat(n interface{}) float64 {
+	if i, ok := n.(int); ok {
+		return float64(i)
+	}
+	return n.(float64)
+}
+
+// Show returns the string representation of the given value, just like
+// toString does in Elm.
+func Show(v interface{}) string {
+	switch v := v.(type) {
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		if v {
+			return "True"
+		}
+		return "False"
+	case string:
+		return strconv.Quote(v)
+	case rune:
+		return strconv.QuoteRune(v)
+	case *List:
+		var elems []string
+		for ; v != nil; v = v.Tail {
+			elems = append(elems, Show(v.Head))
+		}
+		return "[" + strings.Join(elems, ",") + "]"
+	case Tuple:
+		var elems = make([]string, len(v))
+		for i, el := range v {
+			elems[i] = Show(el)
+		}
+		return "(" + strings.Join(elems, ",") + ")"
+	case Record:
+		var fields = make([]string, 0, len(v))
+		for k := range v {
+			fields = append(fields, k)
+		}
+		sort.Strings(fields)
+
+		for i, k := range fields {
+			fields[i] = k + " = " + Show(v[k])
+		}
+		return "{ " + strings.Join(fields, ", ") + " }"
+	case union:
+		t := v.tagged()
+		var parts = []string{t.Ctor}
+		for _, arg := range t.Args {
+			s := Show(arg)
+			if a, ok := arg.(union); ok && len(a.tagged().Args) > 0 {
+				s = "(" + s + ")"
+			}
+			parts = append(parts, s)
+		}
+		return strings.Join(parts, " ")
+	case Func:
+		return "<function>"
+	}
+	return fmt.Sprint(v)
+}
+
+// NoMatch returns the error raised when no branch of a case expression in
+// the given module matches the value.
+func NoMatch(module string) error {
+	return fmt.Errorf("rt: no branch matched the value in a case expression of module %s", module)
+}
+
+// Run prints the given value, which is the result of the main definition of
+// an Elm program. Strings are printed as they are.
+func Run(main interface{}) {
+	if s, ok := main.(string); ok {
+		fmt.Println(s)
+		return
+	}
+	fmt.Println(Show(main))
+}
+`
@@ -0,0 +1,242 @@
+// Package docs generates the documentation of the exposed API of Elm
+// modules, both in the documentation.json format used by the Elm tooling
+// and as a static HTML site.
+//
+// Only the values, unions and aliases exposed by every module are
+// documented, along with their documentation comments. Types are written as
+// they appear in the type annotations, and the inferred types are used for
+// the values that have no type annotation.
+package docs
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/elm-tangram/tangram/ast"
+	"github.com/elm-tangram/tangram/operator"
+	"github.com/elm-tangram/tangram/printer"
+	"github.com/elm-tangram/tangram/types"
+)
+
+// Module is the documentation of a module.
+type Module struct {
+	// Name of the module.
+	Name string `json:"name"`
+	// Comment is the documentation comment of the module.
+	Comment string `json:"comment"`
+	// Aliases are the exposed type aliases.
+	Aliases []*Alias `json:"aliases"`
+	// Types are the exposed union types.
+	Types []*Union `json:"types"`
+	// Values are the exposed definitions and ports.
+	Values []*Value `json:"values"`
+	// Path is the path of the file of the module.
+	Path string `json:"-"`
+}
+
+// Alias is the documentation of a type alias.
+type Alias struct {
+	Name    string   `json:"name"`
+	Comment string   `json:"comment"`
+	Args    []string `json:"args"`
+	Type    string   `json:"type"`
+}
+
+// Union is the documentation of a union type. Only the exposed constructors
+// are in Cases.
+type Union struct {
+	Name    string   `json:"name"`
+	Comment string   `json:"comment"`
+	Args    []string `json:"args"`
+	Cases   []*Case  `json:"cases"`
+}
+
+// Case is a constructor of a union type.
+type Case struct {
+	// Name of the constructor.
+	Name string
+	// Args are the types of the constructor arguments.
+	Args []string
+}
+
+// MarshalJSON encodes the constructor as a pair of its name and the list
+// of its arguments, which is how Elm represents them.
+func (c *Case) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{c.Name, c.Args})
+}
+
+// Value is the documentation of a definition or a port. Operators also have
+// their associativity and precedence.
+type Value struct {
+	Name          string `json:"name"`
+	Comment       string `json:"comment"`
+	Type          string `json:"type"`
+	Associativity string `json:"associativity,omitempty"`
+	Precedence    *int   `json:"precedence,omitempty"`
+}
+
+// New returns the documentation of all the modules in the given package,
+// sorted by name. The package must have been type checked and info must be
+// the result of it.
+func New(pkg *ast.Package, info *types.Info) []*Module {
+	var modules []*Module
+	for _, name := range pkg.Order {
+		if mod := pkg.Modules[name]; mod != nil {
+			modules = append(modules, newModule(mod, info))
+		}
+	}
+
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].Name < modules[j].Name
+	})
+	return modules
+}
+
+func newModule(mod *ast.Module, info *types.Info) *Module {
+	m := &Module{
+		Name:    mod.Name,
+		Comment: comment(mod.Module.Doc),
+		Aliases: []*Alias{},
+		Types:   []*Union{},
+		Values:  []*Value{},
+		Path:    mod.Path,
+	}
+
+	var infixes = make(map[string]*ast.InfixDecl)
+	for _, decl := range mod.Decls {
+		if decl, ok := decl.(*ast.InfixDecl); ok {
+			infixes[decl.Op.Name] = decl
+		}
+	}
+
+	for _, decl := range mod.Decls {
+		switch decl := decl.(type) {
+		case *ast.AliasDecl:
+			if isExposed(mod, decl.Name, decl) {
+				m.Aliases = append(m.Aliases, &Alias{
+					Name:    decl.Name.Name,
+					Comment: comment(decl.Doc),
+					Args:    identNames(decl.Args),
+					Type:    printer.TypeString(decl.Type),
+				})
+			}
+		case *ast.UnionDecl:
+			if union := newUnion(mod, decl); union != nil {
+				m.Types = append(m.Types, union)
+			}
+		case *ast.Definition:
+			if !isExposed(mod, decl.Name, decl.Name) {
+				continue
+			}
+
+			value := &Value{
+				Name:    decl.Name.Name,
+				Comment: comment(decl.Doc),
+			}
+
+			if decl.Annotation != nil {
+				value.Type = printer.TypeString(decl.Annotation.Type)
+			} else if t := info.TypeOf(decl.Name); t != nil {
+				value.Type = t.String()
+			}
+
+			if infix, ok := infixes[decl.Name.Name]; ok {
+				value.Associativity = assocName(infix.Assoc)
+				precedence, _ := strconv.Atoi(infix.Precedence.Value)
+				value.Precedence = &precedence
+			}
+			m.Values = append(m.Values, value)
+		case *ast.PortDecl:
+			if isExposed(mod, decl.Name, decl.Name) {
+				m.Values = append(m.Values, &Value{
+					Name: decl.Name.Name,
+					Type: printer.TypeString(decl.Type),
+				})
+			}
+		}
+	}
+
+	return m
+}
+
+// newUnion returns the documentation of the given union type, or nil if it
+// is not exposed. A union type is exposed if the type itself or any of its
+// constructors are exposed.
+func newUnion(mod *ast.Module, decl *ast.UnionDecl) *Union {
+	union := &Union{
+		Name:    decl.Name.Name,
+		Comment: comment(decl.Doc),
+		Args:    identNames(decl.Args),
+		Cases:   []*Case{},
+	}
+
+	for _, ctor := range decl.Ctors {
+		if !isExposed(mod, ctor.Name, ctor) {
+			continue
+		}
+
+		var args = make([]string, len(ctor.Args))
+		for i, arg := range ctor.Args {
+			args[i] = printer.TypeString(arg)
+		}
+		union.Cases = append(union.Cases, &Case{ctor.Name.Name, args})
+	}
+
+	if len(union.Cases) == 0 && !isExposed(mod, decl.Name, decl) {
+		return nil
+	}
+	return union
+}
+
+// isExposed reports whether the given name is exposed by the module and
+// refers to the given node.
+func isExposed(mod *ast.Module, name *ast.Ident, node ast.Node) bool {
+	if mod.Scope == nil {
+		return false
+	}
+
+	obj, ok := mod.Scope.Exposed[name.Name]
+	return ok && obj.Node == node
+}
+
+// comment returns the text of the given documentation comment without its
+// delimiters.
+func comment(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+
+	text := doc.List[0].Text
+	return strings.TrimSuffix(strings.TrimPrefix(text, "{-|"), "-}")
+}
+
+func identNames(idents []*ast.Ident) []string {
+	var names = make([]string, len(idents))
+	for i, ident := range idents {
+		names[i] = ident.Name
+	}
+	return names
+}
+
+func assocName(assoc operator.Associativity) string {
+	switch assoc {
+	case operator.Left:
+		return "left"
+	case operator.Right:
+		return "right"
+	default:
+		return "non"
+	}
+}
+
+// WriteJSON writes the documentation of the given modules to w in the
+// documentation.json format.
+func WriteJSON(w io.Writer, modules []*Module) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(modules)
+}
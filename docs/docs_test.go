@@ -0,0 +1,224 @@
+package docs
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/elm-tangram/tangram/ast"
+	"github.com/elm-tangram/tangram/package"
+	"github.com/elm-tangram/tangram/parser"
+	"github.com/elm-tangram/tangram/report"
+	"github.com/elm-tangram/tangram/source"
+	"github.com/elm-tangram/tangram/types"
+	"github.com/stretchr/testify/require"
+)
+
+var projectDir = filepath.Join("..", "types", "_testdata", "project")
+
+const testModule = `module Main exposing (Shape(..), Point, Hidden, area, (<+>), origin)
+
+{-| Shapes and points.
+-}
+
+import Shape
+
+
+{-| A shape. -}
+type Shape
+    = Circle Float
+    | Polygon (List Point)
+
+
+type Hidden
+    = Hidden Int
+
+
+{-| A point in the plane. -}
+type alias Point =
+    { x : Float, y : Float }
+
+
+{-| Area of a shape. -}
+area : Shape -> Float
+area shape =
+    case shape of
+        Circle r ->
+            3.14 * r * r
+
+        Polygon _ ->
+            0
+
+
+origin =
+    { x = 0, y = 0 }
+
+
+{-| Adds two points. -}
+(<+>) : Point -> Point -> Point
+(<+>) a b =
+    { x = a.x + b.x, y = a.y + b.y }
+
+
+infixl 6 <+>
+
+
+secret =
+    1
+`
+
+func TestNew(t *testing.T) {
+	require := require.New(t)
+	dir, err := ioutil.TempDir("", "docs")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	modules := New(check(t, dir, testModule))
+	var main *Module
+	for _, m := range modules {
+		if m.Name == "Main" {
+			main = m
+		}
+	}
+	require.NotNil(main)
+
+	precedence := 6
+	expected := &Module{
+		Name:    "Main",
+		Comment: " Shapes and points.\n",
+		Aliases: []*Alias{
+			{"Point", " A point in the plane. ", []string{}, "{ x : Float, y : Float }"},
+		},
+		Types: []*Union{
+			{"Shape", " A shape. ", []string{}, []*Case{
+				{"Circle", []string{"Float"}},
+				{"Polygon", []string{"List Point"}},
+			}},
+			{"Hidden", "", []string{}, []*Case{}},
+		},
+		Values: []*Value{
+			{"area", " Area of a shape. ", "Shape -> Float", "", nil},
+			{"origin", "", "{ x : number, y : number1 }", "", nil},
+			{"<+>", " Adds two points. ", "Point -> Point -> Point", "left", &precedence},
+		},
+		Path: main.Path,
+	}
+	require.Equal(expected, main)
+}
+
+func TestWriteJSON(t *testing.T) {
+	require := require.New(t)
+	modules := []*Module{
+		{
+			Name:    "Foo",
+			Comment: " Foo. ",
+			Aliases: []*Alias{},
+			Types: []*Union{
+				{"Bar", "", []string{"a"}, []*Case{{"Bar", []string{"a"}}}},
+			},
+			Values: []*Value{},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(WriteJSON(&buf, modules))
+
+	var result []map[string]interface{}
+	require.NoError(json.Unmarshal(buf.Bytes(), &result))
+	require.Equal([]map[string]interface{}{
+		{
+			"name":    "Foo",
+			"comment": " Foo. ",
+			"aliases": []interface{}{},
+			"types": []interface{}{
+				map[string]interface{}{
+					"name":    "Bar",
+					"comment": "",
+					"args":    []interface{}{"a"},
+					"cases": []interface{}{
+						[]interface{}{"Bar", []interface{}{"a"}},
+					},
+				},
+			},
+			"values": []interface{}{},
+		},
+	}, result)
+}
+
+func TestWriteHTML(t *testing.T) {
+	require := require.New(t)
+	dir, err := ioutil.TempDir("", "docs")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	modules := New(check(t, dir, testModule))
+	out := filepath.Join(dir, "html")
+	require.NoError(WriteHTML(out, modules))
+
+	index, err := ioutil.ReadFile(filepath.Join(out, "index.html"))
+	require.NoError(err)
+	require.Contains(string(index), `<a href="Main.html">Main</a>`)
+	require.Contains(string(index), `<a href="Shape.html">Shape</a>`)
+
+	content, err := ioutil.ReadFile(filepath.Join(out, "Main.html"))
+	require.NoError(err)
+	require.Contains(string(content), "| Polygon (List Point)")
+	require.Contains(string(content), "(&lt;&#43;&gt;) : Point -&gt; Point -&gt; Point")
+	require.Contains(string(content), "Associativity: left, precedence: 6")
+	require.NotContains(string(content), "secret")
+}
+
+func TestModuleFile(t *testing.T) {
+	require.Equal(t, "Json-Decode.html", ModuleFile("Json.Decode"))
+	require.Equal(t, "Main.html", ModuleFile("Main"))
+}
+
+func check(t *testing.T, root, src string) (*ast.Package, *types.Info) {
+	require.NoError(t, copyDir(projectDir, root))
+
+	path := filepath.Join(root, "src", "Main.elm")
+	require.NoError(t, ioutil.WriteFile(path, []byte(src), 0644))
+
+	p, err := pkg.Load(filepath.Dir(path))
+	require.NoError(t, err)
+
+	cm := source.NewCodeMap(source.NewFsLoader(p))
+	defer cm.Close()
+
+	reporter := report.NewReporter(cm, report.Errors(true))
+	sess := parser.NewSession(reporter, cm, parser.NewOperatorTable(parser.FullParse))
+	result := parser.ParsePackage(sess, p, path, parser.FullParse)
+	require.NotNil(t, result, "%v", sess.Emit())
+	require.False(t, reporter.HasErrors(), "%v", sess.Emit())
+
+	info, ok := types.Check(result, reporter)
+	require.True(t, ok, "%v", sess.Emit())
+	return result, info
+}
+
+func copyDir(from, to string) error {
+	return filepath.Walk(from, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(to, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, content, 0644)
+	})
+}
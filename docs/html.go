@@ -0,0 +1,142 @@
+package docs
+
+import (
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WriteHTML writes a static HTML site with the documentation of the given
+// modules to the given directory. The site has an index.html page with the
+// list of modules and a page for every module, named after ModuleFile.
+func WriteHTML(dir string, modules []*Module) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	if err := writeTemplate(filepath.Join(dir, "index.html"), indexTemplate, modules); err != nil {
+		return err
+	}
+
+	for _, m := range modules {
+		if err := writeTemplate(filepath.Join(dir, ModuleFile(m.Name)), moduleTemplate, m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ModuleFile returns the name of the HTML page of the module with the given
+// name, which is the name with dashes instead of dots, as in
+// "Json-Decode.html".
+func ModuleFile(name string) string {
+	return strings.Replace(name, ".", "-", -1) + ".html"
+}
+
+func writeTemplate(path string, tpl *template.Template, data interface{}) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := tpl.Execute(f, data); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+var funcs = template.FuncMap{
+	"moduleFile": ModuleFile,
+	"valueName": func(name string) string {
+		if isOp(name) {
+			return "(" + name + ")"
+		}
+		return name
+	},
+	"atom": atom,
+}
+
+// atom wraps the given type with parenthesis if it is not a single term, as
+// the arguments of constructors need.
+func atom(typ string) string {
+	var depth int
+	for _, r := range typ {
+		switch r {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case ' ':
+			if depth == 0 {
+				return "(" + typ + ")"
+			}
+		}
+	}
+	return typ
+}
+
+func isOp(name string) bool {
+	for _, r := range name {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return false
+		}
+	}
+	return name != ""
+}
+
+const style = `
+<style>
+body { font-family: sans-serif; max-width: 860px; margin: 2em auto; color: #293c4b; }
+a { color: #1184ce; text-decoration: none; }
+pre, code { font-family: monospace; }
+.decl { margin: 2em 0; }
+.signature { background: #f5f5f5; padding: 0.5em; margin: 0; }
+.comment { white-space: pre-wrap; }
+</style>
+`
+
+var indexTemplate = template.Must(template.New("index").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Modules</title>` + style + `</head>
+<body>
+<h1>Modules</h1>
+<ul>
+{{range .}}<li><a href="{{moduleFile .Name}}">{{.Name}}</a></li>
+{{end}}</ul>
+</body>
+</html>
+`))
+
+var moduleTemplate = template.Must(template.New("module").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Name}}</title>` + style + `</head>
+<body>
+<p><a href="index.html">Modules</a></p>
+<h1>{{.Name}}</h1>
+<div class="comment">{{.Comment}}</div>
+{{range .Types}}<div class="decl" id="{{.Name}}">
+<pre class="signature">type {{.Name}}{{range .Args}} {{.}}{{end}}{{range $i, $c := .Cases}}
+    {{if $i}}|{{else}}={{end}} {{$c.Name}}{{range $c.Args}} {{atom .}}{{end}}{{end}}</pre>
+<div class="comment">{{.Comment}}</div>
+</div>
+{{end}}{{range .Aliases}}<div class="decl" id="{{.Name}}">
+<pre class="signature">type alias {{.Name}}{{range .Args}} {{.}}{{end}} =
+    {{.Type}}</pre>
+<div class="comment">{{.Comment}}</div>
+</div>
+{{end}}{{range .Values}}<div class="decl" id="{{.Name}}">
+<pre class="signature">{{valueName .Name}} : {{.Type}}</pre>
+{{if .Associativity}}<p>Associativity: {{.Associativity}}, precedence: {{.Precedence}}</p>
+{{end}}<div class="comment">{{.Comment}}</div>
+</div>
+{{end}}</body>
+</html>
+`))
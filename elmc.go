@@ -1,11 +1,538 @@
 package main
 
 import (
+	"bytes"
+	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/elm-tangram/tangram/ast"
+	"github.com/elm-tangram/tangram/cache"
+	"github.com/elm-tangram/tangram/codegen"
+	"github.com/elm-tangram/tangram/docs"
+	"github.com/elm-tangram/tangram/lsp"
+	"github.com/elm-tangram/tangram/package"
+	"github.com/elm-tangram/tangram/parser"
+	"github.com/elm-tangram/tangram/printer"
+	"github.com/elm-tangram/tangram/report"
+	"github.com/elm-tangram/tangram/source"
+	"github.com/elm-tangram/tangram/types"
+)
+
+const (
+	// exitOK is the exit code when everything went fine.
+	exitOK = 0
+	// exitDiagnostics is the exit code when errors were reported.
+	exitDiagnostics = 1
+	// exitFailure is the exit code when the command could not even run,
+	// because of a wrong usage or an unexpected error.
+	exitFailure = 2
 )
 
+// command is a subcommand of elmc.
+type command struct {
+	name  string
+	args  string
+	short string
+	run   func(cmd *command, args []string, stdout, stderr io.Writer) int
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{"build", "[flags] <file>", "check the given module and generate code for it", runBuild},
+		{"check", "[flags] <file>", "parse, resolve and check the given module and all its imports", runCheck},
+		{"docs", "[flags] <file>", "generate the documentation of the given module and all its imports", runDocs},
+		{"fmt", "[flags] <file>", "format the given module using the canonical layout", runFmt},
+		{"install", "[flags] [dir]", "install the dependencies of the package in the given directory from a local registry", runInstall},
+		{"lsp", "[flags]", "run a language server that speaks JSON-RPC over the standard input and output", runLsp},
+		{"parse", "[flags] <file>", "parse the given module and print the modules found", runParse},
+	}
+}
+
 func main() {
-	fmt.Println("CLI not implemented yet")
-	os.Exit(1)
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitFailure
+	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		usage(stdout)
+		return exitOK
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(cmd, args[1:], stdout, stderr)
+		}
+	}
+
+	fmt.Fprintf(stderr, "elmc: unknown command %q\n\n", args[0])
+	usage(stderr)
+	return exitFailure
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "elmc is the tangram compiler for Elm.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "\telmc <command> [flags] <file>")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "The commands are:")
+	fmt.Fprintln(w)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "\t%-8s %s\n", cmd.name, cmd.short)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Use "elmc <command> -h" for more information about a command.`)
+}
+
+// diagnosticFlags are the flags shared by all the commands that report
+// diagnostics to the user.
+type diagnosticFlags struct {
+	warnings bool
+	colors   bool
+	// cache reports whether the build cache of the package should be used.
+	cache bool
+	// report is the format of the diagnostics, "normal", "json" or "sarif".
+	report string
+	// context is the number of lines of code shown around the regions of
+	// the diagnostics in the normal format.
+	context int
+	// width is the maximum width of the lines of code shown in the normal
+	// format.
+	width int
+}
+
+// machineReadable reports whether the diagnostics are written in a format
+// for other programs to read, which is written to stdout. Nothing else can
+// be written to stdout in that case.
+func (d *diagnosticFlags) machineReadable() bool {
+	return d.report == "json" || d.report == "sarif"
+}
+
+// emitter returns the emitter of the diagnostics in the format given in the
+// flags, which are written to stderr or, if they are machine readable, to
+// stdout.
+func (d *diagnosticFlags) emitter(stdout, stderr io.Writer) (report.Emitter, error) {
+	if d.context < 0 || d.width < 0 {
+		return nil, fmt.Errorf("the number of context lines and the width can not be negative")
+	}
+
+	switch d.report {
+	case "normal":
+		return report.Writer(stderr, report.WriterOptions{
+			Warnings:     d.warnings,
+			Colors:       d.colors,
+			ContextLines: d.context,
+			MaxWidth:     d.width,
+		}), nil
+	case "json":
+		return report.JSON(stdout), nil
+	case "sarif":
+		return report.SARIF(stdout), nil
+	default:
+		return nil, fmt.Errorf("unknown report format %q, expecting normal, json or sarif", d.report)
+	}
+}
+
+func (cmd *command) flagSet(stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: elmc %s %s\n\n", cmd.name, cmd.args)
+		fmt.Fprintf(stderr, "elmc %s will %s.\n\nFlags:\n", cmd.name, cmd.short)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// diagnosticFlagSet returns the flag set of a command that reports
+// diagnostics, which has the diagnostic flags registered.
+func (cmd *command) diagnosticFlagSet(stderr io.Writer) (*flag.FlagSet, *diagnosticFlags) {
+	fs := cmd.flagSet(stderr)
+	var diag diagnosticFlags
+	fs.BoolVar(&diag.warnings, "warnings", true, "report warnings as well as errors")
+	fs.BoolVar(&diag.colors, "colors", true, "use colors in the reported diagnostics")
+	fs.BoolVar(&diag.cache, "cache", true, "reuse the modules parsed in previous builds that did not change")
+	fs.StringVar(&diag.report, "report", "normal", "format of the reported diagnostics, normal, or json or sarif written to stdout")
+	fs.IntVar(&diag.context, "context", report.DefaultContextLines, "number of lines of code shown around the reported regions")
+	fs.IntVar(&diag.width, "width", report.DefaultMaxWidth, "maximum width of the lines of code shown, or 0 for no limit")
+	return fs, &diag
+}
+
+// parseFlags parses the arguments with the given flag set and returns the
+// file given to the command. If the arguments are not valid, it will
+// report so and false will be returned.
+func parseFlags(fs *flag.FlagSet, args []string) (string, bool) {
+	if err := fs.Parse(args); err != nil {
+		return "", false
+	}
+
+	if fs.NArg() != 1 {
+		fmt.Fprintf(fs.Output(), "elmc %s: expecting exactly one file, got %d\n\n", fs.Name(), fs.NArg())
+		fs.Usage()
+		return "", false
+	}
+
+	return fs.Arg(0), true
+}
+
+// pass is a step of the compilation that runs after the package has been
+// parsed. It should report all the problems it finds to the given reporter
+// and return false if the following passes should not run.
+type pass func(*report.Reporter, *ast.Package) bool
+
+// typeCheck is the pass that infers and checks the types of the package.
+func typeCheck(r *report.Reporter, pkg *ast.Package) bool {
+	_, ok := types.Check(pkg, r)
+	return ok
+}
+
+// compile parses the module at the given path with the given mode and runs
+// all the given passes after that. All the diagnostics are emitted once all
+// the passes have run and the exit code for the command is returned along
+// with the parsed package.
+func compile(
+	path string,
+	mode parser.ParseMode,
+	diag *diagnosticFlags,
+	stdout, stderr io.Writer,
+	passes ...pass,
+) (*ast.Package, int) {
+	emitter, err := diag.emitter(stdout, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "elmc: %s\n", err)
+		return nil, exitFailure
+	}
+
+	pkg, err := pkg.Load(filepath.Dir(path))
+	if err != nil {
+		fmt.Fprintf(stderr, "elmc: unable to load package of %s: %s\n", path, err)
+		return nil, exitFailure
+	}
+
+	cm := source.NewCodeMap(source.NewFsLoader(pkg))
+	defer cm.Close()
+
+	reporter := report.NewReporter(cm, emitter)
+	sess := parser.NewSession(reporter, cm, parser.NewOperatorTable(mode))
+	if diag.cache {
+		// the package is built anyway if the cache can not be used
+		c, err := cache.Open(filepath.Join(pkg.Root(), cache.Dir))
+		if err != nil {
+			fmt.Fprintf(stderr, "elmc: unable to open the build cache: %s\n", err)
+		}
+		sess.Cache = c
+	}
+	result := parser.ParsePackage(sess, pkg, path, mode)
+
+	if result != nil && !sess.HasErrors() {
+		for _, p := range passes {
+			if !p(reporter, result) {
+				break
+			}
+		}
+	}
+
+	if err := sess.Emit(); err != nil {
+		fmt.Fprintf(stderr, "elmc: unable to emit diagnostics: %s\n", err)
+		return nil, exitFailure
+	}
+
+	if result == nil || sess.HasErrors() {
+		return nil, exitDiagnostics
+	}
+
+	return result, exitOK
+}
+
+func runCheck(cmd *command, args []string, stdout, stderr io.Writer) int {
+	fs, diag := cmd.diagnosticFlagSet(stderr)
+	path, ok := parseFlags(fs, args)
+	if !ok {
+		return exitFailure
+	}
+
+	_, code := compile(path, parser.FullParse, diag, stdout, stderr, typeCheck)
+	return code
+}
+
+func runInstall(cmd *command, args []string, stdout, stderr io.Writer) int {
+	fs := cmd.flagSet(stderr)
+	registry := fs.String("registry", os.Getenv("TANGRAM_REGISTRY"), "directory of the registry from which packages are installed, $TANGRAM_REGISTRY by default")
+	if err := fs.Parse(args); err != nil {
+		return exitFailure
+	}
+
+	if fs.NArg() > 1 {
+		fmt.Fprintf(stderr, "elmc install: expecting at most one directory, got %d\n\n", fs.NArg())
+		fs.Usage()
+		return exitFailure
+	}
+
+	if *registry == "" {
+		fmt.Fprintln(stderr, "elmc install: no registry given, use the -registry flag or set TANGRAM_REGISTRY")
+		return exitFailure
+	}
+
+	dir := "."
+	if fs.NArg() == 1 {
+		dir = fs.Arg(0)
+	}
+
+	p, err := pkg.Load(dir)
+	if err != nil {
+		fmt.Fprintf(stderr, "elmc install: unable to load package: %s\n", err)
+		return exitFailure
+	}
+
+	exact, err := p.Install(pkg.NewLocalRegistry(*registry))
+	if err != nil {
+		fmt.Fprintf(stderr, "elmc install: %s\n", err)
+		return exitFailure
+	}
+
+	var names = make([]string, 0, len(exact))
+	for name := range exact {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(stdout, "%s %s\n", name, exact[name])
+	}
+
+	return exitOK
+}
+
+func runLsp(cmd *command, args []string, stdout, stderr io.Writer) int {
+	fs := cmd.flagSet(stderr)
+	if err := fs.Parse(args); err != nil {
+		return exitFailure
+	}
+
+	if fs.NArg() != 0 {
+		fmt.Fprintf(stderr, "elmc lsp: expecting no arguments, got %d\n\n", fs.NArg())
+		fs.Usage()
+		return exitFailure
+	}
+
+	if err := lsp.NewServer(os.Stdin, stdout).Serve(); err != nil {
+		fmt.Fprintf(stderr, "elmc lsp: %s\n", err)
+		return exitFailure
+	}
+
+	return exitOK
+}
+
+func runParse(cmd *command, args []string, stdout, stderr io.Writer) int {
+	fs, diag := cmd.diagnosticFlagSet(stderr)
+	justModule := fs.Bool("just-module", false, "parse only the given module and not the modules it imports")
+	skipDefinitions := fs.Bool("skip-definitions", false, "parse only module declarations, imports and fixity declarations")
+	path, ok := parseFlags(fs, args)
+	if !ok {
+		return exitFailure
+	}
+
+	mode := parser.FullParse
+	if *justModule {
+		mode |= parser.JustModule
+	}
+
+	if *skipDefinitions {
+		mode |= parser.SkipDefinitions
+	}
+
+	result, code := compile(path, mode, diag, stdout, stderr)
+	if code != exitOK {
+		return code
+	}
+
+	// the modules are not listed, because the diagnostics are in stdout
+	if diag.machineReadable() {
+		return exitOK
+	}
+
+	for _, name := range result.Order {
+		mod := result.Modules[name]
+		fmt.Fprintf(
+			stdout,
+			"%s (%s): %d imports, %d declarations\n",
+			name,
+			mod.Path,
+			len(mod.Imports),
+			len(mod.Decls),
+		)
+	}
+
+	return exitOK
+}
+
+func runBuild(cmd *command, args []string, stdout, stderr io.Writer) int {
+	fs, diag := cmd.diagnosticFlagSet(stderr)
+	out := fs.String("o", "build", "directory in which the generated Go code will be written")
+	path, ok := parseFlags(fs, args)
+	if !ok {
+		return exitFailure
+	}
+
+	var info *types.Info
+	check := func(r *report.Reporter, pkg *ast.Package) bool {
+		var ok bool
+		info, ok = types.Check(pkg, r)
+		return ok
+	}
+
+	result, code := compile(path, parser.FullParse, diag, stdout, stderr, check)
+	if code != exitOK {
+		return code
+	}
+
+	if err := codegen.Generate(result, info, *out); err != nil {
+		fmt.Fprintf(stderr, "elmc build: %s\n", err)
+		return exitFailure
+	}
+
+	return exitOK
+}
+
+func runDocs(cmd *command, args []string, stdout, stderr io.Writer) int {
+	fs, diag := cmd.diagnosticFlagSet(stderr)
+	out := fs.String("o", "docs", "directory in which the documentation will be written")
+	deps := fs.Bool("deps", false, "document the modules of the dependencies as well")
+	path, ok := parseFlags(fs, args)
+	if !ok {
+		return exitFailure
+	}
+
+	var info *types.Info
+	check := func(r *report.Reporter, pkg *ast.Package) bool {
+		var ok bool
+		info, ok = types.Check(pkg, r)
+		return ok
+	}
+
+	result, code := compile(path, parser.FullParse, diag, stdout, stderr, check)
+	if code != exitOK {
+		return code
+	}
+
+	modules := docs.New(result, info)
+	if !*deps {
+		p, err := pkg.Load(filepath.Dir(path))
+		if err != nil {
+			fmt.Fprintf(stderr, "elmc docs: %s\n", err)
+			return exitFailure
+		}
+
+		var own []*docs.Module
+		for _, m := range modules {
+			if !isDependency(p.Root(), m.Path) {
+				own = append(own, m)
+			}
+		}
+		modules = own
+	}
+
+	if err := docs.WriteHTML(*out, modules); err != nil {
+		fmt.Fprintf(stderr, "elmc docs: %s\n", err)
+		return exitFailure
+	}
+
+	var buf bytes.Buffer
+	if err := docs.WriteJSON(&buf, modules); err != nil {
+		fmt.Fprintf(stderr, "elmc docs: %s\n", err)
+		return exitFailure
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(*out, "documentation.json"), buf.Bytes(), 0644); err != nil {
+		fmt.Fprintf(stderr, "elmc docs: %s\n", err)
+		return exitFailure
+	}
+
+	return exitOK
+}
+
+// isDependency reports whether the module at the given path belongs to one
+// of the dependencies installed in the elm-stuff directory of the package
+// at root.
+func isDependency(root, path string) bool {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return false
+	}
+
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+
+	return strings.HasPrefix(filepath.ToSlash(rel), "elm-stuff/")
+}
+
+func runFmt(cmd *command, args []string, stdout, stderr io.Writer) int {
+	fs := cmd.flagSet(stderr)
+	check := fs.Bool("check", false, "do not print the result and fail if the file is not formatted")
+	write := fs.Bool("w", false, "write the result to the file instead of printing it")
+	path, ok := parseFlags(fs, args)
+	if !ok {
+		return exitFailure
+	}
+
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "elmc fmt: %s\n", err)
+		return exitFailure
+	}
+
+	result, err := printer.Format(path, src)
+	if err != nil {
+		fmt.Fprintf(stderr, "elmc fmt: %s\n", err)
+		return exitDiagnostics
+	}
+
+	switch {
+	case *check:
+		if !bytes.Equal(src, result) {
+			fmt.Fprintf(stderr, "elmc fmt: %s is not formatted\n", path)
+			return exitDiagnostics
+		}
+	case *write:
+		if bytes.Equal(src, result) {
+			return exitOK
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "elmc fmt: %s\n", err)
+			return exitFailure
+		}
+
+		if err := ioutil.WriteFile(path, result, info.Mode()); err != nil {
+			fmt.Fprintf(stderr, "elmc fmt: %s\n", err)
+			return exitFailure
+		}
+	default:
+		stdout.Write(result)
+	}
+
+	return exitOK
 }
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	validProject      = filepath.Join("parser", "_testdata", "valid_fullparse", "src", "Main.elm")
+	unresolvedProject = filepath.Join("parser", "_testdata", "unresolved", "src", "Main.elm")
+	typedProject      = filepath.Join("types", "_testdata", "project", "src", "Main.elm")
+	mismatchProject   = filepath.Join("types", "_testdata", "project", "src", "Mismatch.elm")
+)
+
+// runUncached runs the given command line like run, but with the build cache
+// disabled in the commands that use it, so that the tests do not write the
+// cache inside the test data.
+func runUncached(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "build", "check", "docs", "parse":
+			args = append([]string{args[0], "-cache=false"}, args[1:]...)
+		}
+	}
+	return run(args, stdout, stderr)
+}
+
+func TestRun(t *testing.T) {
+	cases := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"no command", nil, exitFailure},
+		{"unknown command", []string{"foo"}, exitFailure},
+		{"help", []string{"help"}, exitOK},
+		{"check without file", []string{"check"}, exitFailure},
+		{"check with many files", []string{"check", "a.elm", "b.elm"}, exitFailure},
+		{"check invalid flag", []string{"check", "-foo", validProject}, exitFailure},
+		{"check not a package", []string{"check", "/Main.elm"}, exitFailure},
+		{"check invalid report format", []string{"check", "-report=xml", typedProject}, exitFailure},
+		{"check negative context", []string{"check", "-context=-1", typedProject}, exitFailure},
+		{"check without context", []string{"check", "-context=0", "-width=0", typedProject}, exitOK},
+		{"check valid", []string{"check", typedProject}, exitOK},
+		{"check valid without colors", []string{"check", "-colors=false", typedProject}, exitOK},
+		{"check with errors", []string{"check", "-colors=false", unresolvedProject}, exitDiagnostics},
+		{"check with type errors", []string{"check", "-colors=false", mismatchProject}, exitDiagnostics},
+		{"parse valid", []string{"parse", validProject}, exitOK},
+		{"parse with errors", []string{"parse", "-colors=false", unresolvedProject}, exitDiagnostics},
+		{"parse just module", []string{"parse", "--just-module", unresolvedProject}, exitOK},
+		{"install without registry", []string{"install", "-registry=", "."}, exitFailure},
+		{"install with many dirs", []string{"install", "-registry=.", "a", "b"}, exitFailure},
+		{"lsp with arguments", []string{"lsp", validProject}, exitFailure},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := runUncached(c.args, &stdout, &stderr)
+			require.Equal(t, c.code, code, "stderr: %s", stderr.String())
+		})
+	}
+}
+
+func TestRunFlags(t *testing.T) {
+	diagnostic := []string{"-cache", "-colors", "-context", "-report", "-warnings", "-width"}
+	for _, cmd := range commands {
+		t.Run(cmd.name, func(t *testing.T) {
+			require := require.New(t)
+			var stdout, stderr bytes.Buffer
+			run([]string{cmd.name, "-h"}, &stdout, &stderr)
+
+			for _, flag := range diagnostic {
+				switch cmd.name {
+				case "build", "check", "docs", "parse":
+					require.Contains(stderr.String(), flag)
+				default:
+					require.NotContains(stderr.String(), flag)
+				}
+			}
+		})
+	}
+}
+
+func TestRunBuild(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "elmc")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	var stdout, stderr bytes.Buffer
+	code := runUncached([]string{"build", "-colors=false", "-o", dir, typedProject}, &stdout, &stderr)
+	require.Equal(exitOK, code, stderr.String())
+
+	for _, f := range []string{"go.mod", "main.go", filepath.Join("Main", "Main.go")} {
+		_, err := os.Stat(filepath.Join(dir, f))
+		require.NoError(err, "file %s should have been generated", f)
+	}
+
+	code = runUncached([]string{"build", "-colors=false", "-o", dir, mismatchProject}, &stdout, &stderr)
+	require.Equal(exitDiagnostics, code)
+}
+
+func TestRunReportJSON(t *testing.T) {
+	require := require.New(t)
+
+	var stdout, stderr bytes.Buffer
+	code := runUncached([]string{"check", "-report=json", mismatchProject}, &stdout, &stderr)
+	require.Equal(exitDiagnostics, code, stderr.String())
+
+	var diagnostics []struct {
+		Type     string
+		Tag      string
+		Code     string
+		File     string
+		Overview string
+		Region   struct {
+			Start struct{ Line, Column int }
+			End   struct{ Line, Column int }
+		}
+		Snippet struct {
+			Line  int
+			Lines []string
+		}
+	}
+	require.NoError(json.Unmarshal(stdout.Bytes(), &diagnostics), stdout.String())
+	require.NotEmpty(diagnostics)
+
+	d := diagnostics[0]
+	require.Equal("error", d.Type)
+	require.Equal("TYPE ERROR", d.Tag)
+	require.Regexp("^T[0-9]{3}$", d.Code)
+	require.Equal(mismatchProject, d.File)
+	require.NotEmpty(d.Overview)
+	require.True(d.Region.Start.Line > 0)
+	require.False(
+		d.Region.End.Line < d.Region.Start.Line ||
+			(d.Region.End.Line == d.Region.Start.Line && d.Region.End.Column <= d.Region.Start.Column),
+		"region must not be empty",
+	)
+	require.Equal(d.Region.Start.Line, d.Snippet.Line)
+	require.NotEmpty(d.Snippet.Lines)
+}
+
+func TestRunReportJSONWithoutDiagnostics(t *testing.T) {
+	require := require.New(t)
+
+	var stdout, stderr bytes.Buffer
+	code := runUncached([]string{"check", "-report=json", typedProject}, &stdout, &stderr)
+	require.Equal(exitOK, code, stderr.String())
+	require.Equal("[]\n", stdout.String())
+	require.Empty(stderr.String())
+
+	stdout.Reset()
+	code = runUncached([]string{"parse", "-report=json", validProject}, &stdout, &stderr)
+	require.Equal(exitOK, code, stderr.String())
+	require.Equal("[]\n", stdout.String())
+}
+
+func TestRunReportSARIF(t *testing.T) {
+	require := require.New(t)
+
+	type sarifLog struct {
+		Version string
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string
+					Rules []struct{ ID string }
+				}
+			}
+			Results []struct {
+				RuleID    string
+				RuleIndex int
+				Level     string
+				Message   struct{ Text string }
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct{ URI string }
+						Region           struct{ StartLine, StartColumn, EndLine, EndColumn int }
+					}
+				}
+			}
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := runUncached([]string{"check", "-report=sarif", mismatchProject}, &stdout, &stderr)
+	require.Equal(exitDiagnostics, code, stderr.String())
+
+	var log sarifLog
+	require.NoError(json.Unmarshal(stdout.Bytes(), &log), stdout.String())
+	require.Equal("2.1.0", log.Version)
+	require.Len(log.Runs, 1)
+
+	r := log.Runs[0]
+	require.Equal("tangram", r.Tool.Driver.Name)
+	require.NotEmpty(r.Results)
+
+	result := r.Results[0]
+	require.Equal("TypeMismatchError", result.RuleID)
+	require.Equal("TypeMismatchError", r.Tool.Driver.Rules[result.RuleIndex].ID)
+	require.Equal("error", result.Level)
+	require.NotEmpty(result.Message.Text)
+	require.Len(result.Locations, 1)
+
+	loc := result.Locations[0].PhysicalLocation
+	require.Equal(filepath.ToSlash(mismatchProject), loc.ArtifactLocation.URI)
+	require.True(loc.Region.StartLine > 0)
+}
+
+func TestRunReportSARIFWithoutDiagnostics(t *testing.T) {
+	require := require.New(t)
+
+	var stdout, stderr bytes.Buffer
+	code := runUncached([]string{"check", "-report=sarif", typedProject}, &stdout, &stderr)
+	require.Equal(exitOK, code, stderr.String())
+
+	var log struct {
+		Runs []struct {
+			Results []interface{}
+		}
+	}
+	require.NoError(json.Unmarshal(stdout.Bytes(), &log), stdout.String())
+	require.Len(log.Runs, 1)
+	require.Len(log.Runs[0].Results, 0)
+}
+
+func TestRunParse(t *testing.T) {
+	require := require.New(t)
+
+	var stdout, stderr bytes.Buffer
+	code := runUncached([]string{"parse", "--just-module", validProject}, &stdout, &stderr)
+	require.Equal(exitOK, code, stderr.String())
+	require.Equal(
+		"Main ("+validProject+"): 9 imports, 1 declarations\n",
+		stdout.String(),
+	)
+
+	stdout.Reset()
+	code = runUncached([]string{"parse", "--skip-definitions", validProject}, &stdout, &stderr)
+	require.Equal(exitOK, code, stderr.String())
+	require.Contains(stdout.String(), "Dependency (")
+	require.Contains(stdout.String(), "Main (")
+}
+
+func TestRunFmt(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "elmc")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "Main.elm")
+	src := "module Main exposing (..)\nmain = [1,2]\n"
+	formatted := "module Main exposing (..)\n\n\nmain =\n    [ 1, 2 ]\n"
+	require.NoError(ioutil.WriteFile(path, []byte(src), 0644))
+
+	var stdout, stderr bytes.Buffer
+	code := runUncached([]string{"fmt", path}, &stdout, &stderr)
+	require.Equal(exitOK, code, stderr.String())
+	require.Equal(formatted, stdout.String())
+
+	stdout.Reset()
+	code = runUncached([]string{"fmt", "-check", path}, &stdout, &stderr)
+	require.Equal(exitDiagnostics, code)
+	require.Empty(stdout.String())
+
+	code = runUncached([]string{"fmt", "-w", path}, &stdout, &stderr)
+	require.Equal(exitOK, code, stderr.String())
+	require.Empty(stdout.String())
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(err)
+	require.Equal(formatted, string(content))
+
+	code = runUncached([]string{"fmt", "-check", path}, &stdout, &stderr)
+	require.Equal(exitOK, code, stderr.String())
+
+	require.NoError(ioutil.WriteFile(path, []byte("module Main exposing (..)\nmain = (1"), 0644))
+	code = runUncached([]string{"fmt", path}, &stdout, &stderr)
+	require.Equal(exitDiagnostics, code)
+
+	code = runUncached([]string{"fmt", filepath.Join(dir, "Missing.elm")}, &stdout, &stderr)
+	require.Equal(exitFailure, code)
+}
+
+func TestRunDocs(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "elmc")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	var stdout, stderr bytes.Buffer
+	code := runUncached([]string{"docs", "-colors=false", "-o", dir, typedProject}, &stdout, &stderr)
+	require.Equal(exitOK, code, stderr.String())
+
+	for _, f := range []string{"documentation.json", "index.html", "Main.html", "Shape.html"} {
+		_, err := os.Stat(filepath.Join(dir, f))
+		require.NoError(err, "file %s should have been generated", f)
+	}
+
+	_, err = os.Stat(filepath.Join(dir, "Maybe.html"))
+	require.True(os.IsNotExist(err), "dependencies should not be documented")
+
+	code = runUncached([]string{"docs", "-colors=false", "-deps", "-o", dir, typedProject}, &stdout, &stderr)
+	require.Equal(exitOK, code, stderr.String())
+
+	_, err = os.Stat(filepath.Join(dir, "Maybe.html"))
+	require.NoError(err)
+}
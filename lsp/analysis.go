@@ -0,0 +1,330 @@
+package lsp
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+
+	"github.com/elm-tangram/tangram/ast"
+	"github.com/elm-tangram/tangram/package"
+	"github.com/elm-tangram/tangram/parser"
+	"github.com/elm-tangram/tangram/report"
+	"github.com/elm-tangram/tangram/source"
+	"github.com/elm-tangram/tangram/token"
+	"github.com/elm-tangram/tangram/types"
+)
+
+// diagnosticSource is the source of all the diagnostics published.
+const diagnosticSource = "elmc"
+
+// analysis is the result of parsing, resolving and type checking a module
+// and all its imports.
+type analysis struct {
+	path string
+	// content is the content of the module analysed.
+	content []byte
+	// pkg is the parsed package, which is nil if it could not be parsed or
+	// resolved.
+	pkg *ast.Package
+	// reporter contains the reports of all the modules.
+	reporter *report.Reporter
+	// err is the error that happened if the module is not in a package or
+	// the analysis failed.
+	err error
+}
+
+// newAnalysis analyses the module at the given path with the given content,
+// loading the rest of files with the given code map. If the analysis panics,
+// the panic is the error of the analysis, so it is still published as a
+// diagnostic of the module.
+func newAnalysis(cm *source.CodeMap, path string, content []byte) (a *analysis) {
+	defer func() {
+		if r := recover(); r != nil {
+			a = &analysis{
+				path:    path,
+				content: content,
+				err:     fmt.Errorf("Oops, an unexpected error happened analysing the module: %v", r),
+			}
+		}
+	}()
+
+	a = &analysis{path: path, content: content}
+	p, err := pkg.Load(filepath.Dir(path))
+	if err != nil {
+		// modules outside a package can only be checked for syntax errors
+		_, a.err = parser.ParseFrom(path, bytes.NewReader(content), parser.FullParse)
+		return a
+	}
+
+	a.reporter = report.NewReporter(cm, report.Errors(true))
+	sess := parser.NewSession(a.reporter, cm, parser.NewOperatorTable(parser.FullParse))
+	a.pkg = parser.ParsePackage(sess, p, path, parser.FullParse)
+	if a.pkg != nil && !a.reporter.HasErrors() {
+		types.Check(a.pkg, a.reporter)
+	}
+	return a
+}
+
+// includes reports whether the module at the given path was part of the
+// analysis.
+func (a *analysis) includes(path string) bool {
+	return a != nil && a.module(path) != nil
+}
+
+// module returns the module at the given path, if it was parsed.
+func (a *analysis) module(path string) *ast.Module {
+	if a.pkg == nil {
+		return nil
+	}
+
+	for _, mod := range a.pkg.Modules {
+		if mod.Path == path {
+			return mod
+		}
+	}
+	return nil
+}
+
+// diagnostics returns the diagnostics found in every file by path. content
+// is used to get the content of the files.
+func (a *analysis) diagnostics(content func(string) []byte) map[string][]Diagnostic {
+	var result = make(map[string][]Diagnostic)
+	if a.reporter == nil {
+		result[a.path] = []Diagnostic{}
+		if a.err != nil {
+			result[a.path] = append(result[a.path], Diagnostic{
+				Severity: SeverityError,
+				Source:   diagnosticSource,
+				Message:  a.err.Error(),
+			})
+		}
+		return result
+	}
+
+	// the module analysed always has diagnostics, even if they are empty,
+	// so previous diagnostics are cleared in the client
+	result[a.path] = []Diagnostic{}
+	for _, path := range a.reporter.Paths() {
+		src := content(path)
+		for _, r := range a.reporter.Reports(path) {
+			start, end := r.Pos(), r.Pos()
+			if region := r.Region(); region != nil {
+				start, end = region.Start, region.End
+			}
+
+			result[path] = append(result[path], Diagnostic{
+				Range:              Range{position(src, start), position(src, end)},
+				Severity:           severity(r.Type()),
+				Code:               string(r.Code()),
+				Source:             diagnosticSource,
+				Message:            diagnosticMessage(r),
+				RelatedInformation: relatedInformation(path, src, r.Labels()),
+			})
+		}
+	}
+	return result
+}
+
+// diagnosticMessage returns the message of the given report followed by its
+// notes and hints, which have no place of their own in a diagnostic.
+func diagnosticMessage(r report.Report) string {
+	msg := r.Message()
+	for _, n := range r.Notes() {
+		msg += "\n\nNote: " + n
+	}
+	for _, h := range r.Hints() {
+		msg += "\n\nHint: " + h
+	}
+	return msg
+}
+
+// relatedInformation returns the related information of the given labels
+// of a report in the file at the given path.
+func relatedInformation(path string, src []byte, labels []report.Label) []DiagnosticRelatedInformation {
+	var result []DiagnosticRelatedInformation
+	for _, l := range labels {
+		if l.Region == nil {
+			continue
+		}
+
+		result = append(result, DiagnosticRelatedInformation{
+			Location: Location{
+				URI:   pathToURI(path),
+				Range: Range{position(src, l.Region.Start), position(src, l.Region.End)},
+			},
+			Message: l.Message,
+		})
+	}
+	return result
+}
+
+func severity(typ report.ReportType) DiagnosticSeverity {
+	switch typ {
+	case report.Warning:
+		return SeverityWarning
+	case report.Info:
+		return SeverityInformation
+	default:
+		return SeverityError
+	}
+}
+
+// span is the range of an identifier in a file.
+type span struct {
+	path       string
+	start, end token.Pos
+}
+
+func identSpan(ident *ast.Ident) span {
+	return span{ident.NamePos.Source, ident.Pos(), ident.End()}
+}
+
+// identAt returns the identifier at the given offset of the module at the
+// given path, or nil if there is none.
+func (a *analysis) identAt(path string, offset token.Pos) *ast.Ident {
+	mod := a.module(path)
+	if mod == nil {
+		return nil
+	}
+
+	var found *ast.Ident
+	ast.WalkFunc(mod, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok && found == nil && inModule(mod, ident) &&
+			ident.Pos() <= offset && offset <= ident.End() {
+			found = ident
+		}
+		return found == nil
+	})
+	return found
+}
+
+// inModule reports whether the given identifier is in the source code of
+// the module, which is not the case of the identifiers in the default
+// imports.
+func inModule(mod *ast.Module, ident *ast.Ident) bool {
+	return ident.NamePos != nil && ident.NamePos.Source == mod.Path
+}
+
+// declaration returns the span of the identifier in which the object the
+// given identifier refers to is declared. If the identifier is the name
+// being declared, its own span is returned.
+func (a *analysis) declaration(ident *ast.Ident) (span, bool) {
+	if ident.Obj != nil {
+		return objectSpan(ident.Obj)
+	}
+
+	mod := a.module(ident.NamePos.Source)
+	if mod == nil {
+		return span{}, false
+	}
+
+	if decl, ok := declaredNames(mod)[ident]; ok {
+		return identSpan(decl), true
+	}
+	return span{}, false
+}
+
+// objectSpan returns the span of the name of the node declaring the given
+// object. Builtin objects are not declared anywhere.
+func objectSpan(obj *ast.Object) (span, bool) {
+	var name *ast.Ident
+	switch node := obj.Node.(type) {
+	case *ast.Ident:
+		name = node
+	case *ast.UnionDecl:
+		name = node.Name
+	case *ast.AliasDecl:
+		name = node.Name
+	case *ast.Constructor:
+		name = node.Name
+	case *ast.VarPattern:
+		name = node.Name
+	case *ast.AliasPattern:
+		name = node.Name
+	case *ast.Module:
+		return span{node.Path, node.Module.Name.Pos(), node.Module.Name.End()}, true
+	}
+
+	if name == nil || name.NamePos == nil {
+		return span{}, false
+	}
+	return identSpan(name), true
+}
+
+// declaredNames returns the identifiers of the module that are names being
+// declared, mapped to the identifier with which the name is declared. Type
+// annotations are mapped to the name of their definition.
+func declaredNames(mod *ast.Module) map[*ast.Ident]*ast.Ident {
+	var names = make(map[*ast.Ident]*ast.Ident)
+	add := func(ident *ast.Ident) {
+		names[ident] = ident
+	}
+
+	ast.WalkFunc(mod, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.Definition:
+			add(node.Name)
+			if node.Annotation != nil {
+				names[node.Annotation.Name] = node.Name
+			}
+		case *ast.PortDecl:
+			add(node.Name)
+		case *ast.UnionDecl:
+			add(node.Name)
+		case *ast.AliasDecl:
+			add(node.Name)
+		case *ast.Constructor:
+			add(node.Name)
+		case *ast.VarPattern:
+			add(node.Name)
+		case *ast.AliasPattern:
+			add(node.Name)
+		}
+		return true
+	})
+	return names
+}
+
+// references returns the spans of all the identifiers that refer to the
+// given declaration in the modules of the analysis, including the
+// declaration itself. Modules whose path is in seen are skipped, and the
+// modules searched are added to it.
+func (a *analysis) references(decl span, seen map[string]struct{}) []span {
+	if a == nil || a.pkg == nil {
+		return nil
+	}
+
+	var refs []span
+	for _, name := range a.pkg.Order {
+		mod := a.pkg.Modules[name]
+		if mod == nil {
+			continue
+		}
+
+		if _, ok := seen[mod.Path]; ok {
+			continue
+		}
+		seen[mod.Path] = struct{}{}
+
+		names := declaredNames(mod)
+		ast.WalkFunc(mod, func(node ast.Node) bool {
+			ident, ok := node.(*ast.Ident)
+			if !ok || !inModule(mod, ident) {
+				return true
+			}
+
+			var sp span
+			if ident.Obj != nil {
+				sp, ok = objectSpan(ident.Obj)
+			} else if name, isDecl := names[ident]; isDecl {
+				sp, ok = identSpan(name), true
+			}
+
+			if ok && sp == decl {
+				refs = append(refs, identSpan(ident))
+			}
+			return true
+		})
+	}
+	return refs
+}
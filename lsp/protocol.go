@@ -0,0 +1,199 @@
+package lsp
+
+import (
+	"net/url"
+	"path/filepath"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/elm-tangram/tangram/token"
+)
+
+// The types in this file are the subset of the Language Server Protocol
+// types used by the server.
+
+// Position is a zero-based line and character offset in a document. The
+// character offset is measured in UTF-16 code units.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a range between two positions in a document.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location is a range inside a document.
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// DiagnosticSeverity is the severity of a diagnostic.
+type DiagnosticSeverity int
+
+// Severities of the diagnostics.
+const (
+	SeverityError       DiagnosticSeverity = 1
+	SeverityWarning     DiagnosticSeverity = 2
+	SeverityInformation DiagnosticSeverity = 3
+)
+
+// Diagnostic is a problem found in a document.
+type Diagnostic struct {
+	Range              Range                          `json:"range"`
+	Severity           DiagnosticSeverity             `json:"severity"`
+	Code               string                         `json:"code,omitempty"`
+	Source             string                         `json:"source"`
+	Message            string                         `json:"message"`
+	RelatedInformation []DiagnosticRelatedInformation `json:"relatedInformation,omitempty"`
+}
+
+// DiagnosticRelatedInformation is a location related to a diagnostic, such
+// as the original declaration of a name declared twice.
+type DiagnosticRelatedInformation struct {
+	Location Location `json:"location"`
+	Message  string   `json:"message"`
+}
+
+// PublishDiagnosticsParams are the params of the
+// textDocument/publishDiagnostics notification.
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// TextDocumentItem is a document opened by the client.
+type TextDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Version    int    `json:"version"`
+	Text       string `json:"text"`
+}
+
+// TextDocumentIdentifier identifies a document.
+type TextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+// TextDocumentContentChangeEvent is a change in a document. Only full
+// changes are supported, so Text is the whole content of the document.
+type TextDocumentContentChangeEvent struct {
+	Text string `json:"text"`
+}
+
+// DidOpenTextDocumentParams are the params of the textDocument/didOpen
+// notification.
+type DidOpenTextDocumentParams struct {
+	TextDocument TextDocumentItem `json:"textDocument"`
+}
+
+// DidChangeTextDocumentParams are the params of the textDocument/didChange
+// notification.
+type DidChangeTextDocumentParams struct {
+	TextDocument   TextDocumentIdentifier           `json:"textDocument"`
+	ContentChanges []TextDocumentContentChangeEvent `json:"contentChanges"`
+}
+
+// DidCloseTextDocumentParams are the params of the textDocument/didClose
+// notification.
+type DidCloseTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+// FileEvent is a change in a file watched by the client.
+type FileEvent struct {
+	URI string `json:"uri"`
+}
+
+// DidChangeWatchedFilesParams are the params of the
+// workspace/didChangeWatchedFiles notification.
+type DidChangeWatchedFilesParams struct {
+	Changes []FileEvent `json:"changes"`
+}
+
+// TextDocumentPositionParams are the params of the requests about a
+// position in a document.
+type TextDocumentPositionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+// ReferenceParams are the params of the textDocument/references request.
+type ReferenceParams struct {
+	TextDocumentPositionParams
+	Context struct {
+		IncludeDeclaration bool `json:"includeDeclaration"`
+	} `json:"context"`
+}
+
+// InitializeResult is the result of the initialize request.
+type InitializeResult struct {
+	Capabilities ServerCapabilities `json:"capabilities"`
+}
+
+// ServerCapabilities are the features supported by the server.
+type ServerCapabilities struct {
+	TextDocumentSync   int  `json:"textDocumentSync"`
+	DefinitionProvider bool `json:"definitionProvider"`
+	ReferencesProvider bool `json:"referencesProvider"`
+}
+
+// syncFull is the kind of document synchronization in which the client
+// sends the whole content of a document every time it changes.
+const syncFull = 1
+
+// uriToPath returns the path of the file with the given URI.
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.Clean(filepath.FromSlash(u.Path))
+}
+
+// pathToURI returns the URI of the file at the given path.
+func pathToURI(path string) string {
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return u.String()
+}
+
+// position returns the position of the given offset in the content.
+func position(content []byte, offset token.Pos) Position {
+	if int(offset) > len(content) {
+		offset = token.Pos(len(content))
+	}
+
+	var pos Position
+	var start int
+	for i := 0; i < int(offset); i++ {
+		if content[i] == '\n' {
+			pos.Line++
+			start = i + 1
+		}
+	}
+
+	for _, r := range string(content[start:offset]) {
+		pos.Character += len(utf16.Encode([]rune{r}))
+	}
+	return pos
+}
+
+// offset returns the offset of the given position in the content.
+func offset(content []byte, pos Position) token.Pos {
+	var i, line int
+	for ; i < len(content) && line < pos.Line; i++ {
+		if content[i] == '\n' {
+			line++
+		}
+	}
+
+	for char := 0; i < len(content) && content[i] != '\n' && char < pos.Character; {
+		r, size := utf8.DecodeRune(content[i:])
+		char += len(utf16.Encode([]rune{r}))
+		i += size
+	}
+	return token.Pos(i)
+}
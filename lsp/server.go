@@ -0,0 +1,363 @@
+// Package lsp implements a Language Server Protocol server for Elm, built on
+// top of the same parser, resolver and type checker used by the compiler.
+//
+// The server speaks JSON-RPC over any reader and writer, usually the
+// standard input and output of the process. Every time a document is opened
+// or changed, the package it belongs to is parsed again with the contents of
+// the open documents, and all the diagnostics found are published. Go to
+// definition and find references are served using the objects the resolver
+// links to every identifier, in the last version of the package that could
+// be resolved.
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/elm-tangram/tangram/source"
+)
+
+// ErrExitWithoutShutdown is returned by Serve when the client sends the exit
+// notification without having requested the server to shut down first.
+var ErrExitWithoutShutdown = errors.New("lsp: exit without shutdown")
+
+// Server is a language server for Elm.
+type Server struct {
+	conn *conn
+	// overlay contains the content of the open documents.
+	overlay *source.OverlayLoader
+	// cm is the code map shared by all the analyses, so the files that did
+	// not change do not need to be loaded again.
+	cm *source.CodeMap
+	// docs are the open documents by path.
+	docs map[string]*document
+	// shutdown reports whether the client requested the server to shut
+	// down.
+	shutdown bool
+}
+
+// document is a document opened by the client.
+type document struct {
+	path    string
+	content []byte
+	// analysis is the result of the last analysis of the document.
+	analysis *analysis
+	// resolved is the last analysis of the document in which its package
+	// could be resolved, which is used to find declarations and references
+	// while the document has errors. It is the last analysis if the
+	// package was never resolved.
+	resolved *analysis
+	// published are the paths for which diagnostics were published after
+	// the last analysis of the document.
+	published map[string]struct{}
+}
+
+// NewServer creates a new server that reads messages from r and writes
+// messages to w.
+func NewServer(r io.Reader, w io.Writer) *Server {
+	overlay := source.NewOverlayLoader()
+	return &Server{
+		conn:    newConn(r, w),
+		overlay: overlay,
+		cm:      source.NewCodeMap(overlay),
+		docs:    make(map[string]*document),
+	}
+}
+
+// Serve handles the messages of the client until it sends the exit
+// notification or the connection is closed. Every message is handled before
+// reading the next one.
+func (s *Server) Serve() error {
+	defer s.cm.Close()
+
+	for {
+		msg, err := s.conn.read()
+		if err == io.EOF {
+			return nil
+		}
+
+		if rerr, ok := err.(*responseError); ok {
+			if err := s.conn.reply(nil, nil, rerr); err != nil {
+				return err
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return ErrExitWithoutShutdown
+			}
+			return nil
+		}
+
+		if err := s.handle(msg); err != nil {
+			return err
+		}
+	}
+}
+
+// handle handles the given request or notification. Only errors writing to
+// the client are returned, the rest of errors are sent to the client.
+// Panics are recovered, so a bug handling a message does not stop the
+// server: they are sent as an internal error of the request, or ignored if
+// the message is a notification, which has no response.
+func (s *Server) handle(msg *message) (err error) {
+	defer func() {
+		if r := recover(); r != nil && msg.ID != nil {
+			err = s.conn.reply(msg.ID, nil, &responseError{
+				codeInternalError,
+				fmt.Sprintf("internal error handling %s: %v", msg.Method, r),
+			})
+		}
+	}()
+
+	if msg.ID == nil {
+		return s.handleNotification(msg)
+	}
+	return s.handleRequest(msg)
+}
+
+func (s *Server) handleRequest(msg *message) error {
+	var result interface{}
+	var err *responseError
+	switch msg.Method {
+	case "initialize":
+		result = &InitializeResult{ServerCapabilities{
+			TextDocumentSync:   syncFull,
+			DefinitionProvider: true,
+			ReferencesProvider: true,
+		}}
+	case "shutdown":
+		s.shutdown = true
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		if err = decodeParams(msg, &params); err == nil {
+			result = s.definition(params)
+		}
+	case "textDocument/references":
+		var params ReferenceParams
+		if err = decodeParams(msg, &params); err == nil {
+			result = s.references(params)
+		}
+	default:
+		err = &responseError{codeMethodNotFound, "method not found: " + msg.Method}
+	}
+
+	return s.conn.reply(msg.ID, result, err)
+}
+
+func (s *Server) handleNotification(msg *message) error {
+	switch msg.Method {
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if decodeParams(msg, &params) == nil {
+			path := uriToPath(params.TextDocument.URI)
+			s.docs[path] = &document{path: path}
+			return s.update(path, []byte(params.TextDocument.Text))
+		}
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if decodeParams(msg, &params) == nil && len(params.ContentChanges) > 0 {
+			path := uriToPath(params.TextDocument.URI)
+			if _, ok := s.docs[path]; ok {
+				changes := params.ContentChanges
+				return s.update(path, []byte(changes[len(changes)-1].Text))
+			}
+		}
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if decodeParams(msg, &params) == nil {
+			path := uriToPath(params.TextDocument.URI)
+			delete(s.docs, path)
+			s.overlay.Remove(path)
+			s.cm.Remove(path)
+		}
+	case "workspace/didChangeWatchedFiles":
+		var params DidChangeWatchedFilesParams
+		if decodeParams(msg, &params) == nil && len(params.Changes) > 0 {
+			for _, change := range params.Changes {
+				path := uriToPath(change.URI)
+				if _, ok := s.docs[path]; !ok {
+					s.cm.Remove(path)
+				}
+			}
+
+			// any file changed may be a module imported by the open
+			// documents or the manifest of their package
+			for _, doc := range s.docs {
+				if err := s.analyze(doc); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// notifications have no response, so malformed and unknown
+	// notifications are ignored
+	return nil
+}
+
+func decodeParams(msg *message, params interface{}) *responseError {
+	if err := json.Unmarshal(msg.Params, params); err != nil {
+		return &responseError{codeInvalidParams, err.Error()}
+	}
+	return nil
+}
+
+// update sets the content of the open document at the given path and
+// analyses it again, along with the rest of open documents that depend on
+// it.
+func (s *Server) update(path string, content []byte) error {
+	s.overlay.Set(path, content)
+	s.cm.Remove(path)
+	s.docs[path].content = content
+
+	for _, doc := range s.docs {
+		if doc.path == path || doc.analysis.includes(path) || doc.resolved.includes(path) {
+			if err := s.analyze(doc); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// analyze analyses the given document and publishes the diagnostics found.
+// The diagnostics published in the previous analysis of the document that
+// are not found anymore are cleared.
+func (s *Server) analyze(doc *document) error {
+	doc.analysis = newAnalysis(s.cm, doc.path, doc.content)
+	if doc.analysis.pkg != nil || doc.resolved == nil {
+		doc.resolved = doc.analysis
+	}
+
+	published := make(map[string]struct{})
+	for path, diagnostics := range doc.analysis.diagnostics(s.content) {
+		published[path] = struct{}{}
+		if err := s.publish(path, diagnostics); err != nil {
+			return err
+		}
+	}
+
+	for path := range doc.published {
+		if _, ok := published[path]; !ok {
+			if err := s.publish(path, []Diagnostic{}); err != nil {
+				return err
+			}
+		}
+	}
+
+	doc.published = published
+	return nil
+}
+
+func (s *Server) publish(path string, diagnostics []Diagnostic) error {
+	return s.conn.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{
+		URI:         pathToURI(path),
+		Diagnostics: diagnostics,
+	})
+}
+
+// content returns the content of the file at the given path, either from
+// the open documents or from the file system.
+func (s *Server) content(path string) []byte {
+	if doc, ok := s.docs[path]; ok {
+		return doc.content
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return content
+}
+
+// definition returns the location of the declaration of the identifier at
+// the given position, or nil if there is none. The position is looked up in
+// the last analysis of the document that could be resolved.
+func (s *Server) definition(params TextDocumentPositionParams) *Location {
+	path := uriToPath(params.TextDocument.URI)
+	doc, ok := s.docs[path]
+	if !ok {
+		return nil
+	}
+
+	a := doc.resolved
+	ident := a.identAt(path, offset(a.content, params.Position))
+	if ident == nil {
+		return nil
+	}
+
+	decl, ok := a.declaration(ident)
+	if !ok {
+		return nil
+	}
+
+	loc := s.location(a, decl)
+	return &loc
+}
+
+// references returns the locations of all the identifiers in the open
+// documents and the modules they import that refer to the same declaration
+// as the identifier at the given position.
+func (s *Server) references(params ReferenceParams) []Location {
+	var locations = []Location{}
+	path := uriToPath(params.TextDocument.URI)
+	doc, ok := s.docs[path]
+	if !ok {
+		return locations
+	}
+
+	ident := doc.resolved.identAt(path, offset(doc.resolved.content, params.Position))
+	if ident == nil {
+		return locations
+	}
+
+	decl, ok := doc.resolved.declaration(ident)
+	if !ok {
+		return locations
+	}
+
+	// the analysis of the document has priority over the rest because it
+	// is the one the declaration was found in
+	var analyses = []*analysis{doc.resolved}
+	for _, other := range s.docs {
+		if other != doc {
+			analyses = append(analyses, other.resolved)
+		}
+	}
+
+	seen := make(map[string]struct{})
+	for _, a := range analyses {
+		for _, ref := range a.references(decl, seen) {
+			if ref != decl || params.Context.IncludeDeclaration {
+				locations = append(locations, s.location(a, ref))
+			}
+		}
+	}
+
+	return locations
+}
+
+// location returns the location of the given span found in the given
+// analysis. The span is in the content the module analysed had at the
+// time, which may not be the current one.
+func (s *Server) location(a *analysis, sp span) Location {
+	content := s.content(sp.path)
+	if sp.path == a.path {
+		content = a.content
+	}
+
+	return Location{
+		URI: pathToURI(sp.path),
+		Range: Range{
+			Start: position(content, sp.start),
+			End:   position(content, sp.end),
+		},
+	}
+}
@@ -0,0 +1,442 @@
+package lsp
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elm-tangram/tangram/token"
+	"github.com/stretchr/testify/require"
+)
+
+var projectDir = filepath.Join("..", "types", "_testdata", "project")
+
+// fakeClient is a client that talks to a server running in the same
+// process.
+type fakeClient struct {
+	t      *testing.T
+	server *Server
+	conn   *conn
+	w      io.Closer
+	nextID int
+	// responses are the responses received, by request ID.
+	responses chan *message
+	// notifications are the notifications received.
+	notifications chan *message
+	// done receives the result of the server once it stops.
+	done chan error
+}
+
+func newFakeClient(t *testing.T) *fakeClient {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	c := &fakeClient{
+		t:             t,
+		server:        NewServer(serverIn, serverOut),
+		conn:          newConn(clientIn, clientOut),
+		w:             clientOut,
+		responses:     make(chan *message, 16),
+		notifications: make(chan *message, 64),
+		done:          make(chan error, 1),
+	}
+
+	go func() {
+		c.done <- c.server.Serve()
+		serverOut.Close()
+	}()
+
+	// messages are read all the time so the server never blocks writing
+	go func() {
+		for {
+			msg, err := c.conn.read()
+			if err != nil {
+				close(c.responses)
+				close(c.notifications)
+				return
+			}
+
+			if msg.ID != nil {
+				c.responses <- msg
+			} else {
+				c.notifications <- msg
+			}
+		}
+	}()
+
+	return c
+}
+
+func (c *fakeClient) call(method string, params interface{}, result interface{}) *responseError {
+	c.nextID++
+	id := json.RawMessage(strings.TrimSpace(string(mustMarshal(c.t, c.nextID))))
+	require.NoError(c.t, c.conn.write(&message{
+		ID:     &id,
+		Method: method,
+		Params: mustMarshal(c.t, params),
+	}))
+
+	select {
+	case msg := <-c.responses:
+		require.NotNil(c.t, msg, "connection closed")
+		require.Equal(c.t, string(id), string(*msg.ID))
+		if msg.Error != nil {
+			return msg.Error
+		}
+
+		if result != nil {
+			require.NoError(c.t, json.Unmarshal(msg.Result, result))
+		}
+		return nil
+	case <-time.After(10 * time.Second):
+		c.t.Fatalf("timeout waiting for the response to %s", method)
+		return nil
+	}
+}
+
+func (c *fakeClient) notify(method string, params interface{}) {
+	require.NoError(c.t, c.conn.write(&message{
+		Method: method,
+		Params: mustMarshal(c.t, params),
+	}))
+}
+
+// diagnostics returns the next diagnostics published for the given URI,
+// skipping the diagnostics of other documents.
+func (c *fakeClient) diagnostics(uri string) []Diagnostic {
+	for {
+		select {
+		case msg := <-c.notifications:
+			require.NotNil(c.t, msg, "connection closed")
+			require.Equal(c.t, "textDocument/publishDiagnostics", msg.Method)
+
+			var params PublishDiagnosticsParams
+			require.NoError(c.t, json.Unmarshal(msg.Params, &params))
+			if params.URI == uri {
+				return params.Diagnostics
+			}
+		case <-time.After(10 * time.Second):
+			c.t.Fatalf("timeout waiting for the diagnostics of %s", uri)
+			return nil
+		}
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) json.RawMessage {
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return data
+}
+
+func TestServer(t *testing.T) {
+	require := require.New(t)
+	dir, err := ioutil.TempDir("", "lsp")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	dir, err = filepath.Abs(dir)
+	require.NoError(err)
+	require.NoError(copyDir(projectDir, dir))
+
+	mainPath := filepath.Join(dir, "src", "Main.elm")
+	shapePath := filepath.Join(dir, "src", "Shape.elm")
+	mainURI := pathToURI(mainPath)
+	shapeURI := pathToURI(shapePath)
+	content, err := ioutil.ReadFile(mainPath)
+	require.NoError(err)
+
+	c := newFakeClient(t)
+
+	var init InitializeResult
+	require.Nil(c.call("initialize", map[string]interface{}{}, &init))
+	require.Equal(ServerCapabilities{syncFull, true, true}, init.Capabilities)
+	c.notify("initialized", map[string]interface{}{})
+
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{mainURI, "elm", 1, string(content)},
+	})
+	require.Empty(c.diagnostics(mainURI))
+
+	broken := strings.Replace(string(content), `"figures"`, `figures`, 1)
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   TextDocumentIdentifier{mainURI},
+		ContentChanges: []TextDocumentContentChangeEvent{{broken}},
+	})
+	diagnostics := c.diagnostics(mainURI)
+	require.Len(diagnostics, 1)
+	require.Equal(SeverityError, diagnostics[0].Severity)
+	require.Equal(Position{41, 18}, diagnostics[0].Range.Start)
+	require.Contains(diagnostics[0].Message, "figures")
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   TextDocumentIdentifier{mainURI},
+		ContentChanges: []TextDocumentContentChangeEvent{{string(content)}},
+	})
+	require.Empty(c.diagnostics(mainURI))
+
+	// area in "area shape + acc"
+	var loc *Location
+	require.Nil(c.call("textDocument/definition", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{mainURI},
+		Position:     Position{20, 32},
+	}, &loc))
+	require.Equal(&Location{shapeURI, Range{Position{9, 0}, Position{9, 4}}}, loc)
+
+	// model in "{ model | name = name }"
+	require.Nil(c.call("textDocument/definition", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{mainURI},
+		Position:     Position{25, 7},
+	}, &loc))
+	require.Equal(&Location{mainURI, Range{Position{24, 12}, Position{24, 17}}}, loc)
+
+	// a literal has no definition
+	loc = nil
+	require.Nil(c.call("textDocument/definition", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{mainURI},
+		Position:     Position{13, 25},
+	}, &loc))
+	require.Nil(loc)
+
+	var refs []Location
+	require.Nil(c.call("textDocument/references", ReferenceParams{
+		TextDocumentPositionParams: TextDocumentPositionParams{
+			TextDocument: TextDocumentIdentifier{mainURI},
+			Position:     Position{20, 32},
+		},
+	}, &refs))
+	require.Equal([]Location{
+		{shapeURI, Range{Position{8, 0}, Position{8, 4}}},
+		{mainURI, Range{Position{20, 30}, Position{20, 34}}},
+	}, refs)
+
+	var params ReferenceParams
+	params.TextDocument = TextDocumentIdentifier{mainURI}
+	params.Position = Position{20, 32}
+	params.Context.IncludeDeclaration = true
+	require.Nil(c.call("textDocument/references", params, &refs))
+	require.Len(refs, 3)
+	require.Contains(refs, Location{shapeURI, Range{Position{9, 0}, Position{9, 4}}})
+
+	rerr := c.call("textDocument/hover", TextDocumentPositionParams{}, nil)
+	require.NotNil(rerr)
+	require.Equal(codeMethodNotFound, rerr.Code)
+
+	c.notify("textDocument/didClose", DidCloseTextDocumentParams{TextDocumentIdentifier{mainURI}})
+	require.Nil(c.call("shutdown", nil, nil))
+	c.notify("exit", nil)
+	require.NoError(<-c.done)
+}
+
+func TestServerWithErrors(t *testing.T) {
+	require := require.New(t)
+	dir, err := ioutil.TempDir("", "lsp")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	dir, err = filepath.Abs(dir)
+	require.NoError(err)
+	require.NoError(copyDir(projectDir, dir))
+
+	mainPath := filepath.Join(dir, "src", "Main.elm")
+	shapePath := filepath.Join(dir, "src", "Shape.elm")
+	mainURI := pathToURI(mainPath)
+	shapeURI := pathToURI(shapePath)
+	content, err := ioutil.ReadFile(mainPath)
+	require.NoError(err)
+
+	c := newFakeClient(t)
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{mainURI, "elm", 1, string(content)},
+	})
+	require.Empty(c.diagnostics(mainURI))
+
+	// the declarations are still found while the module is being written
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   TextDocumentIdentifier{mainURI},
+		ContentChanges: []TextDocumentContentChangeEvent{{string(content) + "\nfoo = (1\n"}},
+	})
+	require.NotEmpty(c.diagnostics(mainURI))
+
+	// area in "area shape + acc"
+	var loc *Location
+	require.Nil(c.call("textDocument/definition", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{mainURI},
+		Position:     Position{20, 32},
+	}, &loc))
+	require.Equal(&Location{shapeURI, Range{Position{9, 0}, Position{9, 4}}}, loc)
+
+	var refs []Location
+	require.Nil(c.call("textDocument/references", ReferenceParams{
+		TextDocumentPositionParams: TextDocumentPositionParams{
+			TextDocument: TextDocumentIdentifier{mainURI},
+			Position:     Position{20, 32},
+		},
+	}, &refs))
+	require.Equal([]Location{
+		{shapeURI, Range{Position{8, 0}, Position{8, 4}}},
+		{mainURI, Range{Position{20, 30}, Position{20, 34}}},
+	}, refs)
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   TextDocumentIdentifier{mainURI},
+		ContentChanges: []TextDocumentContentChangeEvent{{string(content)}},
+	})
+	require.Empty(c.diagnostics(mainURI))
+
+	// the open documents are analysed again when a module they import
+	// changes on disk
+	shape, err := ioutil.ReadFile(shapePath)
+	require.NoError(err)
+	broken := strings.Replace(string(shape), "width * height", "width * (height", 1)
+	require.NoError(ioutil.WriteFile(shapePath, []byte(broken), 0644))
+	c.notify("workspace/didChangeWatchedFiles", DidChangeWatchedFilesParams{
+		Changes: []FileEvent{{shapeURI}},
+	})
+	diagnostics := c.diagnostics(shapeURI)
+	require.Len(diagnostics, 1)
+	require.Equal("S003", diagnostics[0].Code)
+
+	require.NoError(ioutil.WriteFile(shapePath, shape, 0644))
+	c.notify("workspace/didChangeWatchedFiles", DidChangeWatchedFilesParams{
+		Changes: []FileEvent{{shapeURI}},
+	})
+	require.Empty(c.diagnostics(shapeURI))
+
+	require.Nil(c.call("shutdown", nil, nil))
+	c.notify("exit", nil)
+	require.NoError(<-c.done)
+}
+
+func TestServerExitWithoutShutdown(t *testing.T) {
+	c := newFakeClient(t)
+	c.notify("exit", nil)
+	require.Equal(t, ErrExitWithoutShutdown, <-c.done)
+}
+
+func TestServerOutsidePackage(t *testing.T) {
+	require := require.New(t)
+	dir, err := ioutil.TempDir("", "lsp")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	uri := pathToURI(filepath.Join(dir, "Main.elm"))
+	c := newFakeClient(t)
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{uri, "elm", 1, "module Main exposing (..)\nx = (1"},
+	})
+
+	diagnostics := c.diagnostics(uri)
+	require.Len(diagnostics, 1)
+	require.Equal(SeverityError, diagnostics[0].Severity)
+
+	require.Nil(c.call("shutdown", nil, nil))
+	c.notify("exit", nil)
+	require.NoError(<-c.done)
+}
+
+func TestServerMalformedModule(t *testing.T) {
+	require := require.New(t)
+	dir, err := ioutil.TempDir("", "lsp")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	dir, err = filepath.Abs(dir)
+	require.NoError(err)
+	require.NoError(copyDir(projectDir, dir))
+
+	uri := pathToURI(filepath.Join(dir, "src", "Main.elm"))
+	c := newFakeClient(t)
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{uri, "elm", 1, "module Main expos 3.ing (..)\n\nx = 1\n"},
+	})
+
+	diagnostics := c.diagnostics(uri)
+	require.NotEmpty(diagnostics)
+	require.Equal(SeverityError, diagnostics[0].Severity)
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   TextDocumentIdentifier{uri},
+		ContentChanges: []TextDocumentContentChangeEvent{{"module Main exposing (..)\n\nx = 1\n"}},
+	})
+	require.Empty(c.diagnostics(uri))
+
+	require.Nil(c.call("shutdown", nil, nil))
+	c.notify("exit", nil)
+	require.NoError(<-c.done)
+}
+
+func TestServerPanic(t *testing.T) {
+	require := require.New(t)
+	c := newFakeClient(t)
+
+	// a document that was never analysed makes the server panic, which
+	// can only happen because of a bug
+	path := filepath.Join(string(filepath.Separator), "Main.elm")
+	c.server.docs[path] = &document{path: path}
+
+	rerr := c.call("textDocument/definition", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{pathToURI(path)},
+	}, nil)
+	require.NotNil(rerr)
+	require.Equal(codeInternalError, rerr.Code)
+
+	require.Nil(c.call("shutdown", nil, nil))
+	c.notify("exit", nil)
+	require.NoError(<-c.done)
+}
+
+func TestPosition(t *testing.T) {
+	require := require.New(t)
+	content := []byte("ab\ncéd\n\U0001F600x")
+
+	cases := []struct {
+		offset int
+		pos    Position
+	}{
+		{0, Position{0, 0}},
+		{2, Position{0, 2}},
+		{3, Position{1, 0}},
+		{6, Position{1, 2}},
+		{8, Position{2, 0}},
+		{12, Position{2, 2}},
+	}
+
+	for _, c := range cases {
+		require.Equal(c.pos, position(content, token.Pos(c.offset)), "offset %d", c.offset)
+		require.Equal(token.Pos(c.offset), offset(content, c.pos), "position %v", c.pos)
+	}
+}
+
+func TestURI(t *testing.T) {
+	require.Equal(t, "file:///foo/bar%20baz.elm", pathToURI("/foo/bar baz.elm"))
+	require.Equal(t, "/foo/bar baz.elm", uriToPath("file:///foo/bar%20baz.elm"))
+}
+
+func copyDir(from, to string) error {
+	return filepath.Walk(from, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(to, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, content, 0644)
+	})
+}
@@ -0,0 +1,317 @@
+// Package native loads the native modules imported by Elm modules.
+//
+// A native module is a Go file whose path is the name of the module with
+// ".go" as extension. For example, Native.Basics is found at
+// src/Native/Basics.go. All the exported functions without receiver in the
+// file are the values of the module, which can be used from Elm with the
+// name of the function starting with a lowercase letter. For example, the
+// function FloatDiv is used from Elm as Native.Basics.floatDiv.
+//
+// Parameters and results of native functions can only be of the following
+// types, which correspond to these Elm types:
+//
+//	int          Int
+//	float64      Float
+//	string       String
+//	rune         Char
+//	bool         Bool
+//	interface{}  any type
+//
+// Types of the runtime package (imported as "elm/rt") can be used as well,
+// and they are considered any type. Functions can return at most one value,
+// and functions that return nothing return the unit value. Functions with
+// no parameters are values instead of functions.
+package native
+
+import (
+	"fmt"
+	goast "go/ast"
+	goparser "go/parser"
+	gotoken "go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/elm-tangram/tangram/ast"
+)
+
+// RuntimePath is the import path of the runtime package of the generated
+// code, which native modules can import.
+const RuntimePath = "elm/rt"
+
+// Kind is the kind of the Go type of a parameter or result of a native
+// function.
+type Kind byte
+
+const (
+	// Any is a type that can hold any Elm value.
+	Any Kind = iota
+	// Int is the Go int type.
+	Int
+	// Float is the Go float64 type.
+	Float
+	// String is the Go string type.
+	String
+	// Char is the Go rune type.
+	Char
+	// Bool is the Go bool type.
+	Bool
+	// Unit is the result of a function that returns nothing.
+	Unit
+)
+
+var kindStrings = [...]string{
+	"interface{}",
+	"int",
+	"float64",
+	"string",
+	"rune",
+	"bool",
+	"()",
+}
+
+func (k Kind) String() string {
+	if int(k) >= len(kindStrings) {
+		return "invalid"
+	}
+	return kindStrings[k]
+}
+
+var basicKinds = map[string]Kind{
+	"int":     Int,
+	"float64": Float,
+	"string":  String,
+	"rune":    Char,
+	"int32":   Char,
+	"bool":    Bool,
+}
+
+// Func is an exported function of a native module.
+type Func struct {
+	// Module is the name of the native module.
+	Module string
+	// Name is the name of the function in Elm.
+	Name string
+	// GoName is the name of the function in Go.
+	GoName string
+	// Params are the kinds of the parameters of the function.
+	Params []Kind
+	// Result is the kind of the result of the function.
+	Result Kind
+}
+
+// Arity returns the number of arguments of the function.
+func (f *Func) Arity() int {
+	return len(f.Params)
+}
+
+// Module is a native module.
+type Module struct {
+	// Name is the name of the module, such as Native.Basics.
+	Name string
+	// Path is the path to the Go file of the module.
+	Path string
+	// Funcs contains all the functions of the module by their Elm name.
+	Funcs map[string]*Func
+
+	objects map[string]*ast.Object
+}
+
+// Lookup returns the object of the function with the given Elm name. The
+// node of the object is nil and its data is the *Func. The same object is
+// returned every time for the same name. If there is no such function, nil
+// is returned.
+func (m *Module) Lookup(name string) *ast.Object {
+	fn, ok := m.Funcs[name]
+	if !ok {
+		return nil
+	}
+
+	obj, ok := m.objects[name]
+	if !ok {
+		obj = ast.NewObject(name, ast.Var, nil)
+		obj.Data = fn
+		m.objects[name] = obj
+	}
+	return obj
+}
+
+// Load parses the Go file at the given path and returns the native module
+// with the given name defined in it. An error is returned if the file
+// cannot be parsed or any of its exported functions does not follow the
+// contract of native modules.
+func Load(name, path string) (*Module, error) {
+	fset := gotoken.NewFileSet()
+	file, err := goparser.ParseFile(fset, path, nil, 0)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse native module %s: %s", name, err)
+	}
+
+	rt := runtimeName(file)
+	mod := &Module{
+		Name:    name,
+		Path:    path,
+		Funcs:   make(map[string]*Func),
+		objects: make(map[string]*ast.Object),
+	}
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*goast.FuncDecl)
+		if !ok || fn.Recv != nil || !fn.Name.IsExported() {
+			continue
+		}
+
+		f, err := newFunc(name, rt, fn)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", fset.Position(fn.Pos()), err)
+		}
+		mod.Funcs[f.Name] = f
+	}
+
+	return mod, nil
+}
+
+func newFunc(module, rt string, decl *goast.FuncDecl) (*Func, error) {
+	fn := &Func{
+		Module: module,
+		Name:   ElmName(decl.Name.Name),
+		GoName: decl.Name.Name,
+		Result: Unit,
+	}
+
+	for _, field := range decl.Type.Params.List {
+		if _, ok := field.Type.(*goast.Ellipsis); ok {
+			return nil, fmt.Errorf("native function %s cannot be variadic", fn.GoName)
+		}
+
+		kind, err := kindOf(rt, field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("parameter of native function %s: %s", fn.GoName, err)
+		}
+
+		n := len(field.Names)
+		if n == 0 {
+			n = 1
+		}
+
+		for i := 0; i < n; i++ {
+			fn.Params = append(fn.Params, kind)
+		}
+	}
+
+	if results := decl.Type.Results; results != nil && len(results.List) > 0 {
+		if len(results.List) > 1 || len(results.List[0].Names) > 1 {
+			return nil, fmt.Errorf("native function %s cannot return more than one value", fn.GoName)
+		}
+
+		kind, err := kindOf(rt, results.List[0].Type)
+		if err != nil {
+			return nil, fmt.Errorf("result of native function %s: %s", fn.GoName, err)
+		}
+		fn.Result = kind
+	}
+
+	return fn, nil
+}
+
+// kindOf returns the kind of the given Go type. rt is the name given to the
+// runtime package in the file, if it is imported.
+func kindOf(rt string, typ goast.Expr) (Kind, error) {
+	switch t := typ.(type) {
+	case *goast.Ident:
+		if kind, ok := basicKinds[t.Name]; ok {
+			return kind, nil
+		}
+	case *goast.InterfaceType:
+		if len(t.Methods.List) == 0 {
+			return Any, nil
+		}
+	case *goast.StarExpr:
+		if isRuntime(rt, t.X) {
+			return Any, nil
+		}
+	case *goast.SelectorExpr:
+		if isRuntime(rt, t) {
+			return Any, nil
+		}
+	}
+
+	return Any, fmt.Errorf("unsupported type %s", typeString(typ))
+}
+
+// runtimeName returns the name of the runtime package in the given file or
+// an empty string if it is not imported.
+func runtimeName(file *goast.File) string {
+	for _, imp := range file.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil || path != RuntimePath {
+			continue
+		}
+
+		if imp.Name != nil {
+			return imp.Name.Name
+		}
+		return "rt"
+	}
+	return ""
+}
+
+// isRuntime reports whether the given type is a type of the runtime package.
+func isRuntime(rt string, typ goast.Expr) bool {
+	sel, ok := typ.(*goast.SelectorExpr)
+	if !ok {
+		return false
+	}
+
+	x, ok := sel.X.(*goast.Ident)
+	return ok && rt != "" && x.Name == rt
+}
+
+func typeString(typ goast.Expr) string {
+	switch t := typ.(type) {
+	case *goast.Ident:
+		return t.Name
+	case *goast.StarExpr:
+		return "*" + typeString(t.X)
+	case *goast.SelectorExpr:
+		return typeString(t.X) + "." + t.Sel.Name
+	case *goast.ArrayType:
+		return "[]" + typeString(t.Elt)
+	case *goast.MapType:
+		return "map[" + typeString(t.Key) + "]" + typeString(t.Value)
+	case *goast.FuncType:
+		return "func"
+	case *goast.ChanType:
+		return "chan " + typeString(t.Value)
+	case *goast.InterfaceType:
+		return "interface{...}"
+	}
+	return fmt.Sprintf("%T", typ)
+}
+
+// ElmName returns the Elm name of a native function with the given Go name.
+func ElmName(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(r)) + name[size:]
+}
+
+// GoName returns the Go name of a native function with the given Elm name.
+func GoName(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
+
+// FindPath returns the path of the native module with the given name among
+// the given paths of native modules, such as the ones in the NativeImports
+// of an Elm module. An empty string is returned if it is not found.
+func FindPath(name string, paths []string) string {
+	suffix := filepath.ToSlash(filepath.Join(strings.Split(name, ".")...)) + ".go"
+	for _, path := range paths {
+		if strings.HasSuffix(filepath.ToSlash(path), "/"+suffix) {
+			return path
+		}
+	}
+	return ""
+}
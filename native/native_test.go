@@ -0,0 +1,162 @@
+package native
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/elm-tangram/tangram/ast"
+	"github.com/stretchr/testify/require"
+)
+
+const validSource = `package native
+
+import "elm/rt"
+
+func Add(a, b int) int {
+	return a + b
+}
+
+func FloatDiv(a, b float64) float64 {
+	return a / b
+}
+
+func Concat(a string, b rune) string {
+	return a + string(b)
+}
+
+func Not(b bool) bool {
+	return !b
+}
+
+func Cons(head interface{}, tail *rt.List) *rt.List {
+	return &rt.List{head, tail}
+}
+
+func Log(v interface{}) {}
+
+func Pi() float64 {
+	return 3.14
+}
+
+func helper() {}
+
+type T struct{}
+
+func (T) Method(a int) int {
+	return a
+}
+`
+
+func TestLoad(t *testing.T) {
+	require := require.New(t)
+	path := writeModule(t, validSource)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	mod, err := Load("Native.Foo", path)
+	require.NoError(err)
+	require.Equal("Native.Foo", mod.Name)
+	require.Equal(path, mod.Path)
+
+	expected := map[string]*Func{
+		"add":      {"Native.Foo", "add", "Add", []Kind{Int, Int}, Int},
+		"floatDiv": {"Native.Foo", "floatDiv", "FloatDiv", []Kind{Float, Float}, Float},
+		"concat":   {"Native.Foo", "concat", "Concat", []Kind{String, Char}, String},
+		"not":      {"Native.Foo", "not", "Not", []Kind{Bool}, Bool},
+		"cons":     {"Native.Foo", "cons", "Cons", []Kind{Any, Any}, Any},
+		"log":      {"Native.Foo", "log", "Log", []Kind{Any}, Unit},
+		"pi":       {"Native.Foo", "pi", "Pi", nil, Float},
+	}
+	require.Equal(expected, mod.Funcs)
+	require.Equal(0, mod.Funcs["pi"].Arity())
+	require.Equal(2, mod.Funcs["add"].Arity())
+}
+
+func TestLoadErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		source string
+	}{
+		{"syntax error", "package native\n\nfunc Foo( {}"},
+		{"unsupported param", "package native\n\nfunc Foo(a []int) int { return 0 }"},
+		{"unsupported result", "package native\n\nfunc Foo(a int) map[string]int { return nil }"},
+		{"pointer to basic type", "package native\n\nfunc Foo(a *int) int { return 0 }"},
+		{"non empty interface", "package native\n\nfunc Foo(a interface{ Foo() }) int { return 0 }"},
+		{"variadic", "package native\n\nfunc Foo(a ...int) int { return 0 }"},
+		{"many results", "package native\n\nfunc Foo(a int) (int, error) { return 0, nil }"},
+		{"many named results", "package native\n\nfunc Foo(a int) (b, c int) { return 0, 0 }"},
+		{"runtime not imported", "package native\n\nfunc Foo(a rt.List) int { return 0 }"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path := writeModule(t, c.source)
+			defer os.RemoveAll(filepath.Dir(path))
+
+			_, err := Load("Native.Foo", path)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestLoadRuntimeAlias(t *testing.T) {
+	require := require.New(t)
+	path := writeModule(t, "package native\n\nimport runtime \"elm/rt\"\n\nfunc Foo(a runtime.Tuple) runtime.Record { return nil }")
+	defer os.RemoveAll(filepath.Dir(path))
+
+	mod, err := Load("Native.Foo", path)
+	require.NoError(err)
+	require.Equal([]Kind{Any}, mod.Funcs["foo"].Params)
+	require.Equal(Any, mod.Funcs["foo"].Result)
+}
+
+func TestLookup(t *testing.T) {
+	require := require.New(t)
+	path := writeModule(t, validSource)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	mod, err := Load("Native.Foo", path)
+	require.NoError(err)
+
+	obj := mod.Lookup("add")
+	require.NotNil(obj)
+	require.Equal("add", obj.Name)
+	require.Equal(ast.Var, obj.Kind)
+	require.Nil(obj.Node)
+	require.Equal(mod.Funcs["add"], obj.Data)
+	require.True(obj == mod.Lookup("add"), "same object should be returned")
+
+	require.Nil(mod.Lookup("helper"))
+	require.Nil(mod.Lookup("Add"))
+	require.Nil(mod.Lookup("method"))
+}
+
+func TestFindPath(t *testing.T) {
+	require := require.New(t)
+	paths := []string{
+		filepath.Join("src", "Native", "Basics.go"),
+		filepath.Join("src", "Native", "Json", "Decode.go"),
+		filepath.Join("src", "Native", "MyBasics.go"),
+	}
+
+	require.Equal(paths[0], FindPath("Native.Basics", paths))
+	require.Equal(paths[1], FindPath("Native.Json.Decode", paths))
+	require.Equal("", FindPath("Native.List", paths))
+	require.Equal("", FindPath("Native.Decode", paths))
+}
+
+func TestNames(t *testing.T) {
+	require := require.New(t)
+	require.Equal("floatDiv", ElmName("FloatDiv"))
+	require.Equal("FloatDiv", GoName("floatDiv"))
+}
+
+func writeModule(t *testing.T, src string) string {
+	dir, err := ioutil.TempDir("", "tangram-native")
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "Foo.go")
+	require.NoError(t, ioutil.WriteFile(path, []byte(src), 0644))
+	return path
+}
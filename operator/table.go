@@ -1,10 +1,14 @@
 package operator
 
-import "fmt"
+import (
+	"fmt"
+	"sync"
+)
 
 // Table is the implementation of an operator table. It contains the operators
-// and their info.
+// and their info. It is safe to use it from multiple goroutines.
 type Table struct {
+	mut      sync.RWMutex
 	ops      map[Op]*OpInfo
 	builtins map[string]struct{}
 }
@@ -60,7 +64,9 @@ var builtinOps = []struct {
 // Add inserts the given operator and its data in the operator table. It
 // returns an error if the operator is a builtin or has already been defined.
 func (t *Table) Add(name, path string, assoc Associativity, precedence uint) error {
-	if t.IsBuiltin(name) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	if _, ok := t.builtins[name]; ok {
 		return fmt.Errorf("operator %s is a builtin operator and can not be overriden", name)
 	}
 
@@ -74,6 +80,12 @@ func (t *Table) Add(name, path string, assoc Associativity, precedence uint) err
 
 // LookupByName returns the list of possible operators with the given name.
 func (t *Table) LookupByName(name string) []Op {
+	t.mut.RLock()
+	defer t.mut.RUnlock()
+	return t.lookupByName(name)
+}
+
+func (t *Table) lookupByName(name string) []Op {
 	var result []Op
 	for op := range t.ops {
 		if op.Name == name {
@@ -86,18 +98,24 @@ func (t *Table) LookupByName(name string) []Op {
 // Lookup finds a specific operator and returns its info. Will return nil if
 // the operator does not exist.
 func (t *Table) Lookup(name, path string) *OpInfo {
+	t.mut.RLock()
+	defer t.mut.RUnlock()
 	return t.ops[Op{name, path}]
 }
 
 // IsBuiltin reports whether the operator with the given name is a builtin.
 func (t *Table) IsBuiltin(name string) bool {
+	t.mut.RLock()
+	defer t.mut.RUnlock()
 	_, ok := t.builtins[name]
 	return ok
 }
 
 // AddBuiltin adds a new builtin operator to the operator table.
 func (t *Table) AddBuiltin(name string, assoc Associativity, precedence uint) error {
-	if len(t.LookupByName(name)) > 0 {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	if len(t.lookupByName(name)) > 0 {
 		return fmt.Errorf("cannot add builtin operator %s, is already defined", name)
 	}
 	t.builtins[name] = struct{}{}
@@ -0,0 +1,202 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Format is the format of a package manifest.
+type Format byte
+
+const (
+	// ElmPackageJSON is the elm-package.json format used up to Elm 0.18.
+	ElmPackageJSON Format = iota
+	// ElmJSON is the elm.json format used since Elm 0.19.
+	ElmJSON
+)
+
+// ProjectType is the type of an Elm project.
+type ProjectType byte
+
+const (
+	// Library is a package that can be used as a dependency by other
+	// projects. All elm-package.json projects are libraries.
+	Library ProjectType = iota
+	// Application is a project that can not be used as a dependency. It
+	// lists the exact versions of all its dependencies.
+	Application
+)
+
+// elmJSON is the content of an elm.json file. Its fields have different types
+// depending on the type of project, so they are decoded later.
+type elmJSON struct {
+	Type              string          `json:"type"`
+	Name              string          `json:"name"`
+	Version           *Version        `json:"version"`
+	SourceDirectories []string        `json:"source-directories"`
+	ExposedModules    json.RawMessage `json:"exposed-modules"`
+	ElmVersion        json.RawMessage `json:"elm-version"`
+	Dependencies      json.RawMessage `json:"dependencies"`
+}
+
+// applicationDependencies are the dependencies of an application.
+type applicationDependencies struct {
+	Direct   ExactDependencies `json:"direct"`
+	Indirect ExactDependencies `json:"indirect"`
+}
+
+// decodeElmJSON decodes an elm.json manifest. The dependencies of
+// applications are exact, so they are used both as the exact dependencies
+// of the package and as ranges that only contain the exact version. The
+// source directory of libraries is always "src".
+func decodeElmJSON(r io.Reader) (*Package, error) {
+	var manifest elmJSON
+	if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("pkg: can't decode %s: %s", elmJSONFile, err)
+	}
+
+	pkg := &Package{Format: ElmJSON}
+	var err error
+	switch manifest.Type {
+	case "application":
+		pkg.Type = Application
+		err = decodeApplication(pkg, &manifest)
+	case "package":
+		pkg.Type = Library
+		err = decodeLibrary(pkg, &manifest)
+	default:
+		err = fmt.Errorf("unknown project type %q", manifest.Type)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("pkg: can't decode %s: %s", elmJSONFile, err)
+	}
+	return pkg, nil
+}
+
+func decodeApplication(pkg *Package, manifest *elmJSON) error {
+	var elmVersion Version
+	if err := json.Unmarshal(manifest.ElmVersion, &elmVersion); err != nil {
+		return err
+	}
+
+	var deps applicationDependencies
+	if err := json.Unmarshal(manifest.Dependencies, &deps); err != nil {
+		return err
+	}
+
+	pkg.SourceDirectories = manifest.SourceDirectories
+	pkg.ElmVersion = exactRange(elmVersion)
+	pkg.Dependencies = make(Dependencies)
+	pkg.ExactDependencies = make(ExactDependencies)
+	for _, exact := range []ExactDependencies{deps.Direct, deps.Indirect} {
+		for name, v := range exact {
+			pkg.Dependencies[name] = exactRange(v)
+			pkg.ExactDependencies[name] = v
+		}
+	}
+	return nil
+}
+
+func decodeLibrary(pkg *Package, manifest *elmJSON) error {
+	if manifest.Version == nil {
+		return fmt.Errorf("missing version")
+	}
+
+	if err := json.Unmarshal(manifest.ElmVersion, &pkg.ElmVersion); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(manifest.Dependencies, &pkg.Dependencies); err != nil {
+		return err
+	}
+
+	exposed, err := decodeExposedModules(manifest.ExposedModules)
+	if err != nil {
+		return err
+	}
+
+	pkg.Repository = fmt.Sprintf("https://github.com/%s.git", manifest.Name)
+	pkg.Version = *manifest.Version
+	pkg.SourceDirectories = []string{"src"}
+	pkg.ExposedModules = exposed
+	return nil
+}
+
+// decodeExposedModules decodes the exposed modules of a library, which are
+// either a list of modules or an object with lists of modules by category.
+// Categories are sorted by name, since their order is lost when decoded.
+func decodeExposedModules(data json.RawMessage) ([]string, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var modules []string
+	if err := json.Unmarshal(data, &modules); err == nil {
+		return modules, nil
+	}
+
+	var categories map[string][]string
+	if err := json.Unmarshal(data, &categories); err != nil {
+		return nil, fmt.Errorf("invalid exposed-modules: %s", err)
+	}
+
+	var names = make([]string, 0, len(categories))
+	for name := range categories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		modules = append(modules, categories[name]...)
+	}
+	return modules, nil
+}
+
+// exactRange returns the range that only contains the given version.
+func exactRange(v Version) VersionRange {
+	return VersionRange{v, Version{v[0], v[1], v[2] + 1}}
+}
+
+// elmHome returns the directory in which Elm stores its data, which is
+// $ELM_HOME or, if it is not set, "~/.elm".
+func elmHome() string {
+	if dir := os.Getenv("ELM_HOME"); dir != "" {
+		return dir
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".elm"
+	}
+	return filepath.Join(home, ".elm")
+}
+
+// elmPackagesDir returns the directory in which the packages of the newest
+// version of Elm installed in the given range are stored. If there is none,
+// the directory of the oldest version in the range is returned.
+func elmPackagesDir(rng VersionRange) string {
+	var (
+		home   = elmHome()
+		newest = rng.Min
+	)
+
+	files, _ := ioutil.ReadDir(home)
+	for _, f := range files {
+		var v Version
+		if !f.IsDir() || v.UnmarshalText([]byte(f.Name())) != nil {
+			continue
+		}
+
+		if rng.Contains(v) && newest.Less(v) {
+			newest = v
+		}
+	}
+
+	return filepath.Join(home, newest.String(), packagesDir)
+}
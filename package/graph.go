@@ -1,6 +1,9 @@
 package pkg
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // Graph represents a dependency graph.
 type Graph struct {
@@ -39,10 +42,13 @@ func (g *Graph) node(module string) *node {
 // Resolve returns a list of nodes in the exact order in which they need to be
 // resolved. A graph with the exact same nodes in the exact same order produces
 // an output exactly equal no matter how many times it's called.
+// If there are circular dependencies, a CircularDependencyError with all the
+// cycles found is returned.
 func (g *Graph) Resolve() ([]string, error) {
 	ctx := newResolutionCtx()
-	if err := g.root.resolve(ctx); err != nil {
-		return nil, err
+	ctx.visit(g.root)
+	if len(ctx.cycles) > 0 {
+		return nil, NewCircularDependencyError(ctx.cycles...)
 	}
 
 	return ctx.nodes, nil
@@ -68,27 +74,6 @@ func (n *node) add(node *node) {
 	}
 }
 
-func (n *node) resolve(ctx *resolutionCtx) error {
-	ctx.unresolved.add(n.module)
-
-	for _, mod := range n.dependants {
-		if !ctx.resolved.contains(mod) {
-			if ctx.unresolved.contains(mod) {
-				return NewCircularDependencyError(n.module, mod)
-			}
-
-			if err := n.edges[mod].resolve(ctx); err != nil {
-				return err
-			}
-		}
-	}
-
-	delete(ctx.unresolved, n.module)
-	ctx.resolved.add(n.module)
-	ctx.nodes = append(ctx.nodes, n.module)
-	return nil
-}
-
 type moduleSet map[string]struct{}
 
 func (m moduleSet) add(module string) {
@@ -100,35 +85,152 @@ func (m moduleSet) contains(module string) bool {
 	return ok
 }
 
+// resolutionCtx finds the strongly connected components of the graph using
+// Tarjan's algorithm. Components are found after all the components they
+// depend on, so the modules that are not part of any cycle are found in the
+// order in which they need to be resolved.
 type resolutionCtx struct {
-	nodes      []string
-	unresolved moduleSet
-	resolved   moduleSet
+	nodes  []string
+	cycles [][]string
+	// index is the order in which every node was visited.
+	index map[string]int
+	// lowlink is the lowest index of the nodes reachable from every node
+	// that are still in the stack.
+	lowlink map[string]int
+	stack   []*node
+	onStack moduleSet
 }
 
 func newResolutionCtx() *resolutionCtx {
 	return &resolutionCtx{
-		unresolved: make(moduleSet),
-		resolved:   make(moduleSet),
+		index:   make(map[string]int),
+		lowlink: make(map[string]int),
+		onStack: make(moduleSet),
+	}
+}
+
+func (ctx *resolutionCtx) visit(n *node) {
+	ctx.index[n.module] = len(ctx.index)
+	ctx.lowlink[n.module] = ctx.index[n.module]
+	ctx.stack = append(ctx.stack, n)
+	ctx.onStack.add(n.module)
+
+	for _, mod := range n.dependants {
+		if _, visited := ctx.index[mod]; !visited {
+			ctx.visit(n.edges[mod])
+			if ctx.lowlink[mod] < ctx.lowlink[n.module] {
+				ctx.lowlink[n.module] = ctx.lowlink[mod]
+			}
+		} else if ctx.onStack.contains(mod) && ctx.index[mod] < ctx.lowlink[n.module] {
+			ctx.lowlink[n.module] = ctx.index[mod]
+		}
+	}
+
+	if ctx.lowlink[n.module] != ctx.index[n.module] {
+		return
+	}
+
+	// n is the root of a component, which contains all the nodes above it
+	// in the stack
+	var component []*node
+	for {
+		top := ctx.stack[len(ctx.stack)-1]
+		ctx.stack = ctx.stack[:len(ctx.stack)-1]
+		delete(ctx.onStack, top.module)
+		component = append([]*node{top}, component...)
+		if top == n {
+			break
+		}
+	}
+
+	if _, importsItself := n.edges[n.module]; len(component) == 1 && !importsItself {
+		ctx.nodes = append(ctx.nodes, n.module)
+	} else {
+		ctx.cycles = append(ctx.cycles, cycles(component)...)
 	}
 }
 
-// CircularDependencyError describes an error because there was a circular
-// dependency between two dependencies.
+// cycles returns the cycles of the given strongly connected component, with
+// its nodes in the order in which they were visited. Every edge between the
+// nodes of the component is part of at least one of the cycles, and every
+// cycle is the shortest one containing an edge that is not part of any of
+// the previous cycles.
+func cycles(component []*node) [][]string {
+	var members = make(moduleSet)
+	for _, n := range component {
+		members.add(n.module)
+	}
+
+	var result [][]string
+	var covered = make(map[[2]string]struct{})
+	for _, n := range component {
+		for _, mod := range n.dependants {
+			if _, ok := covered[[2]string{n.module, mod}]; ok || !members.contains(mod) {
+				continue
+			}
+
+			cycle := append([]string{n.module}, shortestPath(n.edges[mod], n.module, members)...)
+			for i := 0; i+1 < len(cycle); i++ {
+				covered[[2]string{cycle[i], cycle[i+1]}] = struct{}{}
+			}
+			result = append(result, cycle)
+		}
+	}
+	return result
+}
+
+// shortestPath returns the shortest path from the given node to the given
+// module going only through the given members, including both ends.
+func shortestPath(from *node, to string, members moduleSet) []string {
+	parents := map[string]*node{from.module: nil}
+	queue := []*node{from}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+
+		if n.module == to {
+			var path []string
+			for ; n != nil; n = parents[n.module] {
+				path = append([]string{n.module}, path...)
+			}
+			return path
+		}
+
+		for _, mod := range n.dependants {
+			if _, seen := parents[mod]; !seen && members.contains(mod) {
+				parents[mod] = n
+				queue = append(queue, n.edges[mod])
+			}
+		}
+	}
+
+	// unreachable, all the nodes in a component are reachable from the rest
+	return []string{from.module, to}
+}
+
+// CircularDependencyError describes an error because there were circular
+// dependencies between modules.
 type CircularDependencyError struct {
-	// Modules that depended on each other.
-	Modules [2]string
+	// Cycles contains the cycles of modules that depend on each other. Every
+	// import between modules that depend on each other is part of at least
+	// one of them. A cycle is the list of modules in which every module
+	// imports the next one, starting and ending with the same module.
+	Cycles [][]string
 }
 
 // NewCircularDependencyError returns a new CircularDependencyError.
-func NewCircularDependencyError(a, b string) *CircularDependencyError {
-	return &CircularDependencyError{[2]string{a, b}}
+func NewCircularDependencyError(cycles ...[]string) *CircularDependencyError {
+	return &CircularDependencyError{cycles}
 }
 
 func (e CircularDependencyError) Error() string {
+	var cycles = make([]string, len(e.Cycles))
+	for i, cycle := range e.Cycles {
+		cycles[i] = strings.Join(cycle, " -> ")
+	}
+
 	return fmt.Sprintf(
-		"circular dependency error: %s -> %s",
-		e.Modules[0],
-		e.Modules[1],
+		"circular dependency error: %s",
+		strings.Join(cycles, ", "),
 	)
 }
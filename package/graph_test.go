@@ -45,6 +45,33 @@ func TestCircularDep(t *testing.T) {
 	require.Error(t, err)
 	circular, ok := err.(*CircularDependencyError)
 	require.True(t, ok, "expected a CircularDependencyError")
-	require.Equal(t, [2]string{"f", "b"}, circular.Modules)
+	require.Equal(t, [][]string{{"b", "e", "f", "b"}}, circular.Cycles)
 	require.Nil(t, nodes)
+	require.Equal(t, "circular dependency error: b -> e -> f -> b", err.Error())
+}
+
+func TestCircularDepMultipleCycles(t *testing.T) {
+	g := NewGraph("a").
+		Add("b", "a").
+		Add("c", "b").
+		Add("d", "c").
+		Add("e", "d").
+		Add("b", "e").
+		Add("c", "e").
+		Add("f", "a").
+		Add("f", "f").
+		Add("g", "a").
+		Add("h", "g").
+		Add("g", "h")
+
+	nodes, err := g.Resolve()
+	require.Nil(t, nodes)
+	circular, ok := err.(*CircularDependencyError)
+	require.True(t, ok, "expected a CircularDependencyError")
+	require.Equal(t, [][]string{
+		{"b", "c", "d", "e", "b"},
+		{"e", "c", "d", "e"},
+		{"f", "f"},
+		{"g", "h", "g"},
+	}, circular.Cycles)
 }
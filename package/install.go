@@ -0,0 +1,95 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Install picks the exact versions of all the dependencies of the package
+// using the given registry and extracts the ones that are not installed yet.
+// Packages with an elm-package.json manifest install them into
+// "elm-stuff/packages/<user>/<name>/<version>" and write them to
+// "elm-stuff/exact-dependencies.json", while packages with an elm.json
+// manifest install them into the packages directory of Elm. The exact
+// dependencies of the package are replaced with the ones installed, which
+// are returned.
+func (p *Package) Install(r Registry) (ExactDependencies, error) {
+	exact, err := Solve(r, p.Dependencies)
+	if err != nil {
+		return nil, err
+	}
+
+	var names = make([]string, 0, len(exact))
+	for name := range exact {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := p.installDependency(r, name, exact[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.Format == ElmPackageJSON {
+		if err := p.writeExactDependencies(exact); err != nil {
+			return nil, err
+		}
+	}
+
+	p.ExactDependencies = exact
+	p.moduleCache = make(map[string]string)
+	return exact, nil
+}
+
+func (p *Package) writeExactDependencies(exact ExactDependencies) error {
+	content, err := json.MarshalIndent(exact, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Join(p.root, elmStuffDir), 0755); err != nil {
+		return err
+	}
+
+	path := filepath.Join(p.root, elmStuffDir, exactDepsFile)
+	if err := ioutil.WriteFile(path, append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("pkg: can't write exact dependencies: %s", err)
+	}
+	return nil
+}
+
+// installDependency extracts the given version of a dependency, unless it
+// is already installed. The package is extracted in a temporary directory
+// first, so a failed installation never leaves a package half installed.
+func (p *Package) installDependency(r Registry, name string, v Version) error {
+	dir := p.dependencyDir(name, v)
+	if ok, err := hasManifest(dir); err != nil {
+		return err
+	} else if ok {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempDir(filepath.Dir(dir), ".install")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := r.Extract(name, v, tmp); err != nil {
+		return fmt.Errorf("pkg: can't extract %s %s: %s", name, v, err)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dir)
+}
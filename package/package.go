@@ -7,11 +7,13 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
 const (
 	pkgFile       = "elm-package.json"
+	elmJSONFile   = "elm.json"
 	ext           = ".elm"
 	nativeExt     = ".go"
 	elmStuffDir   = "elm-stuff"
@@ -32,20 +34,67 @@ var (
 	ErrNotElmPackage = errors.New("pkg: could not find an elm package in the given path or its ancestors")
 )
 
-// Package represents the elm-package.json file, that is, the package manifest.
-// This contains all the package information useful for the compiler, including
-// its dependencies, etc.
+// ModuleNotExposedError is returned when a module is found in a dependency
+// that does not expose it.
+type ModuleNotExposedError struct {
+	Module  string
+	Package string
+}
+
+func (e *ModuleNotExposedError) Error() string {
+	return fmt.Sprintf("pkg: module %s is not exposed by package %s", e.Module, e.Package)
+}
+
+// AmbiguousModuleError is returned when a module is exposed by more than one
+// dependency.
+type AmbiguousModuleError struct {
+	Module   string
+	Packages []string
+}
+
+func (e *AmbiguousModuleError) Error() string {
+	return fmt.Sprintf(
+		"pkg: module %s is exposed by more than one package: %s",
+		e.Module,
+		strings.Join(e.Packages, ", "),
+	)
+}
+
+// DependenciesError is returned when looking for a module in the
+// dependencies of a package if the versions of the dependencies installed
+// could not be picked.
+type DependenciesError struct {
+	// Dir is the directory in which the dependencies are installed.
+	Dir string
+	Err error
+}
+
+func (e *DependenciesError) Error() string {
+	return fmt.Sprintf("pkg: could not pick the dependencies installed in %s: %s", e.Dir, e.Err)
+}
+
+// Package represents the elm-package.json or elm.json file, that is, the
+// package manifest. This contains all the package information useful for the
+// compiler, including its dependencies, etc.
 type Package struct {
 	Repository        string            `json:"repository"`
 	Version           Version           `json:"version"`
 	SourceDirectories []string          `json:"source-directories"`
+	ExposedModules    []string          `json:"exposed-modules"`
 	NativeModules     bool              `json:"native-modules"`
 	Dependencies      Dependencies      `json:"dependencies"`
 	ElmVersion        VersionRange      `json:"elm-version"`
 	ExactDependencies ExactDependencies `json:"-"`
+	// Format is the format of the manifest the package was loaded from.
+	Format Format `json:"-"`
+	// Type is the type of project of an elm.json manifest, Application or
+	// Library. It is always Library for elm-package.json manifests.
+	Type ProjectType `json:"-"`
 
-	// root of the package, that is, the directory where elm-package.json is
+	// root of the package, that is, the directory where the manifest is
 	root string
+	// packagesDir is the directory where the dependencies are installed
+	packagesDir string
 	// dependencyCache keeps a reference to the package manifest of the
 	// dependencies so we don't have to load it every time that we're looking
 	// for a module
@@ -53,6 +102,9 @@ type Package struct {
 	// moduleCache keeps the resolved paths for modules so they don't have to
 	// looked up again
 	moduleCache map[string]string
+	// depsErr is the error picking the versions of the dependencies
+	// installed, if they could not be picked
+	depsErr error
 }
 
 // Root returns the package root.
@@ -122,43 +174,168 @@ func (p *Package) FindSourceModule(path string) (string, error) {
 }
 
 // FindDependencyModule will try to find a module with the given path in all
-// the dependency directories.
+// the dependency directories. Only the modules exposed by the dependencies
+// and their native modules can be found. If the module is exposed by more
+// than one dependency, an AmbiguousModuleError is returned, and if it is
+// found but not exposed, a ModuleNotExposedError is returned.
 func (p *Package) FindDependencyModule(path string) (string, error) {
 	if cachedPath, ok := p.moduleCache[path]; ok {
 		return cachedPath, nil
 	}
 
 	if p.ExactDependencies == nil {
+		if p.depsErr != nil {
+			return "", p.depsErr
+		}
 		return "", ErrDepsNotInstalled
 	}
 
-	for dep, v := range p.ExactDependencies {
-		dir := filepath.Join(p.root, elmStuffDir, packagesDir, dep, v.String())
-		var (
-			pkg *Package
-			ok  bool
-		)
+	var (
+		filePath string
+		exposing []string
+		hiding   []string
+	)
 
-		if pkg, ok = p.dependencyCache[dep]; !ok {
-			var err error
-			pkg, err = loadPackage(dir, false)
-			if err != nil {
-				return "", fmt.Errorf("pkg: expected %s version %s to be a valid Elm package: %s", dep, v, err)
-			}
+	for _, dep := range p.dependencyNames() {
+		pkg, err := p.dependency(dep)
+		if err != nil {
+			return "", err
 		}
 
 		moduleFilePath, err := pkg.FindSourceModule(path)
 		if err != nil && err != ErrModuleNotFound {
 			return "", err
-		} else if moduleFilePath != "" {
-			p.cacheModule(path, moduleFilePath)
-			return moduleFilePath, nil
+		} else if moduleFilePath == "" {
+			continue
+		}
+
+		// native modules are never listed in the exposed modules
+		if pkg.exposes(path) || filepath.Ext(moduleFilePath) == nativeExt {
+			filePath = moduleFilePath
+			exposing = append(exposing, dep)
+		} else {
+			hiding = append(hiding, dep)
 		}
 	}
 
+	switch {
+	case len(exposing) > 1:
+		return "", &AmbiguousModuleError{path, exposing}
+	case len(exposing) == 1:
+		p.cacheModule(path, filePath)
+		return filePath, nil
+	case len(hiding) > 0:
+		return "", &ModuleNotExposedError{path, hiding[0]}
+	}
+
 	return "", ErrModuleNotFound
 }
 
+// FindImport tries to find a module with the given path imported by the
+// module in the given file. Modules of the package can import any module in
+// its source directories and the modules exposed by its dependencies, while
+// modules of a dependency can import any module of that dependency and the
+// modules exposed by the rest of dependencies.
+func (p *Package) FindImport(importer, path string) (string, error) {
+	dep, err := p.dependencyOf(importer)
+	if err != nil {
+		return "", err
+	} else if dep == nil {
+		return p.FindModule(path)
+	}
+
+	if filePath, err := dep.FindSourceModule(path); err != nil && err != ErrModuleNotFound {
+		return "", err
+	} else if filePath != "" {
+		return filePath, nil
+	}
+
+	return p.FindDependencyModule(path)
+}
+
+// ModuleName returns the name the module in the given file must have, which
+// is its path relative to the source directory containing it. The file can
+// be in the source directories of the package or of any of its dependencies.
+// If several source directories contain the file, the deepest one is used.
+// If none of them contains it, an empty string is returned.
+func (p *Package) ModuleName(file string) (string, error) {
+	pkg, err := p.dependencyOf(file)
+	if err != nil {
+		return "", err
+	} else if pkg == nil {
+		pkg = p
+	}
+
+	if filepath.Ext(file) != ext {
+		return "", nil
+	}
+
+	var name string
+	for _, dir := range pkg.SourceDirectories {
+		rel, err := filepath.Rel(filepath.Join(pkg.root, dir), file)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+separator) {
+			continue
+		}
+
+		rel = strings.TrimSuffix(rel, ext)
+		if n := strings.Replace(rel, separator, ".", -1); name == "" || len(n) < len(name) {
+			name = n
+		}
+	}
+
+	return name, nil
+}
+
+// dependencyNames returns the names of all the dependencies, sorted.
+func (p *Package) dependencyNames() []string {
+	var names = make([]string, 0, len(p.ExactDependencies))
+	for name := range p.ExactDependencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// dependency returns the manifest of the dependency with the given name.
+func (p *Package) dependency(name string) (*Package, error) {
+	if pkg, ok := p.dependencyCache[name]; ok {
+		return pkg, nil
+	}
+
+	v := p.ExactDependencies[name]
+	pkg, err := loadPackage(p.dependencyDir(name, v), false)
+	if err != nil {
+		return nil, fmt.Errorf("pkg: expected %s version %s to be a valid Elm package: %s", name, v, err)
+	}
+
+	p.dependencyCache[name] = pkg
+	return pkg, nil
+}
+
+// dependencyOf returns the dependency the given file belongs to, or nil if
+// it does not belong to any dependency.
+func (p *Package) dependencyOf(file string) (*Package, error) {
+	file = filepath.Clean(file)
+	for _, name := range p.dependencyNames() {
+		dir := filepath.Clean(p.dependencyDir(name, p.ExactDependencies[name]))
+		if strings.HasPrefix(file, dir+separator) {
+			return p.dependency(name)
+		}
+	}
+	return nil, nil
+}
+
+// exposes reports whether the package exposes the module with the given
+// path to other packages.
+func (p *Package) exposes(path string) bool {
+	for _, m := range p.ExposedModules {
+		if m == path {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *Package) findModuleInDir(pathParts []string, dir string) (string, error) {
 	var path = filepath.Join(p.root, dir)
 	for i, p := range pathParts {
@@ -198,6 +375,23 @@ func Load(path string) (*Package, error) {
 
 	pkg.dependencyCache = make(map[string]*Package)
 
+	if pkg.Format == ElmJSON {
+		pkg.packagesDir = elmPackagesDir(pkg.ElmVersion)
+		// the exact dependencies of applications are in their manifest, but
+		// packages only have ranges, so the versions installed are picked
+		if pkg.Type == Library {
+			exact, err := Solve(NewLocalRegistry(pkg.packagesDir), pkg.Dependencies)
+			if err != nil {
+				// the package can still be used to install its dependencies,
+				// so the error is only returned when they are needed
+				pkg.depsErr = &DependenciesError{pkg.packagesDir, err}
+			}
+			pkg.ExactDependencies = exact
+		}
+		return pkg, nil
+	}
+
+	pkg.packagesDir = filepath.Join(pkg.root, elmStuffDir, packagesDir)
 	if err := pkg.tryLoadExactDependencies(); err != nil {
 		return nil, err
 	}
@@ -205,6 +399,12 @@ func Load(path string) (*Package, error) {
 	return pkg, nil
 }
 
+// dependencyDir returns the directory in which the given version of a
+// dependency is installed.
+func (p *Package) dependencyDir(name string, v Version) string {
+	return filepath.Join(p.packagesDir, filepath.FromSlash(name), v.String())
+}
+
 func loadPackage(path string, recursive bool) (*Package, error) {
 	f, root, err := findPackageFile(path, recursive)
 	if err != nil {
@@ -216,22 +416,63 @@ func loadPackage(path string, recursive bool) (*Package, error) {
 	}
 
 	defer f.Close()
-	var pkg Package
-	if err := json.NewDecoder(f).Decode(&pkg); err != nil {
-		return nil, fmt.Errorf("pkg: can't decode elm-package.json: %s", err)
+	pkg, err := decodeManifest(filepath.Base(f.Name()), f)
+	if err != nil {
+		return nil, err
 	}
 	pkg.root = root
 	pkg.moduleCache = make(map[string]string)
+	return pkg, nil
+}
+
+// decodeManifest decodes the manifest of a package, which can be either an
+// elm-package.json or an elm.json file, depending on the given file name.
+func decodeManifest(file string, r io.Reader) (*Package, error) {
+	if file == elmJSONFile {
+		return decodeElmJSON(r)
+	}
+
+	var pkg Package
+	if err := json.NewDecoder(r).Decode(&pkg); err != nil {
+		return nil, fmt.Errorf("pkg: can't decode %s: %s", pkgFile, err)
+	}
+	pkg.Type = Library
 	return &pkg, nil
 }
 
-func findPackageFile(path string, recursive bool) (io.ReadCloser, string, error) {
+// isManifest reports whether the given file name is the name of a package
+// manifest.
+func isManifest(file string) bool {
+	return file == pkgFile || file == elmJSONFile
+}
+
+// hasManifest reports whether there is a package manifest in the given
+// directory.
+func hasManifest(dir string) (bool, error) {
+	for _, file := range []string{pkgFile, elmJSONFile} {
+		if ok, err := exists(filepath.Join(dir, file)); err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// findPackageFile finds the manifest of the package at the given path, which
+// is elm-package.json or, if there is none, elm.json.
+func findPackageFile(path string, recursive bool) (*os.File, string, error) {
 	if path == separator {
 		return nil, "", nil
 	}
 
-	file := filepath.Join(path, pkgFile)
-	f, err := os.Open(file)
+	f, err := os.Open(filepath.Join(path, pkgFile))
+	if os.IsNotExist(err) {
+		var jerr error
+		f, jerr = os.Open(filepath.Join(path, elmJSONFile))
+		if !os.IsNotExist(jerr) {
+			err = jerr
+		}
+	}
+
 	if os.IsNotExist(err) && recursive {
 		if path == filepath.Dir(path) {
 			return nil, "", nil
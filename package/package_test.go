@@ -42,17 +42,22 @@ var validPackageEntries = []entry{
 		"elm-stuff/packages/foo/bar/1.0.0/elm-package.json",
 		Package{
 			SourceDirectories: []string{"src"},
+			ExposedModules:    []string{"Foo.Bar.Baz.Qux", "Shared"},
 		},
 	},
 	{"elm-stuff/packages/foo/bar/1.0.0/src/Foo/Bar.elm", nil},
 	{"elm-stuff/packages/foo/bar/1.0.0/src/Foo/Bar/Baz/Qux.elm", nil},
+	{"elm-stuff/packages/foo/bar/1.0.0/src/Foo/Bar/Internal.elm", nil},
+	{"elm-stuff/packages/foo/bar/1.0.0/src/Shared.elm", nil},
 	{
 		"elm-stuff/packages/foo/baz/1.5.0/elm-package.json",
 		Package{
 			SourceDirectories: []string{"src"},
+			ExposedModules:    []string{"Foo.Bar.Baz.Mux", "Shared"},
 		},
 	},
 	{"elm-stuff/packages/foo/baz/1.5.0/src/Foo/Bar/Baz/Mux.elm", nil},
+	{"elm-stuff/packages/foo/baz/1.5.0/src/Shared.elm", nil},
 }
 
 var notInstalledPackageEntries = []entry{
@@ -139,6 +144,8 @@ func TestFindModule(t *testing.T) {
 		{"Foo.Bar.Baz.Mux", "elm-stuff/packages/foo/baz/1.5.0/src/Foo/Bar/Baz/Mux.elm", nil},
 		{"Foo.Bar.Baz.Mux", "elm-stuff/packages/foo/baz/1.5.0/src/Foo/Bar/Baz/Mux.elm", nil}, // this one is cached
 		{"Bar.Foo", "", ErrModuleNotFound},
+		{"Foo.Bar.Internal", "", &ModuleNotExposedError{"Foo.Bar.Internal", "foo/bar"}},
+		{"Shared", "", &AmbiguousModuleError{"Shared", []string{"foo/bar", "foo/baz"}}},
 	}
 
 	for _, c := range cases {
@@ -194,3 +201,196 @@ func createStructure(entries ...entry) (string, error) {
 
 	return root, nil
 }
+
+var elmJSONEntries = []entry{
+	{
+		"app/elm.json",
+		`{
+	"type": "application",
+	"source-directories": ["src"],
+	"elm-version": "0.19.1",
+	"dependencies": {
+		"direct": {"elm/core": "1.0.5"},
+		"indirect": {"elm/json": "1.1.3"}
+	},
+	"test-dependencies": {"direct": {}, "indirect": {}}
+}`,
+	},
+	{"app/src/Main.elm", nil},
+	{
+		"lib/elm.json",
+		`{
+	"type": "package",
+	"name": "foo/lib",
+	"summary": "a library",
+	"license": "BSD-3-Clause",
+	"version": "1.2.3",
+	"exposed-modules": ["Foo"],
+	"elm-version": "0.19.0 <= v < 0.20.0",
+	"dependencies": {"elm/json": "1.0.0 <= v < 2.0.0"},
+	"test-dependencies": {}
+}`,
+	},
+	{"lib/src/Foo.elm", nil},
+	{
+		"home/0.19.1/packages/elm/core/1.0.5/elm.json",
+		`{
+	"type": "package",
+	"name": "elm/core",
+	"version": "1.0.5",
+	"exposed-modules": {"Primitives": ["Basics"], "Data": ["List"]},
+	"elm-version": "0.19.0 <= v < 0.20.0",
+	"dependencies": {}
+}`,
+	},
+	{"home/0.19.1/packages/elm/core/1.0.5/src/Basics.elm", nil},
+	{
+		"home/0.19.1/packages/elm/json/1.1.3/elm.json",
+		`{
+	"type": "package",
+	"name": "elm/json",
+	"version": "1.1.3",
+	"exposed-modules": ["Json.Decode"],
+	"elm-version": "0.19.0 <= v < 0.20.0",
+	"dependencies": {"elm/core": "1.0.0 <= v < 2.0.0"}
+}`,
+	},
+	{"home/0.19.1/packages/elm/json/1.1.3/src/Json/Decode.elm", nil},
+}
+
+func TestLoadElmJSON(t *testing.T) {
+	require := require.New(t)
+	root, err := createStructure(elmJSONEntries...)
+	require.NoError(err)
+	defer os.RemoveAll(root)
+
+	home := filepath.Join(root, "home")
+	require.NoError(os.Setenv("ELM_HOME", home))
+	defer os.Unsetenv("ELM_HOME")
+
+	packages := filepath.Join(home, "0.19.1", "packages")
+
+	app, err := Load(filepath.Join(root, "app", "src"))
+	require.NoError(err)
+	require.Equal(ElmJSON, app.Format)
+	require.Equal(Application, app.Type)
+	require.Equal(filepath.Join(root, "app"), app.Root())
+	require.Equal(ExactDependencies{
+		"elm/core": Version{1, 0, 5},
+		"elm/json": Version{1, 1, 3},
+	}, app.ExactDependencies)
+	require.Equal(VersionRange{Version{1, 0, 5}, Version{1, 0, 6}}, app.Dependencies["elm/core"])
+
+	path, err := app.FindModule("Main")
+	require.NoError(err)
+	require.Equal(filepath.Join(root, "app", "src", "Main.elm"), path)
+
+	path, err = app.FindModule("Json.Decode")
+	require.NoError(err)
+	require.Equal(filepath.Join(packages, "elm", "json", "1.1.3", "src", "Json", "Decode.elm"), path)
+
+	lib, err := Load(filepath.Join(root, "lib"))
+	require.NoError(err)
+	require.Equal(ElmJSON, lib.Format)
+	require.Equal(Library, lib.Type)
+	require.Equal(Version{1, 2, 3}, lib.Version)
+	require.Equal([]string{"Foo"}, lib.ExposedModules)
+	require.Equal(ExactDependencies{
+		"elm/core": Version{1, 0, 5},
+		"elm/json": Version{1, 1, 3},
+	}, lib.ExactDependencies)
+
+	path, err = lib.FindModule("Foo")
+	require.NoError(err)
+	require.Equal(filepath.Join(root, "lib", "src", "Foo.elm"), path)
+
+	path, err = lib.FindModule("Basics")
+	require.NoError(err)
+	require.Equal(filepath.Join(packages, "elm", "core", "1.0.5", "src", "Basics.elm"), path)
+
+	core, err := Load(filepath.Join(packages, "elm", "core", "1.0.5"))
+	require.NoError(err)
+	require.Equal([]string{"List", "Basics"}, core.ExposedModules)
+}
+
+func TestLoadElmJSONNotInstalled(t *testing.T) {
+	require := require.New(t)
+	root, err := createStructure(elmJSONEntries...)
+	require.NoError(err)
+	defer os.RemoveAll(root)
+
+	home := filepath.Join(root, "empty")
+	require.NoError(os.Setenv("ELM_HOME", home))
+	defer os.Unsetenv("ELM_HOME")
+
+	lib, err := Load(filepath.Join(root, "lib"))
+	require.NoError(err)
+	require.Nil(lib.ExactDependencies)
+
+	_, err = lib.FindModule("Json.Decode")
+	require.IsType(new(DependenciesError), err)
+	require.Equal(filepath.Join(home, "0.19.0", "packages"), err.(*DependenciesError).Dir)
+	require.Contains(err.Error(), "elm/json")
+}
+
+func TestFindImport(t *testing.T) {
+	require := require.New(t)
+	root, err := createStructure(validPackageEntries...)
+	require.NoError(err)
+	defer os.RemoveAll(root)
+
+	pkg, err := Load(root)
+	require.NoError(err)
+
+	cases := []struct {
+		importer string
+		module   string
+		expected string
+		err      error
+	}{
+		{"src/Foo.elm", "Foo.Bar", "src/Foo/Bar.elm", nil},
+		{"src/Foo.elm", "Foo.Bar.Internal", "", &ModuleNotExposedError{"Foo.Bar.Internal", "foo/bar"}},
+		{"elm-stuff/packages/foo/bar/1.0.0/src/Shared.elm", "Foo.Bar.Internal", "elm-stuff/packages/foo/bar/1.0.0/src/Foo/Bar/Internal.elm", nil},
+		{"elm-stuff/packages/foo/bar/1.0.0/src/Shared.elm", "Foo.Bar", "elm-stuff/packages/foo/bar/1.0.0/src/Foo/Bar.elm", nil},
+		{"elm-stuff/packages/foo/bar/1.0.0/src/Shared.elm", "Foo.Bar.Baz.Mux", "elm-stuff/packages/foo/baz/1.5.0/src/Foo/Bar/Baz/Mux.elm", nil},
+		{"elm-stuff/packages/foo/baz/1.5.0/src/Shared.elm", "Foo.Bar.Internal", "", &ModuleNotExposedError{"Foo.Bar.Internal", "foo/bar"}},
+	}
+
+	for _, c := range cases {
+		path, err := pkg.FindImport(filepath.Join(root, c.importer), c.module)
+		if c.err != nil {
+			require.Equal(c.err, err, c.module)
+		} else {
+			require.NoError(err, c.module)
+			require.Equal(filepath.Join(root, c.expected), path, c.module)
+		}
+	}
+}
+
+func TestModuleName(t *testing.T) {
+	require := require.New(t)
+	root, err := createStructure(validPackageEntries...)
+	require.NoError(err)
+	defer os.RemoveAll(root)
+
+	pkg, err := Load(root)
+	require.NoError(err)
+
+	cases := []struct {
+		file     string
+		expected string
+	}{
+		{"src/Foo.elm", "Foo"},
+		{"src/Foo/Bar/Baz.elm", "Foo.Bar.Baz"},
+		{"src2/Bar.elm", "Bar"},
+		{"src2/Bar.go", ""},
+		{"Main.elm", ""},
+		{"elm-stuff/packages/foo/bar/1.0.0/src/Foo/Bar/Internal.elm", "Foo.Bar.Internal"},
+	}
+
+	for _, c := range cases {
+		name, err := pkg.ModuleName(filepath.Join(root, c.file))
+		require.NoError(err, c.file)
+		require.Equal(c.expected, name, c.file)
+	}
+}
@@ -0,0 +1,240 @@
+package pkg
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Registry is a source of packages from which the dependencies of a package
+// are installed.
+type Registry interface {
+	// Versions returns all the versions available of the package with the
+	// given name, which has the form "user/name".
+	Versions(name string) ([]Version, error)
+	// Manifest returns the manifest of the given version of a package.
+	Manifest(name string, v Version) (*Package, error)
+	// Extract writes the content of the given version of a package to the
+	// given directory, which must be empty.
+	Extract(name string, v Version, dir string) error
+}
+
+// ErrPackageNotFound is returned by a registry when the package or version
+// requested is not in the registry.
+var ErrPackageNotFound = errors.New("pkg: package not found in the registry")
+
+// LocalRegistry is a registry stored in a directory of the file system. The
+// version of a package can be either a directory with the content of the
+// package, in "<root>/<user>/<name>/<version>", or a zip file with the
+// content of the package, in "<root>/<user>/<name>/<version>.zip". The
+// content of a zip file can also be inside a single directory, as in the
+// archives of the packages downloaded from GitHub.
+type LocalRegistry struct {
+	root string
+}
+
+// NewLocalRegistry returns a new registry stored in the given directory.
+func NewLocalRegistry(root string) *LocalRegistry {
+	return &LocalRegistry{root}
+}
+
+// Versions returns all the versions available of the package with the given
+// name, sorted from the oldest to the newest.
+func (r *LocalRegistry) Versions(name string) ([]Version, error) {
+	files, err := ioutil.ReadDir(r.packageDir(name))
+	if os.IsNotExist(err) {
+		return nil, ErrPackageNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	var versions []Version
+	for _, f := range files {
+		fileName := f.Name()
+		if !f.IsDir() {
+			if !strings.HasSuffix(fileName, ".zip") {
+				continue
+			}
+			fileName = strings.TrimSuffix(fileName, ".zip")
+		}
+
+		var v Version
+		if err := v.UnmarshalText([]byte(fileName)); err == nil {
+			versions = append(versions, v)
+		}
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Less(versions[j])
+	})
+	return versions, nil
+}
+
+// Manifest returns the manifest of the given version of a package.
+func (r *LocalRegistry) Manifest(name string, v Version) (*Package, error) {
+	var manifests = make(map[string][]byte)
+	err := r.walk(name, v, func(file string, rc io.Reader) error {
+		if !isManifest(file) {
+			return nil
+		}
+
+		content, err := ioutil.ReadAll(rc)
+		manifests[file] = content
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range []string{pkgFile, elmJSONFile} {
+		if content, ok := manifests[file]; ok {
+			pkg, err := decodeManifest(file, bytes.NewReader(content))
+			if err != nil {
+				return nil, fmt.Errorf("%s (in %s %s)", err, name, v)
+			}
+			return pkg, nil
+		}
+	}
+
+	return nil, fmt.Errorf("pkg: %s %s has no %s or %s", name, v, pkgFile, elmJSONFile)
+}
+
+// Extract writes the content of the given version of a package to the given
+// directory.
+func (r *LocalRegistry) Extract(name string, v Version, dir string) error {
+	return r.walk(name, v, func(file string, rc io.Reader) error {
+		target := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+
+		f, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(f, rc)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	})
+}
+
+func (r *LocalRegistry) packageDir(name string) string {
+	return filepath.Join(r.root, filepath.FromSlash(name))
+}
+
+// walk calls fn with every file of the given version of a package, with its
+// path relative to the root of the package, using slashes.
+func (r *LocalRegistry) walk(name string, v Version, fn func(string, io.Reader) error) error {
+	dir := filepath.Join(r.packageDir(name), v.String())
+	if ok, err := exists(dir); err != nil {
+		return err
+	} else if ok {
+		return walkDir(dir, fn)
+	}
+
+	if ok, err := exists(dir + ".zip"); err != nil {
+		return err
+	} else if ok {
+		return walkZip(dir+".zip", fn)
+	}
+
+	return ErrPackageNotFound
+}
+
+func walkDir(dir string, fn func(string, io.Reader) error) error {
+	return filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		return fn(filepath.ToSlash(rel), f)
+	})
+}
+
+func walkZip(file string, fn func(string, io.Reader) error) error {
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	prefix := zipPrefix(zr.File)
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		name := path.Clean(strings.TrimPrefix(f.Name, prefix))
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("pkg: invalid file %q in %s", f.Name, file)
+		}
+
+		if err := walkZipFile(f, name, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkZipFile(f *zip.File, name string, fn func(string, io.Reader) error) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	// the content is read first, so errors in the checksum of the file are
+	// found before using it
+	content, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return err
+	}
+	return fn(name, bytes.NewReader(content))
+}
+
+// zipPrefix returns the directory containing all the files of a zip file,
+// if there is such directory and the manifest of the package is in it.
+func zipPrefix(files []*zip.File) string {
+	for _, f := range files {
+		if isManifest(f.Name) {
+			return ""
+		}
+	}
+
+	var prefix string
+	for _, f := range files {
+		i := strings.Index(f.Name, "/")
+		if i < 0 {
+			return ""
+		}
+
+		if prefix == "" {
+			prefix = f.Name[:i+1]
+		} else if prefix != f.Name[:i+1] {
+			return ""
+		}
+	}
+	return prefix
+}
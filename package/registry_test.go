@@ -0,0 +1,129 @@
+package pkg
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var registryEntries = []entry{
+	{
+		"foo/bar/1.0.0/elm-package.json",
+		Package{
+			SourceDirectories: []string{"src"},
+			Dependencies: Dependencies{
+				"foo/baz": VersionRange{Version{1, 0, 0}, Version{2, 0, 0}},
+			},
+		},
+	},
+	{"foo/bar/1.0.0/src/Foo/Bar.elm", "module Foo.Bar exposing (..)"},
+	{"foo/bar/README.md", "not a version"},
+}
+
+func createRegistry(t *testing.T) string {
+	require := require.New(t)
+	root, err := createStructure(registryEntries...)
+	require.NoError(err)
+
+	// foo/baz 1.2.0 is a zip file with all its content inside a directory,
+	// as the ones downloaded from GitHub
+	require.NoError(os.MkdirAll(filepath.Join(root, "foo", "baz"), 0755))
+	f, err := os.Create(filepath.Join(root, "foo", "baz", "1.2.0.zip"))
+	require.NoError(err)
+
+	zw := zip.NewWriter(f)
+	for name, content := range map[string]string{
+		"baz-1.2.0/elm-package.json":  `{"version": "1.2.0", "source-directories": ["src"], "exposed-modules": ["Foo.Baz", "Foo.Baz.X"]}`,
+		"baz-1.2.0/src/Foo/Baz.elm":   "module Foo.Baz exposing (..)",
+		"baz-1.2.0/src/Foo/Baz/X.elm": "module Foo.Baz.X exposing (..)",
+	} {
+		w, err := zw.Create(name)
+		require.NoError(err)
+		_, err = w.Write([]byte(content))
+		require.NoError(err)
+	}
+	require.NoError(zw.Close())
+	require.NoError(f.Close())
+
+	return root
+}
+
+func TestLocalRegistry(t *testing.T) {
+	require := require.New(t)
+	root := createRegistry(t)
+	defer os.RemoveAll(root)
+
+	r := NewLocalRegistry(root)
+
+	versions, err := r.Versions("foo/bar")
+	require.NoError(err)
+	require.Equal([]Version{{1, 0, 0}}, versions)
+
+	versions, err = r.Versions("foo/baz")
+	require.NoError(err)
+	require.Equal([]Version{{1, 2, 0}}, versions)
+
+	_, err = r.Versions("foo/qux")
+	require.Equal(ErrPackageNotFound, err)
+
+	manifest, err := r.Manifest("foo/bar", Version{1, 0, 0})
+	require.NoError(err)
+	require.Equal(registryEntries[0].content.(Package).Dependencies, manifest.Dependencies)
+
+	manifest, err = r.Manifest("foo/baz", Version{1, 2, 0})
+	require.NoError(err)
+	require.Equal(Version{1, 2, 0}, manifest.Version)
+
+	_, err = r.Manifest("foo/baz", Version{1, 3, 0})
+	require.Equal(ErrPackageNotFound, err)
+
+	dir, err := ioutil.TempDir("", "registry")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(r.Extract("foo/baz", Version{1, 2, 0}, dir))
+	content, err := ioutil.ReadFile(filepath.Join(dir, "src", "Foo", "Baz", "X.elm"))
+	require.NoError(err)
+	require.Equal("module Foo.Baz.X exposing (..)", string(content))
+}
+
+func TestInstall(t *testing.T) {
+	require := require.New(t)
+	registry := createRegistry(t)
+	defer os.RemoveAll(registry)
+
+	root, err := createStructure(notInstalledPackageEntries...)
+	require.NoError(err)
+	defer os.RemoveAll(root)
+
+	pkg, err := Load(root)
+	require.NoError(err)
+
+	_, err = pkg.FindDependencyModule("Foo.Baz")
+	require.Equal(ErrDepsNotInstalled, err)
+
+	expected := ExactDependencies{
+		"foo/bar": Version{1, 0, 0},
+		"foo/baz": Version{1, 2, 0},
+	}
+
+	exact, err := pkg.Install(NewLocalRegistry(registry))
+	require.NoError(err)
+	require.Equal(expected, exact)
+
+	path, err := pkg.FindDependencyModule("Foo.Baz.X")
+	require.NoError(err)
+	require.Equal(
+		filepath.Join(root, "elm-stuff", "packages", "foo", "baz", "1.2.0", "src", "Foo", "Baz", "X.elm"),
+		path,
+	)
+
+	pkg, err = Load(root)
+	require.NoError(err)
+	require.Equal(expected, pkg.ExactDependencies)
+
+}
@@ -0,0 +1,196 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Solve picks the exact version of every package the given dependencies
+// depend on, directly or transitively, so that all the version ranges
+// required by them are satisfied. The newest versions are preferred. If
+// there is no combination of versions satisfying all the ranges, an error
+// explaining the first conflict found is returned.
+func Solve(r Registry, deps Dependencies) (ExactDependencies, error) {
+	s := &solver{
+		registry:  r,
+		versions:  make(map[string][]Version),
+		manifests: make(map[string]*Package),
+	}
+
+	var constraints = make(map[string][]constraint)
+	for name, rng := range deps {
+		constraints[name] = append(constraints[name], constraint{rng, ""})
+	}
+
+	return s.solve(make(ExactDependencies), constraints)
+}
+
+// constraint is a version range some package requires for one of its
+// dependencies.
+type constraint struct {
+	rng VersionRange
+	// by is the package that requires the range, which is empty if it is
+	// the package being installed.
+	by string
+}
+
+func (c constraint) String() string {
+	if c.by == "" {
+		return c.rng.String()
+	}
+	return fmt.Sprintf("%s (required by %s)", c.rng, c.by)
+}
+
+type solver struct {
+	registry Registry
+	// versions are the versions of every package, from newest to oldest.
+	versions map[string][]Version
+	// manifests are the manifests of every version of a package, by
+	// package and version.
+	manifests map[string]*Package
+}
+
+// solve picks a version for the first package in alphabetical order that
+// has constraints but no version yet, and solves the rest of packages for
+// every version of it that satisfies all its constraints until a solution
+// is found.
+func (s *solver) solve(exact ExactDependencies, constraints map[string][]constraint) (ExactDependencies, error) {
+	var pending []string
+	for name := range constraints {
+		if _, ok := exact[name]; !ok {
+			pending = append(pending, name)
+		}
+	}
+
+	if len(pending) == 0 {
+		return exact, nil
+	}
+
+	sort.Strings(pending)
+	name := pending[0]
+	versions, err := s.candidates(name, constraints[name])
+	if err != nil {
+		return nil, err
+	}
+
+	// the error of the newest version is kept, since it is usually the one
+	// the user expects to be picked
+	var conflict error
+	for _, v := range versions {
+		result, err := s.pick(name, v, exact, constraints)
+		if err == nil {
+			return result, nil
+		}
+
+		if conflict == nil {
+			conflict = err
+		}
+	}
+
+	if conflict == nil {
+		conflict = fmt.Errorf(
+			"pkg: no version of %s satisfies %s",
+			name,
+			joinConstraints(constraints[name]),
+		)
+	}
+	return nil, conflict
+}
+
+// pick solves the rest of packages after picking the given version of a
+// package.
+func (s *solver) pick(
+	name string,
+	v Version,
+	exact ExactDependencies,
+	constraints map[string][]constraint,
+) (ExactDependencies, error) {
+	manifest, err := s.manifest(name, v)
+	if err != nil {
+		return nil, err
+	}
+
+	by := fmt.Sprintf("%s %s", name, v)
+	newConstraints := copyConstraints(constraints)
+	for dep, rng := range manifest.Dependencies {
+		if depVersion, ok := exact[dep]; ok && !rng.Contains(depVersion) {
+			return nil, fmt.Errorf(
+				"pkg: %s requires %s %s, but %s %s was already picked for %s",
+				by, dep, rng, dep, depVersion, joinConstraints(constraints[dep]),
+			)
+		}
+		newConstraints[dep] = append(newConstraints[dep], constraint{rng, by})
+	}
+
+	newExact := make(ExactDependencies, len(exact)+1)
+	for k, v := range exact {
+		newExact[k] = v
+	}
+	newExact[name] = v
+
+	return s.solve(newExact, newConstraints)
+}
+
+// candidates returns the versions of the given package satisfying all the
+// given constraints, from newest to oldest.
+func (s *solver) candidates(name string, constraints []constraint) ([]Version, error) {
+	versions, ok := s.versions[name]
+	if !ok {
+		var err error
+		versions, err = s.registry.Versions(name)
+		if err == ErrPackageNotFound {
+			return nil, fmt.Errorf("pkg: could not find package %s in the registry", name)
+		} else if err != nil {
+			return nil, err
+		}
+
+		sort.Slice(versions, func(i, j int) bool {
+			return versions[j].Less(versions[i])
+		})
+		s.versions[name] = versions
+	}
+
+	var result []Version
+Outer:
+	for _, v := range versions {
+		for _, c := range constraints {
+			if !c.rng.Contains(v) {
+				continue Outer
+			}
+		}
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+func (s *solver) manifest(name string, v Version) (*Package, error) {
+	key := fmt.Sprintf("%s@%s", name, v)
+	if m, ok := s.manifests[key]; ok {
+		return m, nil
+	}
+
+	m, err := s.registry.Manifest(name, v)
+	if err != nil {
+		return nil, err
+	}
+
+	s.manifests[key] = m
+	return m, nil
+}
+
+func copyConstraints(constraints map[string][]constraint) map[string][]constraint {
+	var result = make(map[string][]constraint, len(constraints))
+	for name, cs := range constraints {
+		result[name] = append([]constraint(nil), cs...)
+	}
+	return result
+}
+
+func joinConstraints(constraints []constraint) string {
+	var strs = make([]string, len(constraints))
+	for i, c := range constraints {
+		strs[i] = c.String()
+	}
+	return strings.Join(strs, " and ")
+}
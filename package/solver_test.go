@@ -0,0 +1,171 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// memRegistry is a registry in memory, in which the manifests of the
+// packages are indexed by "name@version".
+type memRegistry map[string]*Package
+
+func (r memRegistry) Versions(name string) ([]Version, error) {
+	var versions []Version
+	for key := range r {
+		i := strings.LastIndex(key, "@")
+		if key[:i] != name {
+			continue
+		}
+
+		var v Version
+		if err := v.UnmarshalText([]byte(key[i+1:])); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+
+	if len(versions) == 0 {
+		return nil, ErrPackageNotFound
+	}
+	return versions, nil
+}
+
+func (r memRegistry) Manifest(name string, v Version) (*Package, error) {
+	if pkg, ok := r[name+"@"+v.String()]; ok {
+		return pkg, nil
+	}
+	return nil, ErrPackageNotFound
+}
+
+func (r memRegistry) Extract(name string, v Version, dir string) error {
+	return fmt.Errorf("not implemented")
+}
+
+func deps(ranges ...string) Dependencies {
+	var deps = make(Dependencies)
+	for i := 0; i < len(ranges); i += 2 {
+		var rng VersionRange
+		if err := rng.UnmarshalText([]byte(ranges[i+1])); err != nil {
+			panic(err)
+		}
+		deps[ranges[i]] = rng
+	}
+	return deps
+}
+
+var testRegistry = memRegistry{
+	"elm-lang/core@4.0.5": &Package{},
+	"elm-lang/core@5.0.0": &Package{},
+	"elm-lang/core@5.1.1": &Package{},
+	"elm-lang/html@2.0.0": &Package{
+		Dependencies: deps(
+			"elm-lang/core", "5.0.0 <= v < 6.0.0",
+			"elm-lang/virtual-dom", "2.0.0 <= v < 3.0.0",
+		),
+	},
+	"elm-lang/virtual-dom@2.0.4": &Package{
+		Dependencies: deps("elm-lang/core", "5.0.0 <= v < 6.0.0"),
+	},
+	"old/html@1.0.0": &Package{
+		Dependencies: deps("elm-lang/core", "4.0.0 <= v < 5.0.0"),
+	},
+	// the newest version of foo/bar requires an old version of core, so
+	// the previous version has to be picked
+	"foo/bar@1.0.0": &Package{
+		Dependencies: deps("elm-lang/core", "5.0.0 <= v < 6.0.0"),
+	},
+	"foo/bar@1.1.0": &Package{
+		Dependencies: deps("old/html", "1.0.0 <= v < 2.0.0"),
+	},
+}
+
+func TestSolve(t *testing.T) {
+	cases := []struct {
+		name     string
+		deps     Dependencies
+		expected ExactDependencies
+		err      string
+	}{
+		{
+			"no dependencies",
+			nil,
+			ExactDependencies{},
+			"",
+		},
+		{
+			"newest version",
+			deps("elm-lang/core", "4.0.0 <= v < 6.0.0"),
+			ExactDependencies{"elm-lang/core": Version{5, 1, 1}},
+			"",
+		},
+		{
+			"transitive",
+			deps(
+				"elm-lang/core", "5.0.0 <= v < 6.0.0",
+				"elm-lang/html", "2.0.0 <= v < 3.0.0",
+			),
+			ExactDependencies{
+				"elm-lang/core":        Version{5, 1, 1},
+				"elm-lang/html":        Version{2, 0, 0},
+				"elm-lang/virtual-dom": Version{2, 0, 4},
+			},
+			"",
+		},
+		{
+			"backtracking",
+			deps(
+				"elm-lang/core", "5.0.0 <= v < 6.0.0",
+				"foo/bar", "1.0.0 <= v < 2.0.0",
+			),
+			ExactDependencies{
+				"elm-lang/core": Version{5, 1, 1},
+				"foo/bar":       Version{1, 0, 0},
+			},
+			"",
+		},
+		{
+			"no version in range",
+			deps("elm-lang/core", "6.0.0 <= v < 7.0.0"),
+			nil,
+			"pkg: no version of elm-lang/core satisfies 6.0.0 <= v < 7.0.0",
+		},
+		{
+			"conflict",
+			deps(
+				"elm-lang/core", "5.0.0 <= v < 6.0.0",
+				"old/html", "1.0.0 <= v < 2.0.0",
+			),
+			nil,
+			"pkg: old/html 1.0.0 requires elm-lang/core 4.0.0 <= v < 5.0.0, but elm-lang/core 5.1.1 was already picked for 5.0.0 <= v < 6.0.0",
+		},
+		{
+			"unknown package",
+			deps("foo/baz", "1.0.0 <= v < 2.0.0"),
+			nil,
+			"pkg: could not find package foo/baz in the registry",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			result, err := Solve(testRegistry, c.deps)
+			if c.err != "" {
+				require.EqualError(t, err, c.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, c.expected, result)
+			}
+		})
+	}
+}
+
+func TestVersionRangeContains(t *testing.T) {
+	rng := VersionRange{Version{1, 2, 0}, Version{2, 0, 0}}
+	require.False(t, rng.Contains(Version{1, 1, 9}))
+	require.True(t, rng.Contains(Version{1, 2, 0}))
+	require.True(t, rng.Contains(Version{1, 10, 3}))
+	require.False(t, rng.Contains(Version{2, 0, 0}))
+}
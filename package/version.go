@@ -33,6 +33,11 @@ func (vr VersionRange) MarshalText() ([]byte, error) {
 	return []byte(vr.String()), nil
 }
 
+// Contains reports whether the given version is in the range.
+func (vr VersionRange) Contains(v Version) bool {
+	return !v.Less(vr.Min) && v.Less(vr.Max)
+}
+
 func (vr VersionRange) String() string {
 	return fmt.Sprintf("%s <= v < %s", vr.Min, vr.Max)
 }
@@ -64,3 +69,13 @@ func (v Version) MarshalText() ([]byte, error) {
 func (v Version) String() string {
 	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
 }
+
+// Less reports whether the version is older than the given one.
+func (v Version) Less(other Version) bool {
+	for i := range v {
+		if v[i] != other[i] {
+			return v[i] < other[i]
+		}
+	}
+	return false
+}
@@ -1 +1,5 @@
 package native
+
+func Add(a, b interface{}) interface{} {
+	return nil
+}
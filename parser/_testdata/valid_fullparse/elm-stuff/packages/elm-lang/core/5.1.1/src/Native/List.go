@@ -1 +1,5 @@
 package native
+
+func Cons(head, tail interface{}) interface{} {
+	return nil
+}
@@ -14,6 +14,10 @@ func parseModule(p *parser) *ast.ModuleDecl {
 	prevRegion := p.startRegion()
 
 	stepOut := p.indentedBlock()
+	if p.is(token.Port) {
+		decl.IsPort = true
+		decl.Port = p.expect(token.Port)
+	}
 	decl.Module = p.expect(token.Module)
 	decl.Name = parseModuleName(p)
 
@@ -24,6 +28,7 @@ func parseModule(p *parser) *ast.ModuleDecl {
 
 	p.endRegion(prevRegion)
 	stepOut()
+	decl.Doc = p.takeDoc()
 	return decl
 }
 
@@ -135,6 +140,7 @@ func parseExposedIdent(p *parser, parsingUnion bool) ast.ExposedIdent {
 
 func parseDecl(p *parser) ast.Decl {
 	prevRegion := p.startRegion()
+	doc := p.takeDoc()
 	var decl ast.Decl
 	switch p.tok.Type {
 	case token.TypeDef:
@@ -143,6 +149,9 @@ func parseDecl(p *parser) ast.Decl {
 	case token.Infixl, token.Infixr, token.Infix:
 		decl = parseInfixDecl(p)
 
+	case token.Port:
+		decl = parsePortDecl(p)
+
 	case token.Identifier:
 		if p.tok.Value == "_" {
 			decl = parseDestructuringAssignment(p)
@@ -167,6 +176,15 @@ func parseDecl(p *parser) ast.Decl {
 
 	p.endRegion(prevRegion)
 
+	switch decl := decl.(type) {
+	case *ast.Definition:
+		decl.Doc = doc
+	case *ast.AliasDecl:
+		decl.Doc = doc
+	case *ast.UnionDecl:
+		decl.Doc = doc
+	}
+
 	if p.mode.Is(SkipDefinitions) {
 		p.skipUntilNextFixity()
 	}
@@ -289,6 +307,16 @@ func parseConstructor(p *parser) *ast.Constructor {
 	return c
 }
 
+func parsePortDecl(p *parser) ast.Decl {
+	stepOut := p.indentedBlock()
+	defer stepOut()
+	decl := &ast.PortDecl{Port: p.expect(token.Port)}
+	decl.Name = parseLowerName(p)
+	decl.Colon = p.expect(token.Colon)
+	decl.Type = p.expectType()
+	return decl
+}
+
 func parseDefinition(p *parser) ast.Decl {
 	decl := new(ast.Definition)
 
@@ -330,7 +358,7 @@ func parseDefinition(p *parser) ast.Decl {
 	decl.Args = parseFuncArgs(p, token.Assign)
 	decl.Eq = p.expect(token.Assign)
 	stepOut := p.indentedBlockAt(indent, line)
-	decl.Body = parseExpr(p)
+	decl.Body = expectExpr(p)
 	stepOut()
 	return decl
 }
@@ -281,8 +281,7 @@ func parseExpr(p *parser) ast.Expr {
 		defer p.endRegion(p.startRegion())
 		return parseLet(p)
 	case token.EOF:
-		p.errorMessage(p.tok.Position, "Unexpected EOF")
-		panic(bailout{})
+		p.errorUnexpectedEOF()
 	}
 
 	term := parseTerm(p)
@@ -303,6 +302,10 @@ func atExprFinalizer(p *parser) bool {
 	return !p.isCorrectlyIndented()
 }
 
+// appPrecedence is the precedence of function application, which is greater
+// than the precedence of any operator.
+const appPrecedence = ^uint(0)
+
 const errorMsgMultipleNonAssocOps = `Binary operators %s and %s are non associative and have the same precedence. Consider using parenthesis to disambiguate.`
 
 func parseBinaryOp(p *parser, lhs ast.Expr, precedence uint) ast.Expr {
@@ -315,16 +318,19 @@ func parseBinaryOp(p *parser, lhs ast.Expr, precedence uint) ast.Expr {
 		return parseBinaryOp(p, &ast.FuncApp{
 			Func: lhs,
 			Args: []ast.Expr{
-				parseTerm(p),
+				expectTerm(p),
 			},
-		}, 0)
+		}, precedence)
 	}
 
 	opInfo := p.opInfo(p.tok.Value)
 	for p.tok.Type == token.Op &&
 		opInfo.Precedence >= precedence {
 		op := parseOp(p)
-		rhs := parseTerm(p)
+		// parse only the function application, if any, because the
+		// operators that follow need to be parsed taking into account
+		// their precedence
+		rhs := parseBinaryOp(p, expectTerm(p), appPrecedence)
 		prevOp := opInfo
 		opInfo = p.opInfo(p.tok.Value)
 
@@ -336,10 +342,6 @@ func parseBinaryOp(p *parser, lhs ast.Expr, precedence uint) ast.Expr {
 			opInfo = p.opInfo(p.tok.Value)
 		}
 
-		if p.tok.Type != token.Op {
-			rhs = parseBinaryOp(p, rhs, 0)
-		}
-
 		lhs = &ast.BinaryOp{
 			Op:  op,
 			Lhs: lhs,
@@ -360,6 +362,31 @@ func parseBinaryOp(p *parser, lhs ast.Expr, precedence uint) ast.Expr {
 	return lhs
 }
 
+// expectExpr parses an expression, which must be there.
+func expectExpr(p *parser) ast.Expr {
+	expr := parseExpr(p)
+	if expr == nil {
+		p.errorMessage(p.tok.Position, "I ran into an unexpected %q. I was expecting an expression.", p.tok.Value)
+		panic(bailout{})
+	}
+	return expr
+}
+
+// expectTerm parses a term, which must be there because there is no other
+// way to continue parsing the expression.
+func expectTerm(p *parser) ast.Expr {
+	if p.is(token.EOF) {
+		p.errorUnexpectedEOF()
+	}
+
+	term := parseTerm(p)
+	if term == nil {
+		p.errorMessage(p.tok.Position, "I ran into an unexpected %q. I was expecting an expression.", p.tok.Value)
+		panic(bailout{})
+	}
+	return term
+}
+
 func tryFlattenApp(expr ast.Expr) ast.Expr {
 	if app, ok := expr.(*ast.FuncApp); ok {
 		return flattenApp(app)
@@ -381,17 +408,9 @@ func parseLet(p *parser) *ast.LetExpr {
 	indent, line := p.currentPos()
 	e := &ast.LetExpr{Let: p.expect(token.Let)}
 	stepOut := p.indentedBlockAt(indent, line)
+	column := p.tok.Column
 	for p.is(token.Identifier) || p.is(token.LeftParen) || p.is(token.LeftBrace) {
-		switch p.tok.Type {
-		case token.Identifier:
-			if p.tok.Value != "_" {
-				e.Decls = append(e.Decls, parseDefinition(p))
-			} else {
-				e.Decls = append(e.Decls, parseDestructuringAssignment(p))
-			}
-		case token.LeftParen, token.LeftBrace:
-			e.Decls = append(e.Decls, parseDestructuringAssignment(p))
-		}
+		e.Decls = append(e.Decls, parseLetDecl(p, column))
 	}
 
 	stepOut()
@@ -403,6 +422,32 @@ func parseLet(p *parser) *ast.LetExpr {
 	return e
 }
 
+// parseLetDecl parses a declaration of a let expression whose declarations
+// start at the given column. If there is a syntax error in it, the code
+// until the next declaration of the let or its "in" is skipped.
+func parseLetDecl(p *parser, column int) (decl ast.Decl) {
+	start, state := p.tok, p.state()
+	defer func() {
+		if r := recover(); r != nil {
+			p.resync(r, start, state, func() bool {
+				return p.tok.Offset > start.Offset && (p.is(token.In) || p.tok.Column <= column)
+			})
+
+			// the let expression has not been finished, so the error
+			// belongs to the enclosing declaration
+			if !p.is(token.In) && p.tok.Column < column {
+				panic(bailout{})
+			}
+			decl = &ast.BadDecl{StartPos: start.Offset, EndPos: p.tok.Offset}
+		}
+	}()
+
+	if p.is(token.Identifier) && p.tok.Value != "_" {
+		return parseDefinition(p)
+	}
+	return parseDestructuringAssignment(p)
+}
+
 func parseIf(p *parser) *ast.IfExpr {
 	var expr = new(ast.IfExpr)
 
@@ -471,11 +516,35 @@ func parseCaseBranch(p *parser, alignment int) *ast.CaseBranch {
 
 	branch.Arrow = p.expect(token.Arrow)
 	stepOut := p.indentedBlockAt(indent, line)
-	branch.Expr = parseExpr(p)
+	branch.Expr = parseBranchExpr(p, alignment)
 	stepOut()
 	return branch
 }
 
+// parseBranchExpr parses the expression of a case branch whose patterns
+// start at the given column. If there is a syntax error in it, the code
+// until the next branch, or the end of the case expression, is skipped.
+func parseBranchExpr(p *parser, alignment int) (expr ast.Expr) {
+	start, state := p.tok, p.state()
+	defer func() {
+		if r := recover(); r != nil {
+			p.resync(r, start, state, func() bool {
+				return p.tok.Column <= alignment
+			})
+			expr = &ast.BadExpr{StartPos: start.Offset, EndPos: p.tok.Offset}
+		}
+	}()
+
+	// the branch has no expression, and the next branch or the code after
+	// the case expression starts right away
+	if !p.is(token.EOF) && p.tok.Column <= alignment {
+		p.errorMessage(p.tok.Position, "I was expecting the expression of the branch, indented more than its pattern.")
+		panic(bailout{})
+	}
+
+	return expectExpr(p)
+}
+
 func parseLiteral(p *parser) *ast.BasicLit {
 	var typ ast.BasicLitType
 	switch p.tok.Type {
@@ -53,6 +53,13 @@ func Module(module string, exposed ExposedListAssert) DeclAssert {
 	}
 }
 
+func PortModule(module string, exposed ExposedListAssert) DeclAssert {
+	return func(t *testing.T, decl ast.Decl) {
+		Module(module, exposed)(t, decl)
+		require.True(t, decl.(*ast.ModuleDecl).IsPort, "expecting module to be a port module")
+	}
+}
+
 func Import(module string, alias ExprAssert, exposed ExposedListAssert) ImportAssert {
 	return func(t *testing.T, decl ast.Decl) {
 		d, ok := decl.(*ast.ImportDecl)
@@ -163,6 +170,15 @@ func Alias(
 	}
 }
 
+func Port(name string, typeAssert TypeAssert) DeclAssert {
+	return func(t *testing.T, decl ast.Decl) {
+		port, ok := decl.(*ast.PortDecl)
+		require.True(t, ok, "expected a port decl, is %T", decl)
+		assertIdent(t, name, port.Name)
+		typeAssert(t, port.Type)
+	}
+}
+
 func Union(
 	name string,
 	args []string,
@@ -272,6 +288,19 @@ func Record(fields ...recordFieldAssert) TypeAssert {
 	}
 }
 
+func ExtendedRecord(ext string, fields ...recordFieldAssert) TypeAssert {
+	return func(t *testing.T, typ ast.Type) {
+		record, ok := typ.(*ast.RecordType)
+		require.True(t, ok, "type is not record type")
+		require.NotNil(t, record.Extended, "record type is not extended")
+		require.Equal(t, ext, record.Extended.Name, "invalid extended type variable")
+		require.Equal(t, len(fields), len(record.Fields), "invalid number of record fields")
+		for i := range fields {
+			fields[i](t, record.Fields[i])
+		}
+	}
+}
+
 func RecordField(name string, assertType TypeAssert) recordFieldAssert {
 	return func(t *testing.T, f *ast.RecordField) {
 		require.Equal(t, name, f.Name.Name, "invalid record field name")
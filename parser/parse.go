@@ -2,14 +2,20 @@ package parser
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/elm-tangram/tangram/ast"
+	"github.com/elm-tangram/tangram/cache"
 	"github.com/elm-tangram/tangram/operator"
 	"github.com/elm-tangram/tangram/package"
 	"github.com/elm-tangram/tangram/report"
@@ -49,6 +55,9 @@ type Session struct {
 	*report.Reporter
 	*source.CodeMap
 	*operator.Table
+	// Cache is the build cache used to avoid parsing again the modules that
+	// did not change. If it is nil, all modules are always parsed.
+	Cache *cache.Cache
 }
 
 // NewSession creates a new parsing session with a way of diagnosing errors
@@ -58,7 +67,7 @@ func NewSession(
 	cm *source.CodeMap,
 	ops *operator.Table,
 ) *Session {
-	return &Session{r, cm, ops}
+	return &Session{r, cm, ops, nil}
 }
 
 // ParseResult is the result after a full parse, which is a set of parsed files
@@ -73,6 +82,10 @@ type ParseResult struct {
 	Modules map[string]*ast.Module
 }
 
+// ErrDiagnostics is returned by Parse when the diagnostics have been sent
+// to stderr and at least one of them is an error.
+var ErrDiagnostics = errors.New("parser: errors were found during parsing")
+
 // Parse will parse the file at the given path and all its imported modules
 // with the given mode of parsing.
 func Parse(path string, mode ParseMode) (result *ast.Package, err error) {
@@ -91,33 +104,49 @@ func Parse(path string, mode ParseMode) (result *ast.Package, err error) {
 		emitter = report.Errors(!mode.Is(SkipWarnings))
 	}
 
-	var optable *operator.Table
-	if mode.Is(JustModule) {
-		optable = operator.BuiltinTable()
-	} else {
-		optable = operator.NewTable()
+	sess := NewSession(report.NewReporter(cm, emitter), cm, NewOperatorTable(mode))
+	result = ParsePackage(sess, pkg, path, mode)
+	if !mode.Is(StderrDiagnostics) {
+		return result, sess.Emit()
 	}
 
-	reporter := report.NewReporter(cm, emitter)
-	sess := NewSession(reporter, cm, optable)
+	if err := sess.Emit(); err != nil {
+		return nil, err
+	}
 
-	p := newParser(sess)
-	defer catchBailout()
-	if !mode.Is(StderrDiagnostics) {
-		defer func() {
-			err = sess.Emit()
-		}()
-	} else {
-		defer sess.Emit()
+	if sess.HasErrors() {
+		return result, ErrDiagnostics
+	}
+	return result, nil
+}
+
+// NewOperatorTable returns the operator table that needs to be used in a
+// session with the given parse mode. If only the given module is going to be
+// parsed, the builtin operators are loaded because core will not be parsed.
+func NewOperatorTable(mode ParseMode) *operator.Table {
+	if mode.Is(JustModule) {
+		return operator.BuiltinTable()
 	}
+	return operator.NewTable()
+}
 
-	fp := newFullParser(p, pkg, optable, cm, reporter)
-	result = fp.parse(path)
+// ParsePackage parses the file at the given path, which must be inside the
+// given package, and all its imported modules with the given mode of parsing.
+// Diagnostics will not be emitted, that is up to the caller, who can use the
+// session reporter for that.
+// Names will only be resolved if all the modules are fully parsed, that is,
+// when neither JustModule nor SkipDefinitions are in the mode. The cache of
+// the session is only used in that case as well.
+// Modules are parsed concurrently, but the result is always the same as if
+// they were parsed one after the other.
+func ParsePackage(sess *Session, pkg *pkg.Package, path string, mode ParseMode) (result *ast.Package) {
+	defer catchBailout()
+	result = newFullParser(sess, pkg, mode).parse(path)
 	return
 }
 
 type fullParser struct {
-	p        *parser
+	sess     *Session
 	pkg      *pkg.Package
 	optable  *operator.Table
 	cm       *source.CodeMap
@@ -125,32 +154,87 @@ type fullParser struct {
 	reporter *report.Reporter
 	resolver *resolver
 	modCache map[string]string
+	// natives contains the paths of the native modules imported by every
+	// module, which are only found during the first pass.
+	natives map[string][]string
+	mode    ParseMode
+	// cache is the build cache, which may be nil.
+	cache *cache.Cache
+	// entries contains the cache entries of the modules found, by path.
+	entries map[string]*cache.Entry
+	// headers contains the headers of the modules found, by name.
+	headers map[string]*cache.Header
+	// path is the path of the module the package is parsed from.
+	path string
+	// workers is the maximum number of modules parsed at the same time.
+	workers int
+	// mut guards the fields written during the first pass, in which
+	// modules are parsed concurrently.
+	mut sync.Mutex
+	// headerReporter collects the diagnostics of the headers parsed in the
+	// first pass, which are found again when the modules are fully parsed.
+	headerReporter *report.Reporter
+	// depsReported reports whether the dependencies of the package could
+	// not be found and it was already reported.
+	depsReported bool
+	// found contains what the first pass found in every file, by path.
+	found map[string]*moduleFound
+	// collected contains the paths of the modules found in the first pass
+	// that are part of the package.
+	collected map[string]bool
 }
 
-func newFullParser(p *parser, pkg *pkg.Package, optable *operator.Table, cm *source.CodeMap, r *report.Reporter) *fullParser {
-	return &fullParser{
-		p,
-		pkg,
-		optable,
-		cm,
-		nil,
-		r,
-		&resolver{reporter: r},
-		make(map[string]string),
+func newFullParser(sess *Session, pkg *pkg.Package, mode ParseMode) *fullParser {
+	p := &fullParser{
+		sess:      sess,
+		pkg:       pkg,
+		optable:   sess.Table,
+		cm:        sess.CodeMap,
+		reporter:  sess.Reporter,
+		resolver:  &resolver{reporter: sess.Reporter},
+		modCache:  make(map[string]string),
+		natives:   make(map[string][]string),
+		mode:      mode,
+		entries:   make(map[string]*cache.Entry),
+		headers:   make(map[string]*cache.Header),
+		found:     make(map[string]*moduleFound),
+		collected: make(map[string]bool),
+		workers:   runtime.GOMAXPROCS(0),
+		// the diagnostics are only collected, never emitted
+		headerReporter: report.NewReporter(sess.CodeMap, nil),
+	}
+
+	if !mode.Is(JustModule) && !mode.Is(SkipDefinitions) {
+		p.cache = sess.Cache
 	}
+	return p
 }
 
 func (p *fullParser) parse(path string) *ast.Package {
+	p.path = path
+
 	// do a first parse to gather all the imports and operator fixities
-	p.firstPass(path, make(map[string]struct{}))
+	pool := newWorkerPool(p.workers)
+	visited := map[string]struct{}{path: {}}
+	pool.Go(func() {
+		p.firstPass(pool, path, visited)
+	})
+	pool.Wait()
+	p.collect(path)
+
+	// the module the package is parsed from could not be read or its header
+	// could not be parsed
+	if p.g == nil {
+		p.reportHeaderErrors(nil)
+		return nil
+	}
 
 	modules, err := p.g.Resolve()
 	switch err := err.(type) {
 	case *pkg.CircularDependencyError:
-		p.error(
-			path,
-			fmt.Sprintf("I found a circular dependency in your code between these modules:\n- %s\n- %s", err.Modules[0], err.Modules[1]),
-		)
+		for _, cycle := range err.Cycles {
+			p.reportCycle(cycle)
+		}
 	case nil:
 	default:
 		p.error(
@@ -159,12 +243,36 @@ func (p *fullParser) parse(path string) *ast.Package {
 		)
 	}
 
+	// all the operator fixities are known after the first pass, so all the
+	// modules can be parsed at the same time
+	files := make([]*ast.Module, len(modules))
+	pool = newWorkerPool(p.workers)
+	for i, m := range modules {
+		i, m := i, m
+		pool.Go(func() {
+			files[i] = p.completeParse(m)
+		})
+	}
+	pool.Wait()
+
 	r := &ast.Package{Order: modules, Modules: make(map[string]*ast.Module)}
-	for _, m := range modules {
-		if file := p.completeParse(m); file != nil {
-			r.Modules[m] = file
+	parsed := make(map[string]bool, len(modules))
+	for i, m := range modules {
+		if files[i] != nil {
+			r.Modules[m] = files[i]
+			parsed[files[i].Path] = true
 		}
 	}
+	p.reportHeaderErrors(parsed)
+
+	if p.mode.Is(JustModule) || p.mode.Is(SkipDefinitions) {
+		return r
+	}
+
+	// names cannot be resolved if some modules could not be found or parsed
+	if p.reporter.HasErrors() {
+		return nil
+	}
 
 	if !p.resolver.resolve(r) {
 		return nil
@@ -173,62 +281,243 @@ func (p *fullParser) parse(path string) *ast.Package {
 	return r
 }
 
-func (p *fullParser) firstPass(path string, visited map[string]struct{}) {
-	if err := p.cm.Add(path); err != nil {
-		p.error(path, "Oops, unexpected error reading file: %s", err)
-		panic(bailout{})
+// firstPass parses the header of the module at the given path and finds the
+// modules it imports. The modules imported that were not visited yet are
+// parsed in the given pool. Nothing that depends on the order in which
+// modules are parsed is done here, but after all of them have been found.
+func (p *fullParser) firstPass(pool *workerPool, path string, visited map[string]struct{}) {
+	found := new(moduleFound)
+	if found.err = p.cm.Add(path); found.err == nil {
+		found.header = p.header(path)
 	}
-	source := p.cm.Source(path)
-	scanner := source.Scanner()
 
-	p.p.init(source.Path, scanner, SkipDefinitions)
-	file := parseFile(p.p)
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	p.found[path] = found
+	if found.header == nil || p.mode.Is(JustModule) {
+		return
+	}
+
+	for _, importMod := range found.header.Imports {
+		// the module a name refers to depends on the package of the module
+		// importing it, so imports are always looked up
+		importPath, err := p.pkg.FindImport(path, importMod)
+		found.imports = append(found.imports, importFound{importPath, err})
+		if err != nil || isNative(importPath) {
+			continue
+		}
+
+		if _, ok := visited[importPath]; !ok {
+			visited[importPath] = struct{}{}
+			pool.Go(func() {
+				p.firstPass(pool, importPath, visited)
+			})
+		}
+	}
+}
+
+// moduleFound is what the first pass found in the file of a module.
+type moduleFound struct {
+	// err is the error reading the file, if any.
+	err error
+	// header is the header of the module, which is nil if the file could
+	// not be read or its header could not be parsed.
+	header *cache.Header
+	// imports contains the modules imported, in the same order as the
+	// imports of the header.
+	imports []importFound
+}
+
+// importFound is the path of a module imported or the error finding it.
+type importFound struct {
+	path string
+	err  error
+}
+
+// collect goes through the modules found in the first pass, starting at the
+// module at the given path, adding them to the dependency graph and
+// reporting the problems with their imports. They are always visited in
+// the same order, so the result does not depend on the order in which the
+// modules were parsed.
+func (p *fullParser) collect(path string) {
+	visited := make(map[string]struct{})
+	queue := []string{path}
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+		queue = append(queue, p.collectModule(path, visited)...)
+	}
+}
+
+// collectModule adds the module at the given path to the dependency graph
+// and returns the paths of the modules it imports that were not visited
+// yet.
+func (p *fullParser) collectModule(path string, visited map[string]struct{}) []string {
+	p.collected[path] = true
+	found := p.found[path]
+	if found.err != nil {
+		p.error(path, "Oops, unexpected error reading file: %s", found.err)
+		return nil
+	}
+
+	header := found.header
+	if header == nil {
+		return nil
+	}
 
-	mod := file.Module.ModuleName()
-	// TODO: check module name corresponds to the path
+	mod := p.checkModuleName(path, header.Name)
 	visited[mod] = struct{}{}
+	p.headers[mod] = header
+	if _, ok := p.modCache[mod]; !ok {
+		p.modCache[mod] = path
+	}
+
 	if p.g == nil {
 		p.g = pkg.NewGraph(mod)
 	}
 
-	if p.p.mode.Is(JustModule) {
-		return
+	if p.mode.Is(JustModule) {
+		return nil
 	}
 
-	for _, imp := range file.Imports {
-		importMod := imp.ModuleName()
-
-		importPath, ok := p.modCache[importMod]
-		if !ok {
-			var err error
-			importPath, err = p.pkg.FindModule(importMod)
-			if err != nil {
-				p.error(
-					path,
-					fmt.Sprintf("I could not find module %q in any of the package source directories or any of its dependencies. Maybe you're missing a dependency?", importMod),
-				)
-				continue
-			}
+	var next []string
+	for i, importMod := range header.Imports {
+		importPath, err := found.imports[i].path, found.imports[i].err
+		if err != nil {
+			p.importError(path, importMod, err)
+			continue
+		}
+
+		if prev, ok := p.modCache[importMod]; !ok {
 			p.modCache[importMod] = importPath
+		} else if prev != importPath {
+			p.moduleConflict(path, importMod, prev, importPath)
+			continue
 		}
 
 		if isNative(importPath) {
-			file.NativeImports = append(file.NativeImports, importPath)
+			p.natives[mod] = append(p.natives[mod], importPath)
 		} else {
 			p.g.Add(importMod, mod)
 
 			if _, ok := visited[importMod]; !ok {
-				p.firstPass(importPath, visited)
+				visited[importMod] = struct{}{}
+				next = append(next, importPath)
 			}
 		}
 	}
 
+	for _, f := range header.Fixities {
+		p.optable.Add(f.Op, mod, f.Assoc, f.Precedence)
+	}
+	return next
+}
+
+// header returns the header of the module at the given path, which is taken
+// from the cache if the module did not change since it was cached. If the
+// module can not be read or its header can not be parsed, it is reported and
+// nil is returned.
+func (p *fullParser) header(path string) *cache.Header {
+	source := p.cm.Source(path)
+	var entry *cache.Entry
+	if p.cache != nil {
+		content, err := readSource(source)
+		if err != nil {
+			p.error(path, "Oops, unexpected error reading file: %s", err)
+			return nil
+		}
+
+		hash := cache.Sum(content)
+		var ok bool
+		entry, ok = p.cache.Get(path)
+		if !ok || entry.Hash != hash {
+			entry = &cache.Entry{Hash: hash}
+		}
+
+		p.mut.Lock()
+		p.entries[path] = entry
+		p.mut.Unlock()
+
+		if entry.Header != nil {
+			return entry.Header
+		}
+	}
+
+	file := p.parseHeader(path, p.headerReporter)
+	if file == nil {
+		return nil
+	}
+
+	header := newHeader(file)
+	if entry != nil {
+		entry.Header = header
+	}
+	return header
+}
+
+// newHeader returns the header of the given module. The interface of the
+// module is made of its name, the names it exposes and its fixity
+// declarations.
+func newHeader(file *ast.Module) *cache.Header {
+	header := &cache.Header{Name: file.Module.ModuleName()}
+	for _, imp := range file.Imports {
+		header.Imports = append(header.Imports, imp.ModuleName())
+	}
+
+	var iface = [][]byte{
+		[]byte(header.Name),
+		[]byte(exposedString(file.Module.Exposing)),
+	}
 	for _, d := range file.Decls {
 		if fixity, ok := d.(*ast.InfixDecl); ok {
 			n, _ := strconv.Atoi(fixity.Precedence.Value)
-			p.optable.Add(fixity.Op.Name, mod, fixity.Assoc, uint(n))
+			header.Fixities = append(header.Fixities, cache.Fixity{
+				Op:         fixity.Op.Name,
+				Assoc:      fixity.Assoc,
+				Precedence: uint(n),
+			})
+			iface = append(iface, []byte(fmt.Sprintf("%s %d %d", fixity.Op.Name, fixity.Assoc, n)))
 		}
 	}
+	header.Interface = cache.Sum(iface...)
+	return header
+}
+
+// exposedString returns a textual representation of the given list of
+// exposed identifiers.
+func exposedString(list ast.ExposedList) string {
+	switch list := list.(type) {
+	case *ast.OpenList:
+		return ".."
+	case *ast.ClosedList:
+		var names = make([]string, len(list.Exposed))
+		for i, exposed := range list.Exposed {
+			switch exposed := exposed.(type) {
+			case *ast.ExposedVar:
+				names[i] = exposed.Name
+			case *ast.ExposedUnion:
+				names[i] = exposed.Type.Name + "(" + exposedString(exposed.Ctors) + ")"
+			}
+		}
+		return strings.Join(names, ",")
+	}
+	return ""
+}
+
+// readSource returns the whole content of the given source.
+func readSource(source *source.Source) ([]byte, error) {
+	if _, err := source.Src.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	content, err := ioutil.ReadAll(source.Src)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = source.Src.Seek(0, io.SeekStart)
+	return content, err
 }
 
 func isNative(path string) bool {
@@ -236,24 +525,270 @@ func isNative(path string) bool {
 }
 
 func (p *fullParser) completeParse(module string) *ast.Module {
-	path, err := p.pkg.FindModule(module)
-	if err != nil {
-		// TODO: fix this, but should be unreachable
-		panic(err)
+	path, ok := p.modCache[module]
+	if !ok {
+		p.error(p.path, "Oops, module %q was not found when looking for the imports of the package.", module)
+		return nil
+	}
+
+	entry, cached := p.entries[path]
+	if cached {
+		key := p.key(entry.Hash, p.headers[module])
+		if entry.Module != nil && entry.Key == key {
+			entry.Module.NativeImports = p.natives[module]
+			return entry.Module
+		}
+		entry.Key = key
 	}
 
 	source := p.cm.Source(path)
-	p.p.init(path, source.Scanner(), FullParse)
-	return parseFile(p.p)
+	if source == nil {
+		// the module could not be read in the first pass, which has already
+		// been reported
+		return nil
+	}
+
+	parser := newParser(p.sess)
+	parser.init(path, source.Scanner(), p.mode)
+	file := parseFile(parser)
+
+	// modules with errors or warnings are not cached so their diagnostics
+	// are reported again in the next build
+	if cached && len(p.reporter.Reports(path)) == 0 {
+		entry.Module = file
+		// the cache only makes builds faster, so builds do not fail because
+		// of it
+		_ = p.cache.Put(path, entry)
+	}
+
+	file.NativeImports = p.natives[module]
+	return file
+}
+
+// key returns the key of the module with the given content hash and header,
+// which also depends on the interfaces of the modules it imports.
+func (p *fullParser) key(hash cache.Hash, header *cache.Header) cache.Hash {
+	var parts = [][]byte{hash[:]}
+	for _, imp := range header.Imports {
+		parts = append(parts, []byte(imp))
+		if h, ok := p.headers[imp]; ok {
+			parts = append(parts, h.Interface[:])
+		}
+	}
+	return cache.Sum(parts...)
+}
+
+// reportCycle reports every import that is part of the given cycle of
+// modules in the module that contains it.
+func (p *fullParser) reportCycle(cycle []string) {
+	for i := 0; i+1 < len(cycle); i++ {
+		path := p.modCache[cycle[i]]
+		if imp := p.importDecl(path, cycle[i+1]); imp != nil {
+			p.reporter.Report(path, report.NewCircularImportError(imp, cycle))
+		}
+	}
+}
+
+// checkModuleName reports an error if the given name of the module at the
+// given path does not match the path. The name matching the path is
+// returned, so the module is still registered with the name used by the
+// modules importing it.
+func (p *fullParser) checkModuleName(path, name string) string {
+	expected, err := p.pkg.ModuleName(path)
+	if err != nil || expected == "" || expected == name {
+		return name
+	}
+
+	// modules without a valid name already have syntax errors
+	if name != "_" {
+		if file := p.reparseHeader(path); file != nil && file.Module != nil {
+			p.reporter.Report(path, report.NewModuleNameError(file.Module, expected))
+		}
+	}
+	return expected
+}
+
+// importError reports that the given module imported by the module at the
+// given path could not be found.
+func (p *fullParser) importError(path, module string, err error) {
+	var r report.Report
+	switch err := err.(type) {
+	case *pkg.ModuleNotExposedError:
+		if imp := p.importDecl(path, module); imp != nil {
+			r = report.NewModuleNotExposedError(imp, err.Package)
+		}
+	case *pkg.AmbiguousModuleError:
+		if imp := p.importDecl(path, module); imp != nil {
+			r = report.NewAmbiguousModuleError(imp, err.Packages)
+		}
+	case *pkg.DependenciesError:
+		// the error is the same for every import of a dependency
+		if !p.depsReported {
+			p.depsReported = true
+			p.error(
+				path,
+				"I could not find the dependencies of the package installed in %s: %s. Maybe you need to install them?",
+				err.Dir,
+				err.Err,
+			)
+		}
+		return
+	}
+
+	if r != nil {
+		p.reporter.Report(path, r)
+		return
+	}
+
+	p.error(
+		path,
+		"I could not find module %q in any of the package source directories or any of its dependencies. Maybe you're missing a dependency?",
+		module,
+	)
+}
+
+// moduleConflict reports that the given module imported by the module at
+// the given path is not the module with the same name imported by other
+// modules, since modules are identified by their name.
+func (p *fullParser) moduleConflict(path, module string, files ...string) {
+	sort.Strings(files)
+	if imp := p.importDecl(path, module); imp != nil {
+		p.reporter.Report(path, report.NewModuleConflictError(imp, files))
+	}
+}
+
+// importDecl returns the declaration importing the given module in the
+// module at the given path.
+func (p *fullParser) importDecl(path, module string) *ast.ImportDecl {
+	file := p.reparseHeader(path)
+	if file == nil {
+		return nil
+	}
+
+	for _, imp := range file.Imports {
+		if imp.ModuleName() == module {
+			return imp
+		}
+	}
+	return nil
+}
+
+// reparseHeader parses again the header of the module at the given path,
+// since only a summary of the headers is kept in the first pass. The syntax
+// errors of the header have already been found, so they are ignored.
+func (p *fullParser) reparseHeader(path string) *ast.Module {
+	return p.parseHeader(path, report.NewReporter(p.cm, nil))
+}
+
+// parseHeader parses the header of the module at the given path, reporting
+// its syntax errors with the given reporter. If the header can not be
+// parsed, nil is returned.
+func (p *fullParser) parseHeader(path string, r *report.Reporter) (file *ast.Module) {
+	defer catchBailout()
+	parser := newParser(NewSession(r, p.cm, p.optable))
+	parser.init(path, p.cm.Source(path).Scanner(), SkipDefinitions)
+	return parseFile(parser)
+}
+
+// reportHeaderErrors reports the diagnostics found parsing the headers of
+// the modules in the first pass, except for the modules with the given
+// paths, since parsing them again found the same diagnostics.
+func (p *fullParser) reportHeaderErrors(parsed map[string]bool) {
+	for _, path := range p.headerReporter.Paths() {
+		if parsed[path] || !p.collected[path] {
+			continue
+		}
+
+		for _, r := range p.headerReporter.Reports(path) {
+			p.reporter.Report(path, r)
+		}
+	}
 }
 
 func (p *fullParser) error(path, msg string, args ...interface{}) {
 	msg = fmt.Sprintf(msg, args...)
-	p.p.sess.Report(path, report.NewBaseReport(
+	p.reporter.Report(path, report.NewBaseReport(
 		report.SyntaxError, token.NoPos, msg, nil,
 	))
 }
 
+// workerPool runs functions concurrently, with a limited number of them
+// running at the same time.
+type workerPool struct {
+	wg  sync.WaitGroup
+	sem chan struct{}
+	mut sync.Mutex
+	// panic is the value of the first panic in any of the functions.
+	panic interface{}
+}
+
+func newWorkerPool(workers int) *workerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	return &workerPool{sem: make(chan struct{}, workers)}
+}
+
+// Go runs the given function in the pool. The function may run more
+// functions in the pool. Once a function panics, the functions that did not
+// start yet are not run.
+func (w *workerPool) Go(fn func()) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		w.sem <- struct{}{}
+		defer func() { <-w.sem }()
+		defer w.catch()
+
+		if !w.failed() {
+			fn()
+		}
+	}()
+}
+
+func (w *workerPool) catch() {
+	if r := recover(); r != nil {
+		// the stack of the goroutine that panicked is lost once the panic
+		// is recovered, so it is kept for any panic that is not a bailout
+		if _, ok := r.(bailout); !ok {
+			r = &workerPanic{r, debug.Stack()}
+		}
+
+		w.mut.Lock()
+		if w.panic == nil {
+			w.panic = r
+		}
+		w.mut.Unlock()
+	}
+}
+
+func (w *workerPool) failed() bool {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	return w.panic != nil
+}
+
+// Wait waits until all the functions run in the pool finish. If any of them
+// panicked, Wait panics with the value of the first panic. Panics other
+// than bailouts are wrapped in a workerPanic.
+func (w *workerPool) Wait() {
+	w.wg.Wait()
+	if w.panic != nil {
+		panic(w.panic)
+	}
+}
+
+// workerPanic is a panic of a function run in a worker pool, along with the
+// stack of the goroutine in which it happened.
+type workerPanic struct {
+	value interface{}
+	stack []byte
+}
+
+func (p *workerPanic) Error() string {
+	return fmt.Sprintf("%v\n\ngoroutine stack of the panic:\n%s", p.value, p.stack)
+}
+
 // ParseFrom parses the contents of the given reader and returns the
 // corresponding AST file. It will only parse itself and not the imported
 // modules, even if it's explicitly requested in the ParseMode.
@@ -270,6 +805,9 @@ func ParseFrom(name string, src io.Reader, mode ParseMode) (f *ast.Module, err e
 	loader.Add(name, string(content))
 	cm := source.NewCodeMap(loader)
 	defer cm.Close()
+	if err = cm.Add(name); err != nil {
+		return nil, err
+	}
 
 	sess := NewSession(
 		report.NewReporter(cm, report.Errors(!mode.Is(SkipWarnings))),
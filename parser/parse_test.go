@@ -2,12 +2,19 @@ package parser
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/elm-tangram/tangram/ast"
+	"github.com/elm-tangram/tangram/cache"
 	"github.com/elm-tangram/tangram/operator"
+	"github.com/elm-tangram/tangram/package"
+	"github.com/elm-tangram/tangram/report"
+	"github.com/elm-tangram/tangram/source"
+	"github.com/elm-tangram/tangram/token"
 
 	"github.com/stretchr/testify/require"
 )
@@ -258,3 +265,513 @@ func TestParseFull(t *testing.T) {
 		expected(t, f)
 	}
 }
+
+func TestParsePackageCache(t *testing.T) {
+	require := require.New(t)
+	dir, err := ioutil.TempDir("", "parser")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(copyDir(filepath.Join("_testdata", "valid_fullparse"), dir))
+	mainPath := filepath.Join(dir, "src", "Main.elm")
+	internalPath := filepath.Join(dir, "src", "Internal", "Dependency.elm")
+	depPath := filepath.Join(dir, "elm-stuff", "packages", "some", "dependency", "1.0.0", "src", "Dependency.elm")
+
+	c, err := cache.Open(filepath.Join(dir, cache.Dir))
+	require.NoError(err)
+
+	parse := func() *ast.Package {
+		p, err := pkg.Load(filepath.Dir(mainPath))
+		require.NoError(err)
+
+		cm := source.NewCodeMap(source.NewFsLoader(p))
+		defer cm.Close()
+
+		sess := NewSession(report.NewReporter(cm, report.Errors(true)), cm, NewOperatorTable(FullParse))
+		sess.Cache = c
+		result := ParsePackage(sess, p, mainPath, FullParse)
+		require.NoError(sess.Emit())
+		require.NotNil(result)
+		return result
+	}
+
+	// the modules taken from the cache are marked with a comment, so it is
+	// possible to know which modules were parsed again
+	mark := func(path string) {
+		entry, ok := c.Get(path)
+		require.True(ok, path)
+		require.NotNil(entry.Module, path)
+		entry.Module.Comments = []*ast.CommentGroup{{List: []*ast.Comment{{
+			Position: &token.Position{Source: path, Offset: 1, Line: 1, Column: 1},
+			Text:     "-- cached",
+		}}}}
+		require.NoError(c.Put(path, entry))
+	}
+
+	isCached := func(result *ast.Package, mod string) bool {
+		return len(result.Modules[mod].Comments) > 0
+	}
+
+	edit := func(path, old, new string) {
+		content, err := ioutil.ReadFile(path)
+		require.NoError(err)
+		require.Contains(string(content), old)
+		require.NoError(ioutil.WriteFile(path, []byte(strings.Replace(string(content), old, new, 1)), 0644))
+	}
+
+	expected := parse()
+	require.Len(expected.Modules, 10)
+
+	mark(mainPath)
+	mark(internalPath)
+	mark(depPath)
+	result := parse()
+	require.Equal(expected.Order, result.Order)
+	require.True(isCached(result, "Main"))
+	require.True(isCached(result, "Internal.Dependency"))
+	require.True(isCached(result, "Dependency"))
+	require.NotNil(result.Modules["Main"].Scope, "cached modules must be resolved")
+
+	// the interface of the module does not change, so the modules that
+	// import it do not need to be parsed again
+	edit(internalPath, `"hi"`, `"bye"`)
+	result = parse()
+	require.True(isCached(result, "Main"))
+	require.False(isCached(result, "Internal.Dependency"))
+	require.True(isCached(result, "Dependency"))
+
+	lit := result.Modules["Internal.Dependency"].Decls[0].(*ast.Definition).Body.(*ast.FuncApp).Args[0]
+	require.Equal(`"bye"`, lit.(*ast.BasicLit).Value)
+
+	// the fixity of an operator is part of the interface of the module, so
+	// the modules that import it need to be parsed again
+	mark(internalPath)
+	edit(depPath, "infixl 2 ?", "infixl 3 ?")
+	result = parse()
+	require.False(isCached(result, "Main"))
+	require.True(isCached(result, "Internal.Dependency"))
+	require.False(isCached(result, "Dependency"))
+}
+
+func copyDir(from, to string) error {
+	return filepath.Walk(from, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(to, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, content, 0644)
+	})
+}
+
+// parseFiles writes the given files to a new temporary directory and parses
+// the package in it from the module at the given path, relative to the
+// directory. The directory must be removed by the caller.
+func parseFiles(t *testing.T, files map[string]string, path string) (string, *ast.Package, *report.Reporter) {
+	require := require.New(t)
+	dir, err := ioutil.TempDir("", "parser")
+	require.NoError(err)
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(ioutil.WriteFile(path, []byte(content), 0644))
+	}
+
+	path = filepath.Join(dir, filepath.FromSlash(path))
+	p, err := pkg.Load(filepath.Dir(path))
+	require.NoError(err)
+
+	cm := source.NewCodeMap(source.NewFsLoader(p))
+	defer cm.Close()
+
+	r := report.NewReporter(cm, report.Errors(true))
+	sess := NewSession(r, cm, NewOperatorTable(FullParse))
+	return dir, ParsePackage(sess, p, path, FullParse), r
+}
+
+func TestParsePackageConcurrent(t *testing.T) {
+	require := require.New(t)
+	wd, err := os.Getwd()
+	require.NoError(err)
+
+	parse := func(path string, workers int) (*ast.Package, *report.Reporter) {
+		p, err := pkg.Load(filepath.Dir(path))
+		require.NoError(err)
+
+		cm := source.NewCodeMap(source.NewFsLoader(p))
+		defer cm.Close()
+
+		r := report.NewReporter(cm, report.Errors(true))
+		sess := NewSession(r, cm, NewOperatorTable(FullParse))
+		fp := newFullParser(sess, p, FullParse)
+		fp.workers = workers
+		return fp.parse(path), r
+	}
+
+	assertSameResult := func(path string) {
+		expected, expectedReporter := parse(path, 1)
+		for i := 0; i < 10; i++ {
+			result, r := parse(path, 8)
+			require.Equal(expected, result)
+			require.Equal(expectedReporter.Paths(), r.Paths())
+			for _, p := range r.Paths() {
+				require.Equal(expectedReporter.Reports(p), r.Reports(p), p)
+			}
+		}
+	}
+
+	path := filepath.Join(wd, "_testdata", "valid_fullparse", "src", "Main.elm")
+	expected, _ := parse(path, 1)
+	require.NotNil(expected)
+	assertSameResult(path)
+
+	// the module imported by each module is only known after all of them
+	// are parsed, and which ones are reported must not depend on that
+	manifest := `{
+	"version": "1.0.0",
+	"repository": "https://github.com/elm-lang/core.git",
+	"source-directories": ["src"],
+	"exposed-modules": %s,
+	"dependencies": {}
+}`
+
+	dir, err := ioutil.TempDir("", "parser")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"elm-package.json":                                  fmt.Sprintf(manifest, "[]"),
+		"elm-stuff/exact-dependencies.json":                 `{"foo/a": "1.0.0"}`,
+		"elm-stuff/packages/foo/a/1.0.0/elm-package.json":   fmt.Sprintf(manifest, `["A"]`),
+		"elm-stuff/packages/foo/a/1.0.0/src/A.elm":          "module A exposing (..)\n\nimport A.Internal\n\na = A.Internal.a\n",
+		"elm-stuff/packages/foo/a/1.0.0/src/A/Internal.elm": "module A.Internal exposing (..)\n\na = 1\n",
+		"src/Main.elm":       "module Main exposing (..)\n\nimport A\nimport Local\nimport Other\n\nmain = A.a\n",
+		"src/Local.elm":      "module Local exposing (..)\n\nimport A.Internal\nimport Missing\n\nb = A.Internal.a\n",
+		"src/Other.elm":      "module Other exposing (..)\n\nimport A.Internal\nimport Missing\n\nc = A.Internal.a\n",
+		"src/A/Internal.elm": "module A.Internal exposing (..)\n\na = 2\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(ioutil.WriteFile(path, []byte(content), 0644))
+	}
+
+	path = filepath.Join(dir, "src", "Main.elm")
+	_, r := parse(path, 1)
+	require.NotEmpty(r.Paths())
+	assertSameResult(path)
+}
+
+func TestParsePackageCircularDependencies(t *testing.T) {
+	require := require.New(t)
+
+	// core does not have default imports, so no dependencies are needed
+	files := map[string]string{
+		"elm-package.json": `{
+	"version": "1.0.0",
+	"repository": "https://github.com/elm-lang/core.git",
+	"source-directories": ["src"],
+	"dependencies": {}
+}`,
+		"src/Main.elm": "module Main exposing (..)\n\nimport A\n\nmain = A.a\n",
+		"src/A.elm":    "module A exposing (..)\n\nimport B\n\na = B.b\n",
+		"src/B.elm":    "module B exposing (..)\n\nimport Main\nimport C\n\nb = C.c\n",
+		"src/C.elm":    "module C exposing (..)\n\nimport A\n\nc = 1\n",
+	}
+	dir, result, r := parseFiles(t, files, "src/Main.elm")
+	defer os.RemoveAll(dir)
+	require.Nil(result)
+
+	// all the imports of the cycles are reported where they are
+	expected := map[string][]string{
+		"Main": {"Main -> A -> B -> Main"},
+		"A":    {"Main -> A -> B -> Main", "B -> C -> A -> B"},
+		"B":    {"Main -> A -> B -> Main", "B -> C -> A -> B"},
+		"C":    {"B -> C -> A -> B"},
+	}
+
+	for mod, cycles := range expected {
+		path := filepath.Join(dir, "src", mod+".elm")
+		reports := r.Reports(path)
+		require.Len(reports, len(cycles), mod)
+		for i, cycle := range cycles {
+			err, ok := reports[i].(*report.CircularImportError)
+			require.True(ok, mod)
+			require.Equal(cycle, strings.Join(err.Cycle, " -> "), mod)
+			require.Equal(
+				token.Pos(strings.Index(files["src/"+mod+".elm"], "import "+err.Module)),
+				err.Pos(),
+				mod,
+			)
+		}
+	}
+}
+
+func TestParsePackageDependencyModules(t *testing.T) {
+	require := require.New(t)
+
+	manifest := `{
+	"version": "1.0.0",
+	"repository": "https://github.com/elm-lang/core.git",
+	"source-directories": ["src"],
+	"exposed-modules": %s,
+	"dependencies": {}
+}`
+
+	files := map[string]string{
+		"elm-package.json":                                  fmt.Sprintf(manifest, "[]"),
+		"elm-stuff/exact-dependencies.json":                 `{"foo/a": "1.0.0", "foo/b": "1.0.0"}`,
+		"elm-stuff/packages/foo/a/1.0.0/elm-package.json":   fmt.Sprintf(manifest, `["A", "Shared"]`),
+		"elm-stuff/packages/foo/a/1.0.0/src/A.elm":          "module A exposing (..)\n\nimport A.Internal\n\na = A.Internal.a\n",
+		"elm-stuff/packages/foo/a/1.0.0/src/A/Internal.elm": "module A.Internal exposing (..)\n\na = 1\n",
+		"elm-stuff/packages/foo/a/1.0.0/src/Shared.elm":     "module Shared exposing (..)\n\nx = 1\n",
+		"elm-stuff/packages/foo/b/1.0.0/elm-package.json":   fmt.Sprintf(manifest, `["Shared"]`),
+		"elm-stuff/packages/foo/b/1.0.0/src/Shared.elm":     "module Shared exposing (..)\n\nx = 1\n",
+		"src/Main.elm": "module Main exposing (..)\n\nimport A\nimport A.Internal\nimport Shared\n\nmain = A.a\n",
+	}
+	dir, result, r := parseFiles(t, files, "src/Main.elm")
+	defer os.RemoveAll(dir)
+	require.Nil(result)
+
+	path := filepath.Join(dir, "src", "Main.elm")
+
+	// the internal module can be imported from its own package
+	require.Len(r.Reports(filepath.Join(dir, "elm-stuff", "packages", "foo", "a", "1.0.0", "src", "A.elm")), 0)
+
+	reports := r.Reports(path)
+	require.Len(reports, 2)
+
+	notExposed, ok := reports[0].(*report.ModuleNotExposedError)
+	require.True(ok)
+	require.Equal("A.Internal", notExposed.Module)
+	require.Equal("foo/a", notExposed.Package)
+	require.Equal(token.Pos(strings.Index(files["src/Main.elm"], "import A.Internal")), notExposed.Pos())
+
+	ambiguous, ok := reports[1].(*report.AmbiguousModuleError)
+	require.True(ok)
+	require.Equal("Shared", ambiguous.Module)
+	require.Equal([]string{"foo/a", "foo/b"}, ambiguous.Packages)
+	require.Equal(token.Pos(strings.Index(files["src/Main.elm"], "import Shared")), ambiguous.Pos())
+}
+
+func TestParsePackageDependenciesNotInstalled(t *testing.T) {
+	require := require.New(t)
+	require.NoError(os.Setenv("ELM_HOME", filepath.Join(os.TempDir(), "tangram-no-elm-home")))
+	defer os.Unsetenv("ELM_HOME")
+
+	files := map[string]string{
+		"elm.json": `{
+	"type": "package",
+	"name": "foo/lib",
+	"summary": "a library",
+	"license": "BSD-3-Clause",
+	"version": "1.0.0",
+	"exposed-modules": ["Foo"],
+	"elm-version": "0.19.0 <= v < 0.20.0",
+	"dependencies": {"elm/json": "1.0.0 <= v < 2.0.0"},
+	"test-dependencies": {}
+}`,
+		"src/Foo.elm": "module Foo exposing (..)\n\nimport Json.Decode\nimport Json.Encode\n\nfoo = 1\n",
+	}
+
+	dir, result, r := parseFiles(t, files, "src/Foo.elm")
+	defer os.RemoveAll(dir)
+	require.Nil(result)
+
+	reports := r.Reports(filepath.Join(dir, "src", "Foo.elm"))
+	require.Len(reports, 1)
+	require.Contains(reports[0].Message(), "I could not find the dependencies of the package")
+	require.Contains(reports[0].Message(), "elm/json")
+}
+
+func TestParsePackageDependencyInternalModules(t *testing.T) {
+	require := require.New(t)
+
+	manifest := `{
+	"version": "1.0.0",
+	"repository": "https://github.com/elm-lang/core.git",
+	"source-directories": ["src"],
+	"exposed-modules": %s,
+	"dependencies": {}
+}`
+
+	files := map[string]string{
+		"elm-package.json":                                  fmt.Sprintf(manifest, "[]"),
+		"elm-stuff/exact-dependencies.json":                 `{"foo/a": "1.0.0"}`,
+		"elm-stuff/packages/foo/a/1.0.0/elm-package.json":   fmt.Sprintf(manifest, `["A"]`),
+		"elm-stuff/packages/foo/a/1.0.0/src/A.elm":          "module A exposing (..)\n\nimport A.Internal\n\na = A.Internal.a\n",
+		"elm-stuff/packages/foo/a/1.0.0/src/A/Internal.elm": "module A.Internal exposing (..)\n\na = 1\n",
+		"src/Main.elm":  "module Main exposing (..)\n\nimport A\nimport Local\n\nmain = A.a\n",
+		"src/Local.elm": "module Local exposing (..)\n\nimport A.Internal\n\nb = A.Internal.a\n",
+	}
+
+	// the internal module is found through the dependency before or after
+	// the local module imports it, depending on how the modules are
+	// scheduled, so the package is parsed several times
+	for i := 0; i < 10; i++ {
+		dir, result, r := parseFiles(t, files, "src/Main.elm")
+		defer os.RemoveAll(dir)
+		require.Nil(result)
+
+		require.Len(r.Reports(filepath.Join(dir, "elm-stuff", "packages", "foo", "a", "1.0.0", "src", "A.elm")), 0)
+
+		reports := r.Reports(filepath.Join(dir, "src", "Local.elm"))
+		require.Len(reports, 1)
+		notExposed, ok := reports[0].(*report.ModuleNotExposedError)
+		require.True(ok)
+		require.Equal("A.Internal", notExposed.Module)
+		require.Equal("foo/a", notExposed.Package)
+	}
+
+	// a local module with the same name as an internal module of a
+	// dependency is a different module
+	files["src/A/Internal.elm"] = "module A.Internal exposing (..)\n\na = 2\n"
+	for i := 0; i < 10; i++ {
+		dir, result, r := parseFiles(t, files, "src/Main.elm")
+		defer os.RemoveAll(dir)
+		require.Nil(result)
+
+		var conflicts []*report.ModuleConflictError
+		for _, path := range r.Paths() {
+			for _, rep := range r.Reports(path) {
+				conflict, ok := rep.(*report.ModuleConflictError)
+				require.True(ok, "unexpected report: %s", rep.Message())
+				conflicts = append(conflicts, conflict)
+			}
+		}
+
+		require.Len(conflicts, 1)
+		require.Equal("A.Internal", conflicts[0].Module)
+		require.Equal([]string{
+			filepath.Join(dir, "elm-stuff", "packages", "foo", "a", "1.0.0", "src", "A", "Internal.elm"),
+			filepath.Join(dir, "src", "A", "Internal.elm"),
+		}, conflicts[0].Files)
+	}
+}
+
+func TestParsePackageModuleName(t *testing.T) {
+	require := require.New(t)
+
+	files := map[string]string{
+		"elm-package.json": `{
+	"version": "1.0.0",
+	"repository": "https://github.com/elm-lang/core.git",
+	"source-directories": ["src"],
+	"dependencies": {}
+}`,
+		"src/Main.elm":    "module Main exposing (..)\n\nimport Foo.Bar\n\nmain = Foo.Bar.a\n",
+		"src/Foo/Bar.elm": "module Baz exposing (..)\n\nimport Qux\n\na = Qux.b\n",
+		"src/Qux.elm":     "module Qux exposing (..)\n\nb = 1\n",
+	}
+	dir, result, r := parseFiles(t, files, "src/Main.elm")
+	defer os.RemoveAll(dir)
+	require.Nil(result)
+
+	path := filepath.Join(dir, "src", "Main.elm")
+
+	require.Len(r.Reports(path), 0)
+	require.Len(r.Reports(filepath.Join(dir, "src", "Qux.elm")), 0)
+
+	reports := r.Reports(filepath.Join(dir, "src", "Foo", "Bar.elm"))
+	require.Len(reports, 1)
+	nameErr, ok := reports[0].(*report.ModuleNameError)
+	require.True(ok)
+	require.Equal("Baz", nameErr.Module)
+	require.Equal("Foo.Bar", nameErr.Expected)
+	require.Equal(token.Pos(len("module ")), nameErr.Pos())
+}
+
+func TestParsePackageSyntaxErrors(t *testing.T) {
+	require := require.New(t)
+
+	files := map[string]string{
+		"elm-package.json": `{
+	"version": "1.0.0",
+	"repository": "https://github.com/elm-lang/core.git",
+	"source-directories": ["src"],
+	"dependencies": {}
+}`,
+		"src/Main.elm": "module Main expos 3.ing (..)\n\nx = 1\n",
+		"src/A.elm":    "module A exposing (..)\n\nimport Main exposing ((\n\nx = 1\n",
+		"src/B.elm":    "module B exposing (\n",
+	}
+
+	for _, file := range []string{"src/Main.elm", "src/A.elm", "src/B.elm"} {
+		dir, result, r := parseFiles(t, files, file)
+		defer os.RemoveAll(dir)
+		require.Nil(result, file)
+
+		// the headers are parsed twice, but their syntax errors are only
+		// reported once
+		reports := r.Reports(filepath.Join(dir, filepath.FromSlash(file)))
+		require.NotEmpty(reports, file)
+		seen := make(map[string]bool)
+		for _, rep := range reports {
+			key := fmt.Sprintf("%d %s", rep.Pos(), rep.Message())
+			require.False(seen[key], "%s: reported twice: %s", file, key)
+			seen[key] = true
+		}
+	}
+}
+
+func TestParseHeaderSilent(t *testing.T) {
+	require := require.New(t)
+
+	loader := source.NewMemLoader()
+	loader.Add("Main.elm", "module Main exposing (..)\n\nimport Foo exposing ((\n\nx = 1\n")
+	cm := source.NewCodeMap(loader)
+	defer cm.Close()
+	require.NoError(cm.Add("Main.elm"))
+
+	r := report.NewReporter(cm, report.Errors(true))
+	sess := NewSession(r, cm, NewOperatorTable(FullParse))
+	p := newFullParser(sess, nil, FullParse)
+
+	// the errors of the header were already reported in the first pass
+	require.Nil(p.importDecl("Main.elm", "Bar"))
+	require.NotNil(p.importDecl("Main.elm", "Foo"))
+	require.True(r.IsOK())
+}
+
+func TestWorkerPoolPanic(t *testing.T) {
+	require := require.New(t)
+
+	pool := newWorkerPool(2)
+	pool.Go(func() { panic(bailout{}) })
+	require.Equal(bailout{}, catchPanic(pool.Wait))
+
+	pool = newWorkerPool(2)
+	pool.Go(func() { failingWorker() })
+	r := catchPanic(pool.Wait)
+	err, ok := r.(*workerPanic)
+	require.True(ok, "unexpected panic: %v", r)
+	require.Equal("worker failed", err.value)
+	require.Contains(err.Error(), "worker failed")
+	require.Contains(err.Error(), "failingWorker")
+}
+
+func failingWorker() {
+	panic("worker failed")
+}
+
+func catchPanic(fn func()) (r interface{}) {
+	defer func() {
+		r = recover()
+	}()
+	fn()
+	return nil
+}
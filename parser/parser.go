@@ -3,6 +3,7 @@ package parser
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
 	"unicode"
 	"unicode/utf8"
 
@@ -110,6 +111,21 @@ type parser struct {
 	// avoid errors when there might be a backup parsing or when we're skipping
 	// tokens.
 	silent bool
+	// comments are all the comments found so far.
+	comments []*ast.CommentGroup
+	// doc is the documentation comment right before the current token, if
+	// any.
+	doc *ast.CommentGroup
+	// module is the name of the module being parsed.
+	module string
+	// imports are the imports of the module being parsed, including the
+	// default imports.
+	imports []*ast.ImportDecl
+	// ops contains the info of the operators already looked up by name.
+	ops map[string]*operator.OpInfo
+	// reachedEOF reports whether an unexpected end of file has already been
+	// reported, since recovering from it ends up finding it again.
+	reachedEOF bool
 }
 
 func newParser(sess *Session) *parser {
@@ -130,6 +146,12 @@ func (p *parser) init(fileName string, s *scanner.Scanner, mode ParseMode) {
 	p.currentIndent = 1
 	p.silent = false
 	p.expectIndented = false
+	p.comments = nil
+	p.doc = nil
+	p.module = ""
+	p.imports = nil
+	p.ops = make(map[string]*operator.OpInfo)
+	p.reachedEOF = false
 
 	p.next()
 }
@@ -142,24 +164,89 @@ func parseFile(p *parser) *ast.Module {
 	}
 
 	imports = append(imports, parseImports(p)...)
+	p.module = mod.ModuleName()
+	p.imports = imports
 	if p.mode.Is(SkipDefinitions) {
 		p.skipUntilNextFixity()
 	}
 
 	var decls []ast.Decl
 	for p.tok.Type != token.EOF {
-		decls = append(decls, parseDecl(p))
+		decls = append(decls, parseTopLevelDecl(p))
 	}
 
 	return &ast.Module{
-		Path:    p.fileName,
-		Name:    mod.ModuleName(),
-		Module:  mod,
-		Imports: imports,
-		Decls:   decls,
+		Path:     p.fileName,
+		Name:     mod.ModuleName(),
+		Module:   mod,
+		Imports:  imports,
+		Decls:    decls,
+		Comments: p.comments,
 	}
 }
 
+// parseTopLevelDecl parses a declaration of the module. If there is a
+// syntax error in it, the code until the next top-level declaration, which
+// is the next token in the first column, is skipped.
+func parseTopLevelDecl(p *parser) (decl ast.Decl) {
+	start, state := p.tok, p.state()
+	defer func() {
+		if r := recover(); r != nil {
+			p.resync(r, start, state, func() bool {
+				return p.tok.Offset > start.Offset && p.tok.Column == 1
+			})
+			decl = &ast.BadDecl{StartPos: start.Offset, EndPos: p.tok.Offset}
+		}
+	}()
+
+	// the previous declaration ended before the end of its code, which is
+	// not a declaration because it is not in the first column
+	if p.tok.Column != 1 {
+		p.errorMessage(p.tok.Position, "I ran into an unexpected %q. I was expecting a new declaration at the start of a line.", p.tok.Value)
+		panic(bailout{})
+	}
+
+	return parseDecl(p)
+}
+
+// parserState is the state of the parser that is restored when it recovers
+// from a syntax error.
+type parserState struct {
+	region         *token.Position
+	indent         int
+	indentLine     int
+	expectIndented bool
+	silent         bool
+}
+
+func (p *parser) state() parserState {
+	return parserState{p.region, p.indent, p.indentLine, p.expectIndented, p.silent}
+}
+
+// resync recovers from the given panic, which must be a bailout, restoring
+// the parser to the given state and skipping tokens until stop returns true
+// or the end of file is reached. The start token, in which the code that
+// bailed out started, is not skipped if stop returns true for it, so stop
+// must return false for it if parsing from it again would not make
+// progress. Panics other than bailouts are panicked again.
+func (p *parser) resync(r interface{}, start *token.Token, state parserState, stop func() bool) {
+	if _, ok := r.(bailout); !ok {
+		panic(r)
+	}
+
+	p.region = state.region
+	p.indent = state.indent
+	p.indentLine = state.indentLine
+	p.expectIndented = state.expectIndented
+
+	// the tokens skipped can not cause more errors
+	p.silent = true
+	for !p.is(token.EOF) && !stop() {
+		p.next()
+	}
+	p.silent = state.silent
+}
+
 func (p *parser) skipUntilNextFixity() {
 	p.silent = true
 	for {
@@ -247,10 +334,24 @@ func (p *parser) next() {
 		}
 	}
 
+	prev := p.tok
 	p.tok = p.scanner.Next()
-	if p.is(token.Comment) {
-		// ignore comments for now
-		p.next()
+	var group *ast.CommentGroup
+	for p.is(token.Comment) || p.is(token.DocComment) {
+		group = p.addComment(prev, group)
+		prev = p.tok
+		p.tok = p.scanner.Next()
+	}
+
+	// the scanner stops at the first thing it can not scan, so the file is
+	// parsed as if it ended there
+	if p.is(token.Error) {
+		p.errorScanner()
+	}
+
+	p.doc = nil
+	if prev != nil && prev.Type == token.DocComment {
+		p.doc = p.commentGroup(prev.Offset)
 	}
 
 	if p.tok.Line != p.currentLine {
@@ -259,6 +360,61 @@ func (p *parser) next() {
 	}
 }
 
+// addComment adds the current comment token to the given group, or to a new
+// group if it is not right below the previous token, which is returned.
+// Comments in the same line as a token that is not a comment and
+// documentation comments always have their own group. Comments that were
+// already added before a backup are ignored.
+func (p *parser) addComment(prev *token.Token, group *ast.CommentGroup) *ast.CommentGroup {
+	if n := len(p.comments); n > 0 && p.tok.Offset < p.comments[n-1].End() {
+		return group
+	}
+
+	trailing := prev != nil && !isComment(prev) && endLine(prev) == p.tok.Line
+	isDoc := p.is(token.DocComment) || (prev != nil && prev.Type == token.DocComment)
+	if group == nil || trailing || isDoc || (prev != nil && p.tok.Line > endLine(prev)+1) {
+		group = new(ast.CommentGroup)
+		p.comments = append(p.comments, group)
+	}
+
+	group.List = append(group.List, &ast.Comment{
+		Position: p.tok.Position,
+		Text:     p.tok.Value,
+	})
+
+	if trailing {
+		return nil
+	}
+	return group
+}
+
+// commentGroup returns the group of the comment at the given offset.
+func (p *parser) commentGroup(offset token.Pos) *ast.CommentGroup {
+	for i := len(p.comments) - 1; i >= 0; i-- {
+		if p.comments[i].Pos() <= offset {
+			return p.comments[i]
+		}
+	}
+	return nil
+}
+
+// takeDoc returns the documentation comment right before the current token,
+// if any, so it is not used again for another node.
+func (p *parser) takeDoc() *ast.CommentGroup {
+	doc := p.doc
+	p.doc = nil
+	return doc
+}
+
+func isComment(t *token.Token) bool {
+	return t.Type == token.Comment || t.Type == token.DocComment
+}
+
+// endLine returns the line in which the given token ends.
+func endLine(t *token.Token) int {
+	return t.Line + strings.Count(t.Value, "\n")
+}
+
 func (p *parser) backup(until *token.Token) {
 	p.scanner.Backup(until)
 	p.next()
@@ -320,16 +476,93 @@ func (p *parser) is(typ token.Type) bool {
 	return p.tok.Type == typ
 }
 
+// opInfo returns the info of the operator with the given name that is in
+// scope in the current module. If there is more than one operator in scope
+// with that name, the ambiguity is reported and the first one is used. If
+// there is none, the operator is assumed to be left associative and to have
+// the lowest precedence.
 func (p *parser) opInfo(name string) *operator.OpInfo {
-	info := p.sess.Table.Lookup(name, "" /* TODO: path */)
-	if info != nil {
+	if info, ok := p.ops[name]; ok {
 		return info
 	}
 
+	info, modules := p.lookupOp(name)
+	if len(modules) > 1 && p.is(token.Op) {
+		// the ambiguity is reported the first time the operator is found
+		// outside a backup parsing
+		if p.silent {
+			return info
+		}
+		p.report(report.NewAmbiguousOperatorError(ast.NewIdent(name, p.tok.Position), modules))
+	}
+
+	p.ops[name] = info
+	return info
+}
+
+// lookupOp finds the operator with the given name declared in the current
+// module or exposed by any of the modules it imports. Operators declared in
+// the current module take precedence over the imported ones. The info of
+// the operator found is returned along with the modules declaring it.
+func (p *parser) lookupOp(name string) (*operator.OpInfo, []string) {
+	if info := p.sess.Table.Lookup(name, p.module); info != nil {
+		return info, []string{p.module}
+	}
+
+	var info *operator.OpInfo
+	var modules []string
+	for _, imp := range p.imports {
+		mod := imp.ModuleName()
+		if !exposes(imp.Exposing, name) || containsString(modules, mod) {
+			continue
+		}
+
+		if i := p.sess.Table.Lookup(name, mod); i != nil {
+			if info == nil {
+				info = i
+			}
+			modules = append(modules, mod)
+		}
+	}
+
+	if info != nil {
+		return info, modules
+	}
+
+	// builtin operators do not belong to any module
+	if info := p.sess.Table.Lookup(name, ""); info != nil {
+		return info, nil
+	}
+
 	return &operator.OpInfo{
 		Precedence:    0,
 		Associativity: operator.Left,
+	}, nil
+}
+
+// exposes reports whether the given list of exposed identifiers of an import
+// includes the operator with the given name.
+func exposes(list ast.ExposedList, name string) bool {
+	switch list := list.(type) {
+	case *ast.OpenList:
+		return true
+	case *ast.ClosedList:
+		for _, exposed := range list.Exposed {
+			if v, ok := exposed.(*ast.ExposedVar); ok && v.Name == name {
+				return true
+			}
+		}
 	}
+	return false
+}
+
+func containsString(list []string, str string) bool {
+	for _, s := range list {
+		if s == str {
+			return true
+		}
+	}
+	return false
 }
 
 func (p *parser) needsDefaultImports() bool {
@@ -390,10 +623,30 @@ func (p *parser) errorExpectedOneOf(t *token.Token, types ...token.Type) {
 }
 
 func (p *parser) errorUnexpectedEOF() {
-	p.report(report.NewUnexpectedEOFError(p.tok.Offset, p.currentRegion()))
+	if !p.reachedEOF {
+		p.reachedEOF = !p.silent
+		p.report(report.NewUnexpectedEOFError(p.tok.Offset, p.currentRegion()))
+	}
 	panic(bailout{})
 }
 
+// errorScanner reports the error of the current token, which is an error of
+// the scanner, and replaces it with the end of file.
+func (p *parser) errorScanner() {
+	if !p.reachedEOF {
+		p.reachedEOF = !p.silent
+		p.report(report.NewBaseReport(
+			report.SyntaxError,
+			p.tok.Offset,
+			fmt.Sprintf("I could not read the rest of the file from here: %s.", p.tok.Value),
+			nil,
+		))
+	}
+
+	t := p.tok
+	p.tok = token.New(token.EOF, t.Source, int(t.Offset), t.Column, t.Line, "")
+}
+
 func (p *parser) errorExpectedType(pos *token.Position) {
 	p.report(report.NewExpectedTypeError(pos.Offset, p.currentRegion()))
 	panic(bailout{})
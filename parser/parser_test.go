@@ -10,6 +10,7 @@ import (
 	"github.com/elm-tangram/tangram/report"
 	"github.com/elm-tangram/tangram/scanner"
 	"github.com/elm-tangram/tangram/source"
+	"github.com/elm-tangram/tangram/token"
 	"github.com/stretchr/testify/require"
 )
 
@@ -77,6 +78,34 @@ func TestParseModule(t *testing.T) {
 	}
 }
 
+func TestParsePortModule(t *testing.T) {
+	cases := []struct {
+		input   string
+		ok      bool
+		module  string
+		exposed ExposedListAssert
+	}{
+		{"port module Foo exposing (..)", true, "Foo", OpenList},
+		{"port module Foo.Bar exposing (foo)", true, "Foo.Bar", ClosedList(
+			ExposedVar("foo"),
+		)},
+		{"port Foo exposing (..)", false, "", nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			defer assertEOF(t, c.input, false)
+			p := stringParser(t, c.input)
+			defer p.sess.Emit()
+			mod := parseModule(p)
+			if c.ok {
+				PortModule(c.module, c.exposed)(t, mod)
+			}
+			require.Equal(t, c.ok, p.sess.IsOK())
+		})
+	}
+}
+
 func TestParseImport(t *testing.T) {
 	cases := []struct {
 		input   string
@@ -219,6 +248,10 @@ const inputAliasRecordArgs = `
 type alias Foo a = {x: List a}
 `
 
+const inputAliasExtendedRecord = `
+type alias Named a = { a | name : String, age : Int }
+`
+
 const inputAliasTuple = `
 type alias Point = (Int, Int)
 `
@@ -349,6 +382,18 @@ func TestParseTypeAlias(t *testing.T) {
 				),
 			),
 		},
+		{
+			inputAliasExtendedRecord,
+			Alias(
+				"Named",
+				[]string{"a"},
+				ExtendedRecord(
+					"a",
+					BasicRecordField("name", "String"),
+					BasicRecordField("age", "Int"),
+				),
+			),
+		},
 		{
 			inputAliasRecordArgs,
 			Alias(
@@ -611,6 +656,38 @@ func TestParseDefinition(t *testing.T) {
 	}
 }
 
+func TestParsePortDecl(t *testing.T) {
+	cases := []struct {
+		input  string
+		assert DeclAssert
+	}{
+		{
+			`port send : String -> Cmd msg`,
+			Port(
+				"send",
+				FuncType(
+					NamedType("String"),
+					NamedType("Cmd", VarType("msg")),
+				),
+			),
+		},
+		{
+			`port receive : (Int -> msg) -> Sub msg`,
+			Port(
+				"receive",
+				FuncType(
+					FuncType(NamedType("Int"), VarType("msg")),
+					NamedType("Sub", VarType("msg")),
+				),
+			),
+		},
+	}
+
+	for _, c := range cases {
+		mustParseDecl(t, c.input, false, true, c.assert)
+	}
+}
+
 func TestParseDestructuringAssignment(t *testing.T) {
 	cases := []struct {
 		input  string
@@ -671,7 +748,7 @@ func TestParsePattern(t *testing.T) {
 		},
 		{
 			`True`,
-			CtorPattern("True"),
+			LiteralPattern(ast.Bool, "True"),
 		},
 		{
 			`a`,
@@ -767,6 +844,28 @@ func TestParseType(t *testing.T) {
 				NamedType("List", NamedType("Int")),
 			),
 		},
+		{
+			"{ a | x : Int, y : List b }",
+			ExtendedRecord(
+				"a",
+				BasicRecordField("x", "Int"),
+				RecordField("y", NamedType("List", VarType("b"))),
+			),
+		},
+		{
+			"Named { age : Int } -> String",
+			FuncType(
+				NamedType("Named", Record(BasicRecordField("age", "Int"))),
+				NamedType("String"),
+			),
+		},
+		{
+			"Maybe { a | x : Int } -> Int",
+			FuncType(
+				NamedType("Maybe", ExtendedRecord("a", BasicRecordField("x", "Int"))),
+				NamedType("Int"),
+			),
+		},
 		// TODO(erizocosmico): improve this tests cases and relieve pressure
 		// from ParseTypeUnion and ParseTypeAlias
 	}
@@ -995,6 +1094,94 @@ func TestParseLet(t *testing.T) {
 	mustParseExpr(t, input, expected)
 }
 
+func TestParseComments(t *testing.T) {
+	require := require.New(t)
+	input := `-- first
+-- second
+
+{- third -}
+module Foo exposing (..)
+
+foo = -- trailing
+-- below trailing
+    5
+-- at the start of the line
+    + 1
+`
+
+	p := stringParser(t, input)
+	defer p.sess.Emit()
+	mod := parseFile(p)
+	require.True(p.sess.IsOK())
+
+	var groups [][]string
+	for _, g := range mod.Comments {
+		var texts []string
+		for _, c := range g.List {
+			texts = append(texts, c.Text)
+		}
+		groups = append(groups, texts)
+	}
+
+	expected := [][]string{
+		{"-- first", "-- second"},
+		{"{- third -}"},
+		{"-- trailing"},
+		{"-- below trailing"},
+		{"-- at the start of the line"},
+	}
+	require.Equal(expected, groups)
+}
+
+func TestParseDocComments(t *testing.T) {
+	require := require.New(t)
+	input := `module Foo exposing (..)
+
+{-| Module docs.
+-}
+
+import Bar
+
+-- not a doc comment
+{-| Foo docs. -}
+type Foo = Foo
+
+{-| Bar docs. -}
+type alias Bar = Int
+
+{-| baz docs -}
+baz : Int
+baz = 1
+
+{-| not a doc comment because of the comment below -}
+-- qux
+qux = 2
+
+infixl 5 <+>
+`
+
+	p := stringParser(t, input)
+	defer p.sess.Emit()
+	mod := parseFile(p)
+	require.True(p.sess.IsOK())
+
+	docText := func(doc *ast.CommentGroup) string {
+		if doc == nil {
+			return ""
+		}
+		require.Len(doc.List, 1)
+		return doc.List[0].Text
+	}
+
+	require.Equal("{-| Module docs.\n-}", docText(mod.Module.Doc))
+	require.Len(mod.Decls, 5)
+	require.Equal("{-| Foo docs. -}", docText(mod.Decls[0].(*ast.UnionDecl).Doc))
+	require.Equal("{-| Bar docs. -}", docText(mod.Decls[1].(*ast.AliasDecl).Doc))
+	require.Equal("{-| baz docs -}", docText(mod.Decls[2].(*ast.Definition).Doc))
+	require.Nil(mod.Decls[3].(*ast.Definition).Doc)
+	require.Len(mod.Comments, 7)
+}
+
 func mustParseExpr(t *testing.T, input string, assert ExprAssert) {
 	defer assertEOF(t, input, false)
 	p := stringParser(t, input)
@@ -1239,24 +1426,42 @@ func TestParseExpr(t *testing.T) {
 			`fn a 1 + fn b c + fn d e`,
 			BinaryOp(
 				"+",
-				FuncApp(
-					Identifier("fn"),
-					Identifier("a"),
-					Literal(ast.Int, "1"),
-				),
 				BinaryOp(
 					"+",
+					FuncApp(
+						Identifier("fn"),
+						Identifier("a"),
+						Literal(ast.Int, "1"),
+					),
 					FuncApp(
 						Identifier("fn"),
 						Identifier("b"),
 						Identifier("c"),
 					),
+				),
+				FuncApp(
+					Identifier("fn"),
+					Identifier("d"),
+					Identifier("e"),
+				),
+			),
+		},
+		{
+			`a |> fn b |> fn c`,
+			BinaryOp(
+				"|>",
+				BinaryOp(
+					"|>",
+					Identifier("a"),
 					FuncApp(
 						Identifier("fn"),
-						Identifier("d"),
-						Identifier("e"),
+						Identifier("b"),
 					),
 				),
+				FuncApp(
+					Identifier("fn"),
+					Identifier("c"),
+				),
 			),
 		},
 		{
@@ -1355,3 +1560,206 @@ func stringParser(t *testing.T, str string) *parser {
 	p.init("test", scanner, FullParse)
 	return p
 }
+
+func TestParseBinaryOpScope(t *testing.T) {
+	input := `module Main exposing (..)
+
+import A exposing ((?))
+import B exposing (..)
+import C exposing (foo)
+import D exposing (..)
+
+a = b ? c + d
+
+b = c ~> d + e
+
+c = d <> e
+`
+
+	p := stringParser(t, input)
+	table := p.sess.Table
+	table.Add("?", "A", operator.Left, 1)
+	table.Add("?", "C", operator.Left, 9)
+	table.Add("~>", "Main", operator.Left, 9)
+	table.Add("~>", "B", operator.Left, 1)
+	table.Add("<>", "B", operator.Left, 1)
+	table.Add("<>", "D", operator.Left, 1)
+
+	var f *ast.Module
+	func() {
+		defer assertEOF(t, input, false)
+		f = parseFile(p)
+	}()
+	require.NotNil(t, f)
+
+	// ? is only exposed by A, the one of C is not in scope
+	Definition("a", nil, nil, BinaryOp(
+		"?",
+		Identifier("b"),
+		BinaryOp("+", Identifier("c"), Identifier("d")),
+	))(t, f.Decls[0])
+
+	// ~> declared in the module takes precedence over the one exposed by B
+	Definition("b", nil, nil, BinaryOp(
+		"+",
+		BinaryOp("~>", Identifier("c"), Identifier("d")),
+		Identifier("e"),
+	))(t, f.Decls[1])
+
+	reports := p.sess.Reports("test")
+	require.Len(t, reports, 1)
+	require.Equal(t, report.NameError, reports[0].Type())
+	require.Equal(t, `The operator "<>" is ambiguous, it is exposed by modules B, D. Consider exposing it from only one of them.`, reports[0].Message())
+	require.Equal(t, token.Pos(strings.Index(input, "<>")), reports[0].Pos())
+}
+
+func TestParseErrorRecovery(t *testing.T) {
+	parse := func(t *testing.T, input string) (*ast.Module, []report.Report) {
+		p := stringParser(t, "module Main exposing (..)\n\n"+input)
+		var f *ast.Module
+		func() {
+			defer assertEOF(t, input, false)
+			f = parseFile(p)
+		}()
+		require.NotNil(t, f)
+		return f, p.sess.Reports("test")
+	}
+
+	t.Run("top-level declarations", func(t *testing.T) {
+		require := require.New(t)
+		f, reports := parse(t, `a = 1 ) 2
+
+b = 2
+
+c = = 3
+
+d = 4
+`)
+
+		require.Len(reports, 2)
+		require.Len(f.Decls, 5)
+		Definition("a", nil, nil, Literal(ast.Int, "1"))(t, f.Decls[0])
+		require.IsType(new(ast.BadDecl), f.Decls[1])
+		Definition("b", nil, nil, Literal(ast.Int, "2"))(t, f.Decls[2])
+		require.IsType(new(ast.BadDecl), f.Decls[3])
+		Definition("d", nil, nil, Literal(ast.Int, "4"))(t, f.Decls[4])
+	})
+
+	t.Run("case branches", func(t *testing.T) {
+		require := require.New(t)
+		f, reports := parse(t, `f x =
+    case x of
+        1 -> )
+        2 -> 2
+        _ -> 3
+
+g = = 1
+`)
+
+		require.Len(reports, 2)
+		require.Len(f.Decls, 2)
+		Definition("f", nil, []PatternAssert{VarPattern("x")}, CaseExpr(
+			Identifier("x"),
+			func(t *testing.T, b *ast.CaseBranch) {
+				LiteralPattern(ast.Int, "1")(t, b.Pattern)
+				require.IsType(new(ast.BadExpr), b.Expr)
+			},
+			CaseBranch(LiteralPattern(ast.Int, "2"), Literal(ast.Int, "2")),
+			CaseBranch(AnythingPattern, Literal(ast.Int, "3")),
+		))(t, f.Decls[0])
+		require.IsType(new(ast.BadDecl), f.Decls[1])
+	})
+
+	t.Run("case branch without expression", func(t *testing.T) {
+		require := require.New(t)
+		f, reports := parse(t, `f x =
+    case x of
+        1 ->
+        2 -> 3
+        _ -> 4
+
+g = 1
+`)
+
+		require.Len(reports, 1)
+		require.Equal(token.Pos(68), reports[0].Pos())
+		require.Len(f.Decls, 2)
+		Definition("f", nil, []PatternAssert{VarPattern("x")}, CaseExpr(
+			Identifier("x"),
+			func(t *testing.T, b *ast.CaseBranch) {
+				LiteralPattern(ast.Int, "1")(t, b.Pattern)
+				require.IsType(new(ast.BadExpr), b.Expr)
+			},
+			CaseBranch(LiteralPattern(ast.Int, "2"), Literal(ast.Int, "3")),
+			CaseBranch(AnythingPattern, Literal(ast.Int, "4")),
+		))(t, f.Decls[0])
+		Definition("g", nil, nil, Literal(ast.Int, "1"))(t, f.Decls[1])
+	})
+
+	t.Run("let declarations", func(t *testing.T) {
+		require := require.New(t)
+		f, reports := parse(t, `f =
+    let
+        a = = 1
+        b = 2
+    in
+        b
+
+g = 3
+`)
+
+		require.Len(reports, 1)
+		require.Len(f.Decls, 2)
+		Definition("f", nil, nil, Let(
+			Identifier("b"),
+			func(t *testing.T, d ast.Decl) {
+				require.IsType(new(ast.BadDecl), d)
+			},
+			Definition("b", nil, nil, Literal(ast.Int, "2")),
+		))(t, f.Decls[0])
+		Definition("g", nil, nil, Literal(ast.Int, "3"))(t, f.Decls[1])
+	})
+
+	t.Run("unexpected token after an expression", func(t *testing.T) {
+		require := require.New(t)
+		f, reports := parse(t, "x = a =\n")
+
+		require.Len(reports, 1)
+		require.Len(f.Decls, 1)
+		require.IsType(new(ast.BadDecl), f.Decls[0])
+	})
+
+	t.Run("unexpected EOF", func(t *testing.T) {
+		require := require.New(t)
+		f, reports := parse(t, "a = 1\n\nb = (1,")
+
+		require.Len(reports, 1)
+		require.Len(f.Decls, 2)
+		require.IsType(new(ast.BadDecl), f.Decls[1])
+	})
+
+	t.Run("token that can not start an argument", func(t *testing.T) {
+		require := require.New(t)
+		f, reports := parse(t, "x : Flo-}at\nx = 1\n\ny = 2\n")
+
+		require.NotEmpty(reports)
+		require.Len(f.Decls, 3)
+		require.IsType(new(ast.BadDecl), f.Decls[0])
+		Definition("x", nil, nil, Literal(ast.Int, "1"))(t, f.Decls[1])
+		Definition("y", nil, nil, Literal(ast.Int, "2"))(t, f.Decls[2])
+	})
+
+	t.Run("scanner error", func(t *testing.T) {
+		require := require.New(t)
+		f, reports := parse(t, "a = 1\n\nb = 3.ing\n\nc = 2\n")
+
+		require.Len(reports, 1)
+		require.Equal(token.Pos(strings.Index(
+			"module Main exposing (..)\n\na = 1\n\nb = 3.ing",
+			".ing",
+		)), reports[0].Pos())
+		require.Len(f.Decls, 2)
+		Definition("a", nil, nil, Literal(ast.Int, "1"))(t, f.Decls[0])
+		require.IsType(new(ast.BadDecl), f.Decls[1])
+	})
+}
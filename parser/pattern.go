@@ -20,6 +20,12 @@ func parseFuncArgs(p *parser, end token.Type) []ast.Pattern {
 		// in arguments, we parse the patterns as non-greedy so it forces the
 		// developer to wrap around parenthesis the alias pattern
 		pattern := parsePattern(p, false)
+		if p.tok == tok {
+			// the token can not start a pattern, which has been reported,
+			// and it would be found again and again
+			panic(bailout{})
+		}
+
 		arg, ok := pattern.(ast.ArgPattern)
 		if !ok {
 			p.errorMessage(
@@ -57,11 +63,8 @@ func parsePattern(p *parser, greedy bool) (pat ast.Pattern) {
 		pat = parseTupleOrParenthesizedPattern(p)
 	case token.LeftBrace:
 		pat = parseRecordPattern(p)
-	case token.Int, token.Char, token.String, token.Float:
+	case token.Int, token.Char, token.String, token.Float, token.True, token.False:
 		pat = &ast.LiteralPattern{parseLiteral(p)}
-	case token.True, token.False:
-		p.expectOneOf(token.True, token.False)
-		pat = &ast.CtorPattern{Ctor: ast.NewIdent(p.tok.Value, p.tok.Position)}
 	default:
 		p.errorExpectedOneOf(p.tok, token.Identifier, token.LeftParen, token.LeftBrace, token.LeftBracket)
 	}
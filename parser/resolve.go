@@ -4,14 +4,21 @@ import (
 	"strings"
 
 	"github.com/elm-tangram/tangram/ast"
+	"github.com/elm-tangram/tangram/native"
 	"github.com/elm-tangram/tangram/report"
 )
 
 type resolver struct {
 	pkg      *ast.Package
 	reporter *report.Reporter
+	// natives contains all the native modules already loaded by path.
+	natives map[string]*native.Module
 
 	path string
+	mod  *ast.Module
+	// typeVars contains the type variables of the declaration whose types
+	// are being resolved, if any.
+	typeVars *ast.NodeScope
 }
 
 func (r *resolver) resolve(pkg *ast.Package) bool {
@@ -19,6 +26,7 @@ func (r *resolver) resolve(pkg *ast.Package) bool {
 	var resolved = true
 	for _, m := range pkg.Order {
 		r.path = pkg.Modules[m].Path
+		r.mod = pkg.Modules[m]
 		resolved = r.resolveModule(pkg.Modules[m]) && resolved
 	}
 	return resolved
@@ -32,6 +40,9 @@ func (r *resolver) resolveModule(mod *ast.Module) bool {
 	}
 
 	for _, decl := range mod.Decls {
+		if port, ok := decl.(*ast.PortDecl); ok && !mod.Module.IsPort {
+			r.report(report.NewPortError(mod.Module, port))
+		}
 		r.resolveDecl(mod.Scope, decl)
 	}
 
@@ -48,6 +59,9 @@ func (r *resolver) resolveImport(scope *ast.ModuleScope, imp *ast.ImportDecl) {
 		kind = ast.NativeMod
 	}
 	obj := ast.NewObject(mod, kind, imp)
+	if isNative {
+		obj.Data = r.loadNative(imp)
+	}
 	scope.ImportModule(obj)
 
 	if imp.Alias != nil {
@@ -79,7 +93,7 @@ func (r *resolver) resolveImport(scope *ast.ModuleScope, imp *ast.ImportDecl) {
 						r.report(report.NewImportError(imp, imp.ModuleName(), id.Ident))
 					}
 				} else {
-					r.report(report.NewImportError(imp, imp.ModuleName(), id.Ident))
+					r.reportImportError(imp, imp.ModuleName(), id.Ident, objectNames(importScope.Exposed))
 				}
 			case *ast.ExposedUnion:
 				if obj := importScope.LookupExposed(id.Type.Name, ast.Typ); obj != nil {
@@ -102,7 +116,7 @@ func (r *resolver) resolveImport(scope *ast.ModuleScope, imp *ast.ImportDecl) {
 										r.report(report.NewExpectedCtorError(imp, obj))
 									}
 								} else {
-									r.report(report.NewImportError(imp, imp.ModuleName(), id.Ident))
+									r.reportImportError(imp, imp.ModuleName(), id.Ident, exposedCtors(importScope, union))
 								}
 							default:
 								// unreachable
@@ -117,7 +131,7 @@ func (r *resolver) resolveImport(scope *ast.ModuleScope, imp *ast.ImportDecl) {
 						}
 					}
 				} else {
-					r.report(report.NewImportError(imp, imp.ModuleName(), id.Type))
+					r.reportImportError(imp, imp.ModuleName(), id.Type, objectNames(importScope.Exposed))
 				}
 			}
 		}
@@ -131,9 +145,6 @@ func (r *resolver) resolveImport(scope *ast.ModuleScope, imp *ast.ImportDecl) {
 	}
 }
 
-// TODO: add again VarTyp resolution to decls, a lookup is enough
-// because they must be previously declared
-// TODO: check when adding a new type to the top-level that is not already declared.
 func (r *resolver) resolveDecl(scope ast.Scope, decl ast.Decl) {
 	switch decl := decl.(type) {
 	case *ast.DestructuringAssignment:
@@ -143,47 +154,94 @@ func (r *resolver) resolveDecl(scope ast.Scope, decl ast.Decl) {
 		r.resolveExpr(scope, decl.Op)
 	case *ast.Definition:
 		if decl.Annotation != nil {
-			r.resolveType(scope, decl.Annotation.Type)
+			r.withTypeVars(decl.Annotation, nil, func() {
+				r.resolveType(scope, decl.Annotation.Type)
+			})
 		}
-		scope.Add(ast.NewObject(decl.Name.Name, ast.Var, decl.Name))
+		r.declare(scope, decl, decl.Name, ast.NewObject(decl.Name.Name, ast.Var, decl.Name))
 
 		defScope := ast.NewNodeScope(decl, scope)
 		for _, arg := range decl.Args {
 			r.resolvePattern(defScope, arg)
 		}
 		r.resolveExpr(defScope, decl.Body)
+	case *ast.PortDecl:
+		r.withTypeVars(decl, nil, func() {
+			r.resolveType(scope, decl.Type)
+		})
+		r.declare(scope, decl, decl.Name, ast.NewObject(decl.Name.Name, ast.Var, decl.Name))
 	case *ast.AliasDecl:
-		scope.Add(ast.NewObject(decl.Name.Name, ast.Typ, decl))
-		set := make(map[string]struct{})
+		r.declare(scope, decl, decl.Name, ast.NewObject(decl.Name.Name, ast.Typ, decl))
+		set := make(map[string]*ast.Ident)
 		for _, arg := range decl.Args {
-			if _, ok := set[arg.Name]; ok {
-				r.report(report.NewRepeatedVarTypeError(decl, arg))
+			if first, ok := set[arg.Name]; ok {
+				r.report(report.NewRepeatedVarTypeError(decl, arg, first))
 				return
 			}
-			set[arg.Name] = struct{}{}
+			set[arg.Name] = arg
 		}
-		r.resolveType(scope, decl.Type)
+
+		r.withTypeVars(decl, decl.Args, func() {
+			r.resolveType(scope, decl.Type)
+		})
 	case *ast.UnionDecl:
-		scope.Add(ast.NewObject(decl.Name.Name, ast.Typ, decl))
-		set := make(map[string]struct{})
+		r.declare(scope, decl, decl.Name, ast.NewObject(decl.Name.Name, ast.Typ, decl))
+		set := make(map[string]*ast.Ident)
 		for _, arg := range decl.Args {
-			if _, ok := set[arg.Name]; ok {
-				r.report(report.NewRepeatedVarTypeError(decl, arg))
+			if first, ok := set[arg.Name]; ok {
+				r.report(report.NewRepeatedVarTypeError(decl, arg, first))
 				return
 			}
-			set[arg.Name] = struct{}{}
+			set[arg.Name] = arg
 		}
 
-		set = make(map[string]struct{})
-		for _, ctor := range decl.Ctors {
-			if _, ok := set[ctor.Name.Name]; ok {
-				r.report(report.NewRepeatedCtorError(decl, ctor.Name))
-				return
+		set = make(map[string]*ast.Ident)
+		r.withTypeVars(decl, decl.Args, func() {
+			for _, ctor := range decl.Ctors {
+				if first, ok := set[ctor.Name.Name]; ok {
+					r.report(report.NewRepeatedCtorError(decl, ctor.Name, first))
+					return
+				}
+				set[ctor.Name.Name] = ctor.Name
+				r.resolveCtor(scope, ctor)
 			}
-			set[ctor.Name.Name] = struct{}{}
-			r.resolveCtor(scope, ctor)
+		})
+	}
+}
+
+// withTypeVars calls fn to resolve the types of the given declaration, in
+// which the given arguments are declared as type variables. The type
+// variables not declared by the arguments are declared the first time they
+// are found.
+func (r *resolver) withTypeVars(decl ast.Node, args []*ast.Ident, fn func()) {
+	typeVars := r.typeVars
+	defer func() {
+		r.typeVars = typeVars
+	}()
+
+	r.typeVars = ast.NewNodeScope(decl, nil)
+	for _, arg := range args {
+		obj := ast.NewObject(arg.Name, ast.VarTyp, arg)
+		if r.typeVars.Add(obj) {
+			arg.Obj = obj
 		}
 	}
+	fn()
+}
+
+// resolveTypeVar binds the given type variable to the type variable of the
+// declaration being resolved with the same name.
+func (r *resolver) resolveTypeVar(v *ast.VarType) {
+	if r.typeVars == nil {
+		return
+	}
+
+	obj := r.typeVars.Object(v.Name)
+	if obj == nil {
+		obj = ast.NewObject(v.Name, ast.VarTyp, v)
+		r.typeVars.Add(obj)
+	}
+	v.Obj = obj
 }
 
 // TODO(erizocosmico): please, split this into smaller functions
@@ -263,6 +321,29 @@ func (r *resolver) tryExposeCtor(scope *ast.ModuleScope, ident *ast.Ident) *ast.
 	return nil
 }
 
+// declare adds the object of the given declaration to the scope, reporting
+// an error if an object of the same kind with the same name was already
+// declared in it.
+func (r *resolver) declare(scope ast.Scope, decl ast.Decl, name *ast.Ident, obj *ast.Object) {
+	if scope.Add(obj) {
+		return
+	}
+
+	original := scope.Object(obj.Name)
+	if original == nil || original.Kind != obj.Kind {
+		return
+	}
+
+	node := original.Node
+	switch n := node.(type) {
+	case *ast.AliasDecl:
+		node = n.Name
+	case *ast.UnionDecl:
+		node = n.Name
+	}
+	r.report(report.NewAlreadyDeclaredError(decl, name, node))
+}
+
 func (r *resolver) resolveCtor(scope ast.Scope, ctor *ast.Constructor) {
 	// TODO: check is not already defined in scope
 	scope.Add(ast.NewObject(ctor.Name.Name, ast.Ctor, ctor))
@@ -308,24 +389,24 @@ func (r *resolver) resolveExpr(scope ast.Scope, expr ast.Expr) {
 			r.resolveExpr(scope, arg)
 		}
 	case *ast.RecordLit:
-		var set = make(map[string]struct{})
+		var set = make(map[string]*ast.Ident)
 		for _, f := range expr.Fields {
-			if _, ok := set[f.Field.Name]; ok {
-				r.report(report.NewRepeatedFieldError(expr, f.Field))
+			if first, ok := set[f.Field.Name]; ok {
+				r.report(report.NewRepeatedFieldError(expr, f.Field, first))
 				return
 			}
-			set[f.Field.Name] = struct{}{}
+			set[f.Field.Name] = f.Field
 			r.resolveExpr(scope, f.Expr)
 		}
 	case *ast.RecordUpdate:
 		r.resolveExpr(scope, expr.Record)
-		var set = make(map[string]struct{})
+		var set = make(map[string]*ast.Ident)
 		for _, f := range expr.Fields {
-			if _, ok := set[f.Field.Name]; ok {
-				r.report(report.NewRepeatedFieldError(expr, f.Field))
+			if first, ok := set[f.Field.Name]; ok {
+				r.report(report.NewRepeatedFieldError(expr, f.Field, first))
 				return
 			}
-			set[f.Field.Name] = struct{}{}
+			set[f.Field.Name] = f.Field
 			r.resolveExpr(scope, f.Expr)
 		}
 	case *ast.UnaryOp:
@@ -351,7 +432,7 @@ func (r *resolver) resolveExpr(scope ast.Scope, expr ast.Expr) {
 func (r *resolver) resolvePattern(scope ast.Scope, pattern ast.Pattern) {
 	switch pattern := pattern.(type) {
 	case *ast.AliasPattern:
-		scope.Add(ast.NewObject(pattern.Name.Name, ast.Var, pattern.Pattern))
+		scope.Add(ast.NewObject(pattern.Name.Name, ast.Var, pattern))
 		r.resolvePattern(scope, pattern.Pattern)
 	case *ast.CtorPattern:
 		r.resolveQualifiedName(scope, pattern.Ctor, ast.Var)
@@ -385,20 +466,24 @@ func (r *resolver) resolveType(scope ast.Scope, typ ast.Type) {
 			r.resolveType(scope, arg)
 		}
 	case *ast.VarType:
-		// don't do anything, they will be resolved during type check
+		r.resolveTypeVar(typ)
 	case *ast.FuncType:
 		for _, arg := range typ.Args {
 			r.resolveType(scope, arg)
 		}
 		r.resolveType(scope, typ.Return)
 	case *ast.RecordType:
-		var idents = make(map[string]struct{})
+		if typ.Extended != nil {
+			r.resolveTypeVar(typ.Extended)
+		}
+
+		var idents = make(map[string]*ast.Ident)
 		for _, f := range typ.Fields {
-			if _, ok := idents[f.Name.Name]; ok {
-				r.report(report.NewRepeatedFieldError(typ, f.Name))
+			if first, ok := idents[f.Name.Name]; ok {
+				r.report(report.NewRepeatedFieldError(typ, f.Name, first))
 				return
 			}
-			idents[f.Name.Name] = struct{}{}
+			idents[f.Name.Name] = f.Name
 			r.resolveType(scope, f.Type)
 		}
 	case *ast.TupleType:
@@ -445,7 +530,10 @@ func (r *resolver) resolveQualifiedName(scope ast.Scope, expr ast.Expr, kind ast
 		kind = ast.Ctor
 	}
 
-	var modName string
+	var (
+		modName string
+		exposed []string
+	)
 	if len(path) > 0 {
 		var parts = make([]string, len(path))
 		for i, id := range path {
@@ -459,12 +547,17 @@ func (r *resolver) resolveQualifiedName(scope ast.Scope, expr ast.Expr, kind ast
 			}
 
 			if obj.Kind == ast.NativeMod {
+				r.resolveNative(expr, obj, varIdent)
 				return
 			}
 
-			scope = obj.Node.(*ast.Module).Scope
+			modScope := obj.Node.(*ast.Module).Scope
+			exposed = objectNames(modScope.Exposed)
+			scope = modScope
 		} else {
-			r.report(report.NewModuleNotImportedError(expr, modName))
+			err := report.NewModuleNotImportedError(expr, modName)
+			err.AddSuggestions(suggestions(modName, moduleNames(scope)))
+			r.report(err)
 			return
 		}
 	}
@@ -473,18 +566,74 @@ func (r *resolver) resolveQualifiedName(scope ast.Scope, expr ast.Expr, kind ast
 		varIdent.Obj = obj
 	} else {
 		if len(path) > 0 {
-			r.report(report.NewImportError(expr, modName, varIdent))
+			r.reportImportError(expr, modName, varIdent, exposed)
 		} else {
 			scope.Resolve(varIdent.Name, varIdent, kind)
 		}
 	}
 }
 
+// loadNative loads the native module imported by the given import. If the
+// module cannot be loaded, it is reported and nil is returned.
+func (r *resolver) loadNative(imp *ast.ImportDecl) *native.Module {
+	name := imp.ModuleName()
+	path := native.FindPath(name, r.mod.NativeImports)
+	if path == "" {
+		r.report(report.NewNativeModuleError(imp, name, "I could not find its Go source file."))
+		return nil
+	}
+
+	if mod, ok := r.natives[path]; ok {
+		return mod
+	}
+
+	mod, err := native.Load(name, path)
+	if err != nil {
+		r.report(report.NewNativeModuleError(imp, name, err.Error()))
+		return nil
+	}
+
+	if r.natives == nil {
+		r.natives = make(map[string]*native.Module)
+	}
+	r.natives[path] = mod
+	return mod
+}
+
+// resolveNative resolves the given identifier as a function of the given
+// native module.
+func (r *resolver) resolveNative(expr ast.Expr, obj *ast.Object, ident *ast.Ident) {
+	mod, ok := obj.Data.(*native.Module)
+	if !ok || mod == nil {
+		// the module could not be loaded, which has already been reported
+		return
+	}
+
+	if fn := mod.Lookup(ident.Name); fn != nil {
+		ident.Obj = fn
+	} else {
+		var funcs []string
+		for name := range mod.Funcs {
+			funcs = append(funcs, name)
+		}
+		r.reportImportError(expr, mod.Name, ident, funcs)
+	}
+}
+
+// reportImportError reports that the given name could not be found in the
+// given module, suggesting the most similar of the given names of the
+// module.
+func (r *resolver) reportImportError(node ast.Node, module string, name *ast.Ident, candidates []string) {
+	err := report.NewImportError(node, module, name)
+	err.AddSuggestions(suggestions(name.Name, candidates))
+	r.report(err)
+}
+
 func (r *resolver) checkUnresolved(scope *ast.ModuleScope) bool {
 	var resolved = true
 	r.resolveBasicTypes(scope.Unresolved)
 	if len(scope.Unresolved) > 0 {
-		r.reportUnresolved(scope.Unresolved)
+		r.reportUnresolved(scope, scope.Unresolved)
 		resolved = false
 	}
 
@@ -505,7 +654,7 @@ func (r *resolver) checkUnresolvedChildren(scopes []*ast.NodeScope) bool {
 	for _, scope := range scopes {
 		r.resolveBasicTypes(scope.Unresolved)
 		if len(scope.Unresolved) > 0 {
-			r.reportUnresolved(scope.Unresolved)
+			r.reportUnresolved(scope, scope.Unresolved)
 			resolved = false
 		}
 	}
@@ -524,10 +673,14 @@ func (r *resolver) resolveBasicTypes(unresolved map[string][]*ast.Ident) {
 	}
 }
 
-func (r *resolver) reportUnresolved(unresolved map[string][]*ast.Ident) {
+func (r *resolver) reportUnresolved(scope ast.Scope, unresolved map[string][]*ast.Ident) {
+	candidates := append(scopeNames(scope), objectNames(basicTypes)...)
 	for name, idents := range unresolved {
+		similar := suggestions(name, candidates)
 		for _, ident := range idents {
-			r.report(report.NewUnresolvedNameError(name, ident))
+			err := report.NewUnresolvedNameError(name, ident)
+			err.AddSuggestions(similar)
+			r.report(err)
 		}
 	}
 }
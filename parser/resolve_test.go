@@ -23,7 +23,7 @@ func TestResolvePattern(t *testing.T) {
 	t.Run("AliasPattern", func(t *testing.T) {
 		require := require.New(t)
 		scope := newScope()
-		r.resolvePattern(scope, &ast.AliasPattern{
+		pattern := &ast.AliasPattern{
 			Name: ast.NewIdent("foo", nil),
 			Pattern: &ast.LiteralPattern{
 				&ast.BasicLit{
@@ -31,11 +31,13 @@ func TestResolvePattern(t *testing.T) {
 					Value: "1",
 				},
 			},
-		})
+		}
+		r.resolvePattern(scope, pattern)
 
 		require.Len(scope.Objects, 1)
 		require.Len(scope.Unresolved, 0)
 		require.NotNil(scope.Objects["foo"])
+		require.Equal(pattern, scope.Objects["foo"].Node)
 		require.True(r.reporter.IsOK())
 	})
 
@@ -236,6 +238,32 @@ func TestResolveType(t *testing.T) {
 		require.True(r.reporter.IsOK())
 	})
 
+	t.Run("RecordType extended", func(t *testing.T) {
+		require := require.New(t)
+		scope := newScope()
+
+		node := &ast.RecordType{
+			Extended: &ast.VarType{ast.NewIdent("a", nil)},
+			Fields: []*ast.RecordField{
+				{
+					Name: ast.NewIdent("x", nil),
+					Type: &ast.NamedType{Name: ast.NewIdent("Int", nil)},
+				},
+			},
+		}
+		r.withTypeVars(node, nil, func() {
+			r.resolveType(scope, node)
+		})
+
+		require.Len(scope.Objects, 0)
+		require.Len(scope.Unresolved, 0)
+		require.NotNil(node.Extended.Obj)
+		require.Equal(ast.VarTyp, node.Extended.Obj.Kind)
+		require.Equal(node.Extended, node.Extended.Obj.Node)
+		assertObj(t, node.Fields[0].Type.(*ast.NamedType).Name, "Int")
+		require.True(r.reporter.IsOK())
+	})
+
 	t.Run("RecordType repeated fields", func(t *testing.T) {
 		require := require.New(t)
 		scope := newScope()
@@ -355,6 +383,38 @@ func TestResolveDecl(t *testing.T) {
 		require.True(r.reporter.IsOK())
 	})
 
+	t.Run("PortDecl", func(t *testing.T) {
+		require := require.New(t)
+		scope := newScope()
+		node := &ast.PortDecl{
+			Name: ast.NewIdent("send", nil),
+			Type: &ast.FuncType{
+				Args: []ast.Type{
+					&ast.NamedType{Name: ast.NewIdent("String", nil)},
+				},
+				Return: &ast.NamedType{
+					Name: ast.NewIdent("Result", nil),
+					Args: []ast.Type{
+						&ast.VarType{ast.NewIdent("a", nil)},
+						&ast.NamedType{Name: ast.NewIdent("Int", nil)},
+					},
+				},
+			},
+		}
+		r.resolveDecl(scope, node)
+
+		require.Len(scope.Objects, 1)
+		require.Len(scope.Unresolved, 0)
+		require.NotNil(scope.Objects["send"])
+		require.Equal(ast.Var, scope.Objects["send"].Kind)
+		assertObj(t, node.Type.(*ast.FuncType).Args[0].(*ast.NamedType).Name, "String")
+		assertObj(t, node.Type.(*ast.FuncType).Return.(*ast.NamedType).Name, "Result")
+		v := node.Type.(*ast.FuncType).Return.(*ast.NamedType).Args[0].(*ast.VarType)
+		require.Equal(ast.VarTyp, v.Obj.Kind)
+		require.Equal(v, v.Obj.Node)
+		require.True(r.reporter.IsOK())
+	})
+
 	t.Run("AliasDecl", func(t *testing.T) {
 		require := require.New(t)
 		scope := newScope()
@@ -378,6 +438,36 @@ func TestResolveDecl(t *testing.T) {
 		require.Len(scope.Unresolved, 0)
 		require.NotNil(scope.Objects["FancyResult"])
 		assertObj(t, node.Type.(*ast.NamedType).Name, "Result")
+		for i, arg := range node.Type.(*ast.NamedType).Args {
+			require.Equal(ast.VarTyp, arg.(*ast.VarType).Obj.Kind)
+			require.Equal(node.Args[i], arg.(*ast.VarType).Obj.Node)
+		}
+		require.True(r.reporter.IsOK())
+	})
+
+	t.Run("AliasDecl extended record", func(t *testing.T) {
+		require := require.New(t)
+		scope := newScope()
+		node := &ast.AliasDecl{
+			Name: ast.NewIdent("Named", nil),
+			Args: []*ast.Ident{ast.NewIdent("a", nil)},
+			Type: &ast.RecordType{
+				Extended: &ast.VarType{ast.NewIdent("a", nil)},
+				Fields: []*ast.RecordField{
+					{
+						Name: ast.NewIdent("name", nil),
+						Type: &ast.NamedType{Name: ast.NewIdent("String", nil)},
+					},
+				},
+			},
+		}
+		r.resolveDecl(scope, node)
+
+		extended := node.Type.(*ast.RecordType).Extended
+		require.NotNil(extended.Obj)
+		require.Equal(ast.VarTyp, extended.Obj.Kind)
+		require.Equal(node.Args[0], extended.Obj.Node)
+		require.Equal(extended.Obj, node.Args[0].Obj)
 		require.True(r.reporter.IsOK())
 	})
 
@@ -476,9 +566,108 @@ func TestResolveDecl(t *testing.T) {
 
 		require.False(r.reporter.IsOK())
 		assertReports(t, r.reporter, new(report.RepeatedCtorError))
+
+		rep := r.reporter.Reports("test")[0]
+		require.Equal(report.Code("N010"), rep.Code())
+		require.Len(rep.Labels(), 1)
+		require.Equal(report.RegionFromNode(node.Ctors[0].Name), rep.Labels()[0].Region)
+	})
+
+	t.Run("Definition already declared", func(t *testing.T) {
+		r := newTestResolver(t)
+		require := require.New(t)
+		scope := newScope()
+		pos := new(token.Position)
+		first := &ast.Definition{
+			Name: ast.NewIdent("foo", pos),
+			Body: &ast.BasicLit{Position: pos, Type: ast.Int, Value: "1"},
+		}
+		second := &ast.Definition{
+			Name: ast.NewIdent("foo", pos),
+			Body: &ast.BasicLit{Position: pos, Type: ast.Int, Value: "2"},
+		}
+		r.resolveDecl(scope, first)
+		r.resolveDecl(scope, second)
+
+		require.False(r.reporter.IsOK())
+		assertReports(t, r.reporter, new(report.AlreadyDeclaredError))
+
+		rep := r.reporter.Reports("test")[0]
+		require.Equal(report.Code("N008"), rep.Code())
+		require.Len(rep.Labels(), 1)
+		require.Equal(report.RegionFromNode(first.Name), rep.Labels()[0].Region)
+	})
+
+	t.Run("UnionDecl already declared", func(t *testing.T) {
+		r := newTestResolver(t)
+		require := require.New(t)
+		scope := newScope()
+		pos := new(token.Position)
+		alias := &ast.AliasDecl{
+			Name: ast.NewIdent("Cmp", pos),
+			Type: &ast.NamedType{Name: ast.NewIdent("Int", pos)},
+		}
+		union := &ast.UnionDecl{
+			Name: ast.NewIdent("Cmp", pos),
+			Ctors: []*ast.Constructor{
+				&ast.Constructor{Name: ast.NewIdent("Gt", pos)},
+			},
+		}
+		r.resolveDecl(scope, alias)
+		r.resolveDecl(scope, union)
+
+		require.False(r.reporter.IsOK())
+		assertReports(t, r.reporter, new(report.AlreadyDeclaredError))
+		require.Equal(
+			report.RegionFromNode(alias.Name),
+			r.reporter.Reports("test")[0].Labels()[0].Region,
+		)
 	})
 }
 
+func TestResolvePorts(t *testing.T) {
+	cases := []struct {
+		name    string
+		port    bool
+		reports []report.Report
+	}{
+		{"port module", true, nil},
+		{"not a port module", false, []report.Report{new(report.PortError)}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require := require.New(t)
+			r := newTestResolver(t)
+			pos := new(token.Position)
+			port := &ast.PortDecl{
+				Name: ast.NewIdent("send", pos),
+				Type: &ast.FuncType{
+					Args:   []ast.Type{&ast.NamedType{Name: ast.NewIdent("String", pos)}},
+					Return: &ast.VarType{ast.NewIdent("a", pos)},
+				},
+			}
+			mod := &ast.Module{
+				Name: "Foo",
+				Module: &ast.ModuleDecl{
+					Name:     ast.NewIdent("Foo", pos),
+					IsPort:   c.port,
+					Exposing: new(ast.OpenList),
+				},
+				Decls: []ast.Decl{port},
+			}
+			r.resolveModule(mod)
+
+			require.NotNil(mod.Scope.Exposed["send"], "expected port to be exposed")
+			if len(c.reports) > 0 {
+				assertReports(t, r.reporter, c.reports...)
+			} else {
+				require.True(r.reporter.IsOK())
+			}
+		})
+	}
+}
+
 func TestResolveQualifiedName(t *testing.T) {
 	parent := ast.NewModuleScope(nil)
 	fooBarBazMod := &ast.Module{
@@ -578,7 +767,62 @@ func TestResolveQualifiedName(t *testing.T) {
 
 		assertReports(t, r.reporter, new(report.ImportError))
 		require.False(t, r.reporter.IsOK())
+		require.Len(t, r.reporter.Reports("test")[0].Hints(), 0)
+	})
+
+	t.Run("Import error suggestions", func(t *testing.T) {
+		r := newTestResolver(t)
+		fooBarMod.Scope.Expose(fooBarMod.Scope.Objects["qux"])
+		defer delete(fooBarMod.Scope.Exposed, "qux")
+
+		node := ast.NewSelectorExpr(append(fooBarPath, ast.NewIdent("fux", pos))...)
+		r.resolveQualifiedName(scope, node, ast.Var)
+
+		assertReports(t, r.reporter, new(report.ImportError))
+		require.Equal(t, []string{`Did you mean "qux"?`}, r.reporter.Reports("test")[0].Hints())
 	})
+
+	t.Run("Module not imported suggestions", func(t *testing.T) {
+		r := newTestResolver(t)
+		node := ast.NewSelectorExpr(
+			ast.NewIdent("Foo", pos),
+			ast.NewIdent("Baz", pos),
+			ast.NewIdent("bar", pos),
+		)
+		r.resolveQualifiedName(scope, node, ast.Var)
+
+		assertReports(t, r.reporter, new(report.ModuleNotImportedError))
+		require.Equal(t, []string{`Did you mean "Foo.Bar"?`}, r.reporter.Reports("test")[0].Hints())
+	})
+}
+
+func TestResolveUnresolvedSuggestions(t *testing.T) {
+	require := require.New(t)
+	r := newTestResolver(t)
+	scope := modScopeWithObjects(
+		ast.NewObject("length", ast.Var, nil),
+		ast.NewObject("lengths", ast.Var, nil),
+		ast.NewObject("Length", ast.Typ, nil),
+		ast.NewObject("width", ast.Var, nil),
+	)
+	scope.Import(ast.NewObject("lengthy", ast.Var, nil))
+
+	pos := new(token.Position)
+	scope.Resolve("lenght", ast.NewIdent("lenght", pos), ast.Var)
+	scope.Resolve("Strin", ast.NewIdent("Strin", pos), ast.Typ)
+	require.False(r.checkUnresolved(scope))
+
+	hints := make(map[string][]string)
+	for _, rep := range r.reporter.Reports("test") {
+		err, ok := rep.(*report.UnresolvedNameError)
+		require.True(ok, "expected unresolved name error, got %T", rep)
+		hints[err.Name] = err.Hints()
+	}
+
+	require.Equal(map[string][]string{
+		"lenght": {`Did you mean one of these? "length", "lengths", "lengthy"`},
+		"Strin":  {`Did you mean "String"?`},
+	}, hints)
 }
 
 func TestResolveExpr(t *testing.T) {
@@ -1422,5 +1666,5 @@ func newTestResolver(t *testing.T) *resolver {
 	cm := source.NewCodeMap(loader)
 	require.NoError(t, cm.Add(path), "adding %s", path)
 	reporter := report.NewReporter(cm, report.Stderr(true, true))
-	return &resolver{nil, reporter, path}
+	return &resolver{reporter: reporter, path: path}
 }
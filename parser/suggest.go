@@ -0,0 +1,168 @@
+package parser
+
+import (
+	"sort"
+	"unicode/utf8"
+
+	"github.com/elm-tangram/tangram/ast"
+)
+
+// maxSuggestions is the maximum number of names suggested for a name that
+// could not be found.
+const maxSuggestions = 4
+
+// suggestions returns the candidates that are close enough to the given name
+// to be suggested instead of it, from the closest to the farthest. Only
+// candidates whose first letter has the same case as the name are considered,
+// since variables can not be mistaken for types or constructors.
+func suggestions(name string, candidates []string) []string {
+	type suggestion struct {
+		name     string
+		distance int
+	}
+
+	// the longer the name, the more typos it may have
+	var (
+		max   = maxDistance(name)
+		seen  = make(map[string]struct{})
+		found []suggestion
+	)
+	for _, c := range candidates {
+		if _, ok := seen[c]; ok || c == name || isUpper(c) != isUpper(name) {
+			continue
+		}
+		seen[c] = struct{}{}
+
+		if d := editDistance(name, c); d <= max {
+			found = append(found, suggestion{c, d})
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].distance != found[j].distance {
+			return found[i].distance < found[j].distance
+		}
+		return found[i].name < found[j].name
+	})
+
+	if len(found) > maxSuggestions {
+		found = found[:maxSuggestions]
+	}
+
+	var result = make([]string, len(found))
+	for i, s := range found {
+		result[i] = s.name
+	}
+	return result
+}
+
+func maxDistance(name string) int {
+	d := (utf8.RuneCountInString(name) + 1) / 3
+	if d > 3 {
+		return 3
+	}
+	return d
+}
+
+// editDistance returns the number of insertions, deletions, substitutions
+// and transpositions of adjacent characters needed to turn a into b. Changing
+// only the case of a character costs the same as any other substitution.
+func editDistance(a, b string) int {
+	var (
+		ra = []rune(a)
+		rb = []rune(b)
+		// rows are the last three rows of the distance matrix
+		rows = [3][]int{
+			make([]int, len(rb)+1),
+			make([]int, len(rb)+1),
+			make([]int, len(rb)+1),
+		}
+	)
+
+	for j := range rows[1] {
+		rows[1][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		prev2, prev, cur := rows[0], rows[1], rows[2]
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			cur[j] = minOf(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = minOf(cur[j], prev2[j-2]+1)
+			}
+		}
+		rows = [3][]int{prev, cur, prev2}
+	}
+	return rows[1][len(rb)]
+}
+
+func minOf(n int, ns ...int) int {
+	for _, m := range ns {
+		if m < n {
+			n = m
+		}
+	}
+	return n
+}
+
+// scopeNames returns the names of all the objects visible from the given
+// scope, including the ones imported into the module.
+func scopeNames(scope ast.Scope) []string {
+	var names []string
+	for scope != nil {
+		switch s := scope.(type) {
+		case *ast.ModuleScope:
+			names = append(names, objectNames(s.Objects)...)
+			names = append(names, objectNames(s.Imported)...)
+			scope = s.Parent
+		case *ast.NodeScope:
+			names = append(names, objectNames(s.Objects)...)
+			scope = s.Parent
+		default:
+			return names
+		}
+	}
+	return names
+}
+
+func objectNames(objects map[string]*ast.Object) []string {
+	var names = make([]string, 0, len(objects))
+	for name := range objects {
+		names = append(names, name)
+	}
+	return names
+}
+
+// moduleNames returns the names of all the modules imported into the module
+// of the given scope.
+func moduleNames(scope ast.Scope) []string {
+	for scope != nil {
+		switch s := scope.(type) {
+		case *ast.ModuleScope:
+			return objectNames(s.Modules)
+		case *ast.NodeScope:
+			scope = s.Parent
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// exposedCtors returns the names of the constructors of the given union
+// exposed by the module of the given scope.
+func exposedCtors(scope *ast.ModuleScope, union *ast.UnionDecl) []string {
+	var names []string
+	for _, c := range union.Ctors {
+		if scope.LookupExposed(c.Name.Name, ast.Ctor) != nil {
+			names = append(names, c.Name.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditDistance(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		distance int
+	}{
+		{"", "", 0},
+		{"", "foo", 3},
+		{"foo", "", 3},
+		{"foo", "foo", 0},
+		{"foo", "fo", 1},
+		{"foo", "fooo", 1},
+		{"foo", "fox", 1},
+		{"lenght", "length", 1},
+		{"foo", "Foo", 1},
+		{"kitten", "sitting", 3},
+		{"ñandú", "ñandu", 1},
+	}
+
+	for _, c := range cases {
+		t.Run(c.a+"/"+c.b, func(t *testing.T) {
+			require.Equal(t, c.distance, editDistance(c.a, c.b))
+		})
+	}
+}
+
+func TestSuggestions(t *testing.T) {
+	cases := []struct {
+		name       string
+		candidates []string
+		expected   []string
+	}{
+		{"x", []string{"y", "z"}, []string{}},
+		{"mpa", []string{"map", "Map", "max", "filter"}, []string{"map"}},
+		{"Mabye", []string{"Maybe", "maybe", "Result"}, []string{"Maybe"}},
+		{"lenght", []string{"length", "length", "lengths"}, []string{"length", "lengths"}},
+		{"foldr", []string{"foldl", "fold", "foldp", "folder", "foldrr", "foo"}, []string{"fold", "folder", "foldl", "foldp"}},
+		{"foo", []string{"foo", "fo"}, []string{"fo"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.expected, suggestions(c.name, c.candidates))
+		})
+	}
+}
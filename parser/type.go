@@ -10,7 +10,7 @@ func parseTypeList(p *parser) (types []ast.Type) {
 		var typ ast.Type
 		switch p.tok.Type {
 		case token.LeftParen, token.LeftBrace:
-			typ = parseType(p)
+			typ = parseAtomType(p)
 		case token.Identifier:
 			ident := parseQualifiedIdentifier(p)
 			if name, ok := ident.(*ast.Ident); ok && isLower(name.Name) {
@@ -111,18 +111,32 @@ func parseRecordType(p *parser) *ast.RecordType {
 		Lbrace: p.expect(token.LeftBrace),
 	}
 
-	for !p.is(token.RightBrace) && !p.is(token.EOF) {
-		if len(t.Fields) > 0 {
-			p.expect(token.Comma)
+	if !p.is(token.RightBrace) && !p.is(token.EOF) {
+		name := parseLowerName(p)
+		if p.is(token.Pipe) {
+			// extended record, e.g. { a | name : String }
+			t.Extended = &ast.VarType{name}
+			t.Pipe = p.expect(token.Pipe)
+			name = parseLowerName(p)
 		}
 
-		f := new(ast.RecordField)
-		f.Name = parseLowerName(p)
-		f.Colon = p.expect(token.Colon)
-		f.Type = p.expectType()
-		t.Fields = append(t.Fields, f)
+		t.Fields = append(t.Fields, parseRecordTypeField(p, name))
+	}
+
+	for !p.is(token.RightBrace) && !p.is(token.EOF) {
+		p.expect(token.Comma)
+		t.Fields = append(t.Fields, parseRecordTypeField(p, parseLowerName(p)))
 	}
 
 	t.Rbrace = p.expect(token.RightBrace)
 	return t
 }
+
+// parseRecordTypeField parses the rest of a record type field whose name
+// has already been parsed.
+func parseRecordTypeField(p *parser, name *ast.Ident) *ast.RecordField {
+	f := &ast.RecordField{Name: name}
+	f.Colon = p.expect(token.Colon)
+	f.Type = p.expectType()
+	return f
+}
@@ -0,0 +1,213 @@
+package printer
+
+import (
+	"github.com/elm-tangram/tangram/ast"
+	"github.com/elm-tangram/tangram/operator"
+	"github.com/elm-tangram/tangram/token"
+)
+
+// maxPos is a position after any other position in the source.
+const maxPos = token.Pos(^uint(0) >> 1)
+
+func (p *printer) module(mod *ast.Module) {
+	p.flush(mod.Module.Pos())
+	p.moduleDecl(mod.Module)
+
+	var first = true
+	for _, imp := range mod.Imports {
+		// default imports are not in the source code
+		if imp.Import == token.NoPos {
+			continue
+		}
+
+		if first {
+			p.newlines(2)
+			first = false
+		} else {
+			p.newlines(1)
+		}
+		p.flush(imp.Pos())
+		p.importDecl(imp)
+	}
+
+	for _, decl := range mod.Decls {
+		p.newlines(3)
+		p.flush(decl.Pos())
+		p.decl(decl)
+	}
+
+	if len(p.comments) > 0 {
+		p.newlines(3)
+		p.flush(maxPos)
+	}
+	p.newlines(1)
+}
+
+func (p *printer) moduleDecl(decl *ast.ModuleDecl) {
+	if decl.IsPort {
+		p.token(decl.Port, "port ")
+	}
+	p.token(decl.Module, "module ")
+	p.expr(decl.Name)
+
+	if decl.Exposing != nil {
+		p.write(" exposing ")
+		p.exposedList(decl.Exposing)
+	}
+}
+
+func (p *printer) importDecl(decl *ast.ImportDecl) {
+	p.token(decl.Import, "import ")
+	p.expr(decl.Module)
+
+	if decl.Alias != nil {
+		p.write(" as ")
+		p.ident(decl.Alias)
+	}
+
+	if decl.Exposing != nil {
+		p.write(" exposing ")
+		p.exposedList(decl.Exposing)
+	}
+}
+
+func (p *printer) exposedList(list ast.ExposedList) {
+	switch list := list.(type) {
+	case *ast.OpenList:
+		p.token(list.Rparen, "(..)")
+	case *ast.ClosedList:
+		p.write("(")
+		for i, exposed := range list.Exposed {
+			if i > 0 {
+				p.write(", ")
+			}
+
+			switch exposed := exposed.(type) {
+			case *ast.ExposedVar:
+				p.ident(exposed.Ident)
+			case *ast.ExposedUnion:
+				p.ident(exposed.Type)
+				if exposed.Ctors != nil {
+					p.exposedList(exposed.Ctors)
+				}
+			}
+		}
+		p.token(list.Rparen, ")")
+	}
+}
+
+func (p *printer) decl(decl ast.Decl) {
+	switch decl := decl.(type) {
+	case *ast.Definition:
+		p.definition(decl)
+	case *ast.DestructuringAssignment:
+		p.pattern(decl.Pattern, false)
+		p.write(" ")
+		p.token(decl.Eq, "=")
+		p.body(decl.Expr)
+	case *ast.AliasDecl:
+		p.aliasDecl(decl)
+	case *ast.UnionDecl:
+		p.unionDecl(decl)
+	case *ast.PortDecl:
+		p.token(decl.Port, "port ")
+		p.ident(decl.Name)
+		p.write(" : ")
+		p.typ(decl.Type, topType)
+	case *ast.InfixDecl:
+		p.token(decl.InfixPos, assocKeyword(decl.Assoc))
+		p.write(" ")
+		p.expr(decl.Precedence)
+		p.write(" ")
+		p.token(decl.Op.Pos(), decl.Op.Name)
+	}
+}
+
+func assocKeyword(assoc operator.Associativity) string {
+	switch assoc {
+	case operator.Left:
+		return "infixl"
+	case operator.Right:
+		return "infixr"
+	default:
+		return "infix"
+	}
+}
+
+func (p *printer) definition(decl *ast.Definition) {
+	if ann := decl.Annotation; ann != nil {
+		p.ident(ann.Name)
+		p.write(" ")
+		p.token(ann.Colon, ":")
+		p.write(" ")
+		p.typ(ann.Type, topType)
+		p.newline()
+		p.flush(decl.Name.Pos())
+	}
+
+	p.ident(decl.Name)
+	for _, arg := range decl.Args {
+		p.write(" ")
+		p.pattern(arg, true)
+	}
+	p.write(" ")
+	p.token(decl.Eq, "=")
+	p.body(decl.Body)
+}
+
+// body prints the given expression in the next line with one more level of
+// indentation.
+func (p *printer) body(expr ast.Expr) {
+	p.indent++
+	p.newline()
+	p.flush(expr.Pos())
+	p.expr(expr)
+	p.indent--
+}
+
+func (p *printer) aliasDecl(decl *ast.AliasDecl) {
+	p.token(decl.TypePos, "type alias ")
+	p.ident(decl.Name)
+	for _, arg := range decl.Args {
+		p.write(" ")
+		p.ident(arg)
+	}
+	p.write(" ")
+	p.token(decl.Eq, "=")
+
+	p.indent++
+	p.newline()
+	p.flush(decl.Type.Pos())
+	p.multilineTypes = true
+	p.typ(decl.Type, topType)
+	p.multilineTypes = false
+	p.indent--
+}
+
+func (p *printer) unionDecl(decl *ast.UnionDecl) {
+	p.token(decl.TypePos, "type ")
+	p.ident(decl.Name)
+	for _, arg := range decl.Args {
+		p.write(" ")
+		p.ident(arg)
+	}
+
+	p.indent++
+	for i, ctor := range decl.Ctors {
+		p.newline()
+		p.flush(ctor.Pos())
+		if i == 0 {
+			p.token(decl.Eq, "=")
+		} else {
+			p.write("|")
+		}
+
+		p.write(" ")
+		p.ident(ctor.Name)
+		for _, arg := range ctor.Args {
+			p.write(" ")
+			p.typ(arg, atomType)
+		}
+	}
+	p.indent--
+}
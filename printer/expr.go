@@ -0,0 +1,265 @@
+package printer
+
+import (
+	"strings"
+
+	"github.com/elm-tangram/tangram/ast"
+)
+
+func (p *printer) expr(expr ast.Expr) {
+	p.inline(expr.Pos())
+	switch e := expr.(type) {
+	case *ast.Ident:
+		p.ident(e)
+	case *ast.SelectorExpr:
+		p.token(e.Pos(), e.String())
+	case *ast.BasicLit:
+		p.token(e.Pos(), e.Value)
+	case *ast.UnaryOp:
+		p.token(e.Op.Pos(), e.Op.Name)
+		p.expr(e.Expr)
+	case *ast.AccessorExpr:
+		p.write(".")
+		p.ident(e.Field)
+	case *ast.TupleCtor:
+		p.token(e.Lparen, "("+strings.Repeat(",", e.Elems-1)+")")
+	case *ast.TupleLit:
+		if len(e.Elems) == 0 {
+			p.token(e.Lparen, "()")
+			return
+		}
+
+		p.list("(", ")", e.Rparen, p.multiline(e), exprNodes(e.Elems), func(i int) {
+			p.expr(e.Elems[i])
+		})
+	case *ast.ListLit:
+		if len(e.Elems) == 0 {
+			p.token(e.Lbracket, "[]")
+			return
+		}
+
+		p.list("[", "]", e.Rbracket, p.multiline(e), exprNodes(e.Elems), func(i int) {
+			p.expr(e.Elems[i])
+		})
+	case *ast.RecordLit:
+		if len(e.Fields) == 0 {
+			p.token(e.Lbrace, "{}")
+			return
+		}
+
+		multiline := p.multiline(e)
+		p.list("{", "}", e.Rbrace, multiline, fieldNodes(e.Fields), func(i int) {
+			p.fieldAssign(e.Fields[i], multiline)
+		})
+	case *ast.RecordUpdate:
+		multiline := p.multiline(e)
+		p.token(e.Lbrace, "{ ")
+		p.ident(e.Record)
+		p.extension(e.Rbrace, multiline, fieldNodes(e.Fields), func(i int) {
+			p.fieldAssign(e.Fields[i], multiline)
+		})
+	case *ast.FuncApp:
+		p.funcApp(e)
+	case *ast.BinaryOp:
+		p.binaryOp(e)
+	case *ast.ParensExpr:
+		p.token(e.Lparen, "(")
+		p.expr(e.Expr)
+		if p.multiline(e) {
+			p.newline()
+			p.flush(e.Rparen)
+		}
+		p.token(e.Rparen, ")")
+	case *ast.Lambda:
+		p.lambda(e)
+	case *ast.IfExpr:
+		p.ifExpr(e)
+	case *ast.CaseExpr:
+		p.caseExpr(e)
+	case *ast.LetExpr:
+		p.letExpr(e)
+	}
+}
+
+func (p *printer) ident(ident *ast.Ident) {
+	if ident.IsOp() {
+		p.inline(ident.Pos())
+		p.write("(")
+		p.token(ident.Pos(), ident.Name)
+		p.write(")")
+		return
+	}
+
+	p.token(ident.Pos(), ident.Name)
+}
+
+func (p *printer) fieldAssign(field *ast.FieldAssign, multiline bool) {
+	p.ident(field.Field)
+	p.write(" ")
+	p.token(field.Eq, "=")
+	if multiline && p.multiline(field.Expr) {
+		p.newline()
+		p.flush(field.Expr.Pos())
+	} else {
+		p.write(" ")
+	}
+	p.expr(field.Expr)
+}
+
+func (p *printer) funcApp(app *ast.FuncApp) {
+	multiline := p.multiline(app)
+	p.expr(app.Func)
+
+	p.indent++
+	for _, arg := range app.Args {
+		if multiline {
+			p.newline()
+			p.flush(arg.Pos())
+		} else {
+			p.write(" ")
+		}
+		p.expr(arg)
+	}
+	p.indent--
+}
+
+func (p *printer) binaryOp(op *ast.BinaryOp) {
+	// operators are printed in the order they appear in the source, so
+	// the left operands are flattened to print all the operators at the
+	// same level
+	var ops []*ast.BinaryOp
+	var lhs ast.Expr = op
+	for {
+		bin, ok := lhs.(*ast.BinaryOp)
+		if !ok {
+			break
+		}
+
+		ops = append([]*ast.BinaryOp{bin}, ops...)
+		lhs = bin.Lhs
+	}
+
+	multiline := p.multiline(op)
+	p.expr(lhs)
+
+	p.indent++
+	for _, op := range ops {
+		if multiline {
+			p.newline()
+			p.flush(op.Op.Pos())
+		} else {
+			p.write(" ")
+		}
+
+		p.token(op.Op.Pos(), op.Op.Name)
+		p.write(" ")
+		p.expr(op.Rhs)
+	}
+	p.indent--
+}
+
+func (p *printer) lambda(lambda *ast.Lambda) {
+	p.token(lambda.Backslash, "\\")
+	for i, arg := range lambda.Args {
+		if i > 0 {
+			p.write(" ")
+		}
+		p.pattern(arg, true)
+	}
+	p.write(" ")
+	p.token(lambda.Arrow, "->")
+
+	if p.multiline(lambda) {
+		p.body(lambda.Expr)
+		return
+	}
+
+	p.write(" ")
+	p.expr(lambda.Expr)
+}
+
+func (p *printer) ifExpr(expr *ast.IfExpr) {
+	if !p.multiline(expr) {
+		p.token(expr.If, "if ")
+		p.expr(expr.Cond)
+		p.write(" ")
+		p.token(expr.Then, "then ")
+		p.expr(expr.ThenExpr)
+		p.write(" ")
+		p.token(expr.Else, "else ")
+		p.expr(expr.ElseExpr)
+		return
+	}
+
+	p.token(expr.If, "if ")
+	p.expr(expr.Cond)
+	p.write(" ")
+	p.token(expr.Then, "then")
+	p.body(expr.ThenExpr)
+
+	p.newline()
+	p.flush(expr.Else)
+	p.token(expr.Else, "else")
+	p.body(expr.ElseExpr)
+}
+
+func (p *printer) caseExpr(expr *ast.CaseExpr) {
+	p.token(expr.Case, "case ")
+	p.expr(expr.Expr)
+	p.write(" ")
+	p.token(expr.Of, "of")
+
+	p.indent++
+	for i, branch := range expr.Branches {
+		if i > 0 {
+			p.newlines(2)
+		} else {
+			p.newline()
+		}
+
+		p.flush(branch.Pos())
+		p.pattern(branch.Pattern, false)
+		p.write(" ")
+		p.token(branch.Arrow, "->")
+		p.body(branch.Expr)
+	}
+	p.indent--
+}
+
+func (p *printer) letExpr(expr *ast.LetExpr) {
+	p.token(expr.Let, "let")
+
+	p.indent++
+	for i, decl := range expr.Decls {
+		if i > 0 {
+			p.newlines(2)
+		} else {
+			p.newline()
+		}
+
+		p.flush(decl.Pos())
+		p.decl(decl)
+	}
+	p.indent--
+
+	p.newline()
+	p.flush(expr.In)
+	p.token(expr.In, "in")
+	p.body(expr.Body)
+}
+
+func exprNodes(exprs []ast.Expr) []ast.Node {
+	var nodes = make([]ast.Node, len(exprs))
+	for i, expr := range exprs {
+		nodes[i] = expr
+	}
+	return nodes
+}
+
+func fieldNodes(fields []*ast.FieldAssign) []ast.Node {
+	var nodes = make([]ast.Node, len(fields))
+	for i, field := range fields {
+		nodes[i] = field
+	}
+	return nodes
+}
@@ -0,0 +1,97 @@
+package printer
+
+import "github.com/elm-tangram/tangram/ast"
+
+// pattern prints the given pattern. If atom is true, the pattern is wrapped
+// with parenthesis unless it is a single term, as it is needed in the
+// arguments of functions and constructors.
+func (p *printer) pattern(pattern ast.Pattern, atom bool) {
+	p.inline(pattern.Pos())
+	if atom && !isAtomPattern(pattern) {
+		p.write("(")
+		defer p.write(")")
+	}
+
+	switch pat := pattern.(type) {
+	case *ast.VarPattern:
+		p.ident(pat.Name)
+	case *ast.AnythingPattern:
+		p.token(pat.Underscore, "_")
+	case *ast.LiteralPattern:
+		p.expr(pat.Literal)
+	case *ast.AliasPattern:
+		_, isAlias := pat.Pattern.(*ast.AliasPattern)
+		p.pattern(pat.Pattern, isAlias || isConsPattern(pat.Pattern))
+		p.write(" as ")
+		p.ident(pat.Name)
+	case *ast.CtorPattern:
+		if isConsPattern(pat) {
+			p.pattern(pat.Args[0], true)
+			p.write(" ")
+			p.token(pat.Ctor.Pos(), "::")
+			p.write(" ")
+			_, isAlias := pat.Args[1].(*ast.AliasPattern)
+			p.pattern(pat.Args[1], isAlias)
+			return
+		}
+
+		p.expr(pat.Ctor)
+		for _, arg := range pat.Args {
+			p.write(" ")
+			p.pattern(arg, true)
+		}
+	case *ast.TuplePattern:
+		p.list("(", ")", pat.Rparen, false, patternNodes(pat.Elems), func(i int) {
+			p.pattern(pat.Elems[i], false)
+		})
+	case *ast.RecordPattern:
+		if len(pat.Fields) == 0 {
+			p.token(pat.Rbrace, "{}")
+			return
+		}
+
+		p.list("{", "}", pat.Rbrace, false, patternNodes(pat.Fields), func(i int) {
+			p.pattern(pat.Fields[i], false)
+		})
+	case *ast.ListPattern:
+		if len(pat.Elems) == 0 {
+			p.token(pat.Rbracket, "[]")
+			return
+		}
+
+		p.list("[", "]", pat.Rbracket, false, patternNodes(pat.Elems), func(i int) {
+			p.pattern(pat.Elems[i], false)
+		})
+	}
+}
+
+// isAtomPattern reports whether the given pattern is a single term.
+func isAtomPattern(pattern ast.Pattern) bool {
+	switch pat := pattern.(type) {
+	case *ast.AliasPattern:
+		return false
+	case *ast.CtorPattern:
+		return len(pat.Args) == 0
+	}
+	return true
+}
+
+// isConsPattern reports whether the given pattern is a pattern using the
+// :: constructor of lists.
+func isConsPattern(pattern ast.Pattern) bool {
+	pat, ok := pattern.(*ast.CtorPattern)
+	if !ok {
+		return false
+	}
+
+	ident, ok := pat.Ctor.(*ast.Ident)
+	return ok && ident.Name == "::" && len(pat.Args) == 2
+}
+
+func patternNodes(patterns []ast.Pattern) []ast.Node {
+	var nodes = make([]ast.Node, len(patterns))
+	for i, pat := range patterns {
+		nodes[i] = pat
+	}
+	return nodes
+}
@@ -0,0 +1,302 @@
+// Package printer prints Elm ASTs in the canonical layout used by
+// elm-format.
+//
+// The layout of the printed code does not depend on how the original code
+// was laid out, except for the lists, records, tuples, function applications,
+// binary operations, lambdas and if expressions, that are printed in multiple
+// lines if they were written in multiple lines in the original source code.
+// All the comments of the module are kept. Comments are printed in their own
+// lines right before the node that follows them, at the end of the line if
+// they were in the same line as the previous token, or between the tokens
+// they were between if they were in the middle of a line.
+package printer
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/elm-tangram/tangram/ast"
+	"github.com/elm-tangram/tangram/parser"
+	"github.com/elm-tangram/tangram/token"
+)
+
+// indentation is the text used for each level of indentation.
+const indentation = "    "
+
+// Format parses the given Elm source code and returns it printed in the
+// canonical layout. An error is returned if the source code cannot be
+// parsed.
+func Format(name string, src []byte) ([]byte, error) {
+	mod, err := parser.ParseFrom(name, bytes.NewReader(src), parser.SkipWarnings)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := Fprint(&buf, src, mod); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Fprint prints the given module to w in the canonical layout. src is the
+// source code the module was parsed from, which is needed to know the lines
+// in which the nodes and comments were.
+func Fprint(w io.Writer, src []byte, mod *ast.Module) error {
+	p := newPrinter(src, mod.Comments)
+	p.module(mod)
+	_, err := w.Write(p.buf.Bytes())
+	return err
+}
+
+// TypeString returns the given type printed in a single line.
+func TypeString(typ ast.Type) string {
+	p := newPrinter(nil, nil)
+	p.typ(typ, topType)
+	return p.buf.String()
+}
+
+type printer struct {
+	buf bytes.Buffer
+	src []byte
+	// lines contains the offset at which every line of the source starts.
+	lines []token.Pos
+	// comments are the comments that have not been printed yet.
+	comments []*ast.CommentGroup
+	// indent is the current indentation level.
+	indent int
+	// lineStart reports whether nothing has been written in the current line
+	// yet.
+	lineStart bool
+	// last is the end position of the last token printed.
+	last token.Pos
+	// multilineTypes reports whether record types can be printed in
+	// multiple lines, which is only allowed in type alias declarations.
+	multilineTypes bool
+}
+
+func newPrinter(src []byte, comments []*ast.CommentGroup) *printer {
+	var lines = []token.Pos{0}
+	for i, b := range src {
+		if b == '\n' {
+			lines = append(lines, token.Pos(i+1))
+		}
+	}
+
+	return &printer{
+		src:       src,
+		lines:     lines,
+		comments:  comments,
+		lineStart: true,
+	}
+}
+
+// line returns the line of the source in which the given position is.
+func (p *printer) line(pos token.Pos) int {
+	return sort.Search(len(p.lines), func(i int) bool {
+		return p.lines[i] > pos
+	})
+}
+
+// multiline reports whether the given node needs to be printed in multiple
+// lines, which happens if it was written in multiple lines or contains an
+// expression that is always printed in multiple lines.
+func (p *printer) multiline(node ast.Node) bool {
+	if p.line(node.Pos()) != p.line(node.End()) {
+		return true
+	}
+
+	var found bool
+	ast.WalkFunc(node, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.CaseExpr, *ast.LetExpr:
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// write writes the given text, indenting it if it is at the start of the
+// line.
+func (p *printer) write(text string) {
+	if text == "" {
+		return
+	}
+
+	if p.lineStart {
+		p.buf.WriteString(strings.Repeat(indentation, p.indent))
+		p.lineStart = false
+	}
+	p.buf.WriteString(text)
+}
+
+// token writes the text of a token that is at the given position in the
+// source, after the pending comments that were before it.
+func (p *printer) token(pos token.Pos, text string) {
+	p.inline(pos)
+	p.write(text)
+	if end := pos + token.Pos(len(text)); pos != token.NoPos && end > p.last {
+		p.last = end
+	}
+}
+
+// inline prints the pending comments that end before the given position
+// where the printer is, so comments in the middle of a line stay in the
+// middle of the code around them. If nothing has been written in the
+// current line yet, they are printed in their own lines instead. Line
+// comments end the current line.
+func (p *printer) inline(pos token.Pos) {
+	for len(p.comments) > 0 && p.comments[0].End() <= pos {
+		if p.lineStart {
+			p.flush(pos)
+			return
+		}
+
+		group := p.comments[0]
+		p.comments = p.comments[1:]
+		for _, c := range group.List {
+			if b := p.buf.Bytes(); b[len(b)-1] != ' ' {
+				p.write(" ")
+			}
+
+			p.token(c.Pos(), c.Text)
+			if strings.HasPrefix(c.Text, "--") {
+				p.newline()
+			} else {
+				p.write(" ")
+			}
+		}
+	}
+}
+
+// separator returns the position of the first sep in the source between
+// from and to that is not inside a comment. It is used for the separators
+// that have no position in the AST. If there is none, to is returned.
+func (p *printer) separator(from, to token.Pos, sep string) token.Pos {
+	comments := p.comments
+	for pos := from; pos < to && int(pos) < len(p.src); pos++ {
+		for len(comments) > 0 && comments[0].End() <= pos {
+			comments = comments[1:]
+		}
+
+		if len(comments) > 0 && comments[0].Pos() <= pos {
+			continue
+		}
+
+		if bytes.HasPrefix(p.src[pos:], []byte(sep)) {
+			return pos
+		}
+	}
+	return to
+}
+
+// newline ends the current line. If the next comment was right after the
+// last token printed in the same line, it is printed before ending the line.
+func (p *printer) newline() {
+	if len(p.comments) > 0 && p.isTrailing(p.comments[0]) {
+		p.write(" ")
+		p.token(p.comments[0].Pos(), p.comments[0].List[0].Text)
+		p.comments = p.comments[1:]
+	}
+
+	p.buf.WriteByte('\n')
+	p.lineStart = true
+}
+
+// isTrailing reports whether the given group is a single line comment that
+// comes right after the last token printed in the same line. Only spaces
+// and closing parenthesis, which may have been omitted by the printer, are
+// allowed between them.
+func (p *printer) isTrailing(group *ast.CommentGroup) bool {
+	if p.last == token.NoPos || len(group.List) > 1 || group.Pos() < p.last ||
+		strings.Contains(group.List[0].Text, "\n") {
+		return false
+	}
+
+	for _, b := range p.src[p.last:group.Pos()] {
+		if b != ' ' && b != '\t' && b != ')' {
+			return false
+		}
+	}
+	return true
+}
+
+// newlines ends the current line, if it was not ended yet, and makes sure
+// that the output ends with at least n line breaks.
+func (p *printer) newlines(n int) {
+	if !p.lineStart {
+		p.newline()
+	}
+
+	b := p.buf.Bytes()
+	var count int
+	for i := len(b) - 1; i >= 0 && b[i] == '\n'; i-- {
+		count++
+	}
+
+	if len(b) > 0 {
+		for ; count < n; count++ {
+			p.buf.WriteByte('\n')
+		}
+	}
+}
+
+// flush prints all the pending comments that end before the given position
+// in their own lines. Groups of comments are separated by an empty line, and
+// the last one is separated from the given position by an empty line if
+// there was one in the source.
+func (p *printer) flush(pos token.Pos) {
+	var last *ast.CommentGroup
+	for len(p.comments) > 0 && p.comments[0].End() <= pos {
+		if !p.lineStart {
+			p.newline()
+		}
+
+		last = p.comments[0]
+		p.comments = p.comments[1:]
+		for _, c := range last.List {
+			p.token(c.Pos(), c.Text)
+			p.newline()
+		}
+
+		if len(p.comments) > 0 && p.comments[0].End() <= pos {
+			p.buf.WriteByte('\n')
+		}
+	}
+
+	if last != nil && pos < token.Pos(len(p.src)) && p.line(pos) > p.line(last.End())+1 {
+		p.buf.WriteByte('\n')
+	}
+}
+
+// list prints the given items separated by commas between the given
+// delimiters, either in a single line or one item per line if multiline is
+// true. item is used to print the item at each index. end is the position of
+// the closing delimiter.
+func (p *printer) list(open, close string, end token.Pos, multiline bool, items []ast.Node, item func(int)) {
+	p.write(open + " ")
+	for i := range items {
+		if i > 0 {
+			if multiline {
+				p.newline()
+				p.flush(items[i].Pos())
+			}
+			p.write(", ")
+		}
+
+		p.indent++
+		item(i)
+		p.indent--
+	}
+
+	if multiline {
+		p.newline()
+		p.flush(end)
+	} else {
+		p.write(" ")
+	}
+	p.token(end, close)
+}
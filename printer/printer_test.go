@@ -0,0 +1,251 @@
+package printer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			"header",
+			"port module  Main exposing ( main,Foo(..) , Bar(A,B), (<+>) )\nimport Foo as F exposing (..)\nimport Bar\nmain = 1",
+			"port module Main exposing (main, Foo(..), Bar(A, B), (<+>))\n\nimport Foo as F exposing (..)\nimport Bar\n\n\nmain =\n    1\n",
+		},
+		{
+			"definitions",
+			"module Main exposing (..)\nf : (a -> b) -> List (Maybe a) -> (Int, b)\nf g (x, _) {a} = a\n(<+>) a b = a",
+			"module Main exposing (..)\n\n\nf : (a -> b) -> List (Maybe a) -> ( Int, b )\nf g ( x, _ ) { a } =\n    a\n\n\n(<+>) a b =\n    a\n",
+		},
+		{
+			"types",
+			"module Main exposing (..)\ntype Foo a = Foo (List a) | Bar\ntype alias R a = { a | x : Int, y : { z : Int\n , w : Int } }\ninfixr 5 <+>\nport foo : Int -> Cmd msg",
+			`module Main exposing (..)
+
+
+type Foo a
+    = Foo (List a)
+    | Bar
+
+
+type alias R a =
+    { a
+        | x : Int
+        , y :
+            { z : Int
+            , w : Int
+            }
+    }
+
+
+infixr 5 <+>
+
+
+port foo : Int -> Cmd msg
+`,
+		},
+		{
+			"lists and records",
+			"module Main exposing (..)\nx = [1,2, 3]\ny = [ 1\n  , 2 ]\nz = {a = 1, b = [ ]}\nw = { z | a = 2 }\nv = { z\n | a = (1, 2), b = ()}",
+			`module Main exposing (..)
+
+
+x =
+    [ 1, 2, 3 ]
+
+
+y =
+    [ 1
+    , 2
+    ]
+
+
+z =
+    { a = 1, b = [] }
+
+
+w =
+    { z | a = 2 }
+
+
+v =
+    { z
+        | a = ( 1, 2 )
+        , b = ()
+    }
+`,
+		},
+		{
+			"case and let",
+			"module Main exposing (..)\nf x = let\n          y = 1\n          (a, b) = (2, 3)\n      in case x of\n           Just (z :: _) -> z\n           _ ->\n             y",
+			`module Main exposing (..)
+
+
+f x =
+    let
+        y =
+            1
+
+        ( a, b ) =
+            ( 2, 3 )
+    in
+        case x of
+            Just (z :: _) ->
+                z
+
+            _ ->
+                y
+`,
+		},
+		{
+			"expressions",
+			"module Main exposing (..)\nf = if a then b else c\ng = if a then\n      b\n    else if c then d else e\nh = \\x (a, b) -> x + a * -b\ni = List.map (.x) (,) (+)\nj = a\n  |> f\n  |> g\nk = foo\n  bar\n  (\\x ->\n     x)",
+			`module Main exposing (..)
+
+
+f =
+    if a then b else c
+
+
+g =
+    if a then
+        b
+    else
+        if c then d else e
+
+
+h =
+    \x ( a, b ) -> x + a * -b
+
+
+i =
+    List.map (.x) (,) (+)
+
+
+j =
+    a
+        |> f
+        |> g
+
+
+k =
+    foo
+        bar
+        (\x ->
+            x
+        )
+`,
+		},
+		{
+			"comments",
+			"-- header\nmodule Main exposing (..)\n\n{-| docs\n-}\n\nimport Foo -- trailing\n\n-- section\n\n-- above\nx = 1 -- one\n\ny = case x of\n  -- first\n  1 -> 2\n  _ -> 3\n{- end -}",
+			`-- header
+module Main exposing (..)
+
+{-| docs
+-}
+
+import Foo -- trailing
+
+
+-- section
+
+-- above
+x =
+    1 -- one
+
+
+y =
+    case x of
+        -- first
+        1 ->
+            2
+
+        _ ->
+            3
+
+
+{- end -}
+`,
+		},
+		{
+			"comments inside expressions",
+			"module Main exposing (..)\nf x =\n    (\\y -> y {- inline -} + 1) x\ng {- arg -} (a, b) = [ {- first -} a, b ]\nh : Int {- type -} -> Int\nh = (+) {- op -} 1\ni = foo -- line\n  bar",
+			`module Main exposing (..)
+
+
+f x =
+    (\y -> y {- inline -} + 1) x
+
+
+g {- arg -} ( a, b ) =
+    [ {- first -} a, b ]
+
+
+h : Int {- type -} -> Int
+h =
+    (+) {- op -} 1
+
+
+i =
+    foo -- line
+        bar
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require := require.New(t)
+			result, err := Format("Main.elm", []byte(c.input))
+			require.NoError(err)
+			require.Equal(c.expected, string(result))
+
+			result, err = Format("Main.elm", result)
+			require.NoError(err)
+			require.Equal(c.expected, string(result), "formatting should be idempotent")
+		})
+	}
+}
+
+func TestFormatFiles(t *testing.T) {
+	var files []string
+	err := filepath.Walk(filepath.Join("..", "types", "_testdata"), func(path string, info os.FileInfo, err error) error {
+		if err == nil && strings.HasSuffix(path, ".elm") {
+			files = append(files, path)
+		}
+		return err
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, path := range files {
+		t.Run(path, func(t *testing.T) {
+			require := require.New(t)
+			src, err := ioutil.ReadFile(path)
+			require.NoError(err)
+
+			result, err := Format(path, src)
+			require.NoError(err)
+			require.Equal(strings.Count(string(src), "--"), strings.Count(string(result), "--"), "comments should be kept")
+
+			again, err := Format(path, result)
+			require.NoError(err)
+			require.Equal(string(result), string(again), "formatting should be idempotent")
+		})
+	}
+}
+
+func TestFormatError(t *testing.T) {
+	_, err := Format("Main.elm", []byte("module Main exposing (..)\nx = (1"))
+	require.Error(t, err)
+}
@@ -0,0 +1,143 @@
+package printer
+
+import (
+	"github.com/elm-tangram/tangram/ast"
+	"github.com/elm-tangram/tangram/token"
+)
+
+// typeContext is the context in which a type is printed, which determines
+// whether it needs to be wrapped with parenthesis.
+type typeContext byte
+
+const (
+	// topType is a type that does not need parenthesis.
+	topType typeContext = iota
+	// funcType is an argument or the return type of a function type.
+	funcType
+	// atomType is an argument of another type.
+	atomType
+)
+
+func (p *printer) typ(typ ast.Type, ctx typeContext) {
+	p.inline(typ.Pos())
+	switch t := typ.(type) {
+	case *ast.NamedType:
+		parens := ctx == atomType && len(t.Args) > 0
+		if parens {
+			p.write("(")
+		}
+
+		p.expr(t.Name)
+		for _, arg := range t.Args {
+			p.write(" ")
+			p.typ(arg, atomType)
+		}
+
+		if parens {
+			p.write(")")
+		}
+	case *ast.VarType:
+		p.ident(t.Ident)
+	case *ast.FuncType:
+		if ctx != topType {
+			p.write("(")
+		}
+
+		for i, arg := range t.Args {
+			next := t.Return
+			if i+1 < len(t.Args) {
+				next = t.Args[i+1]
+			}
+
+			p.typ(arg, funcType)
+			p.write(" ")
+			p.token(p.separator(arg.End(), next.Pos(), "->"), "->")
+			p.write(" ")
+		}
+		p.typ(t.Return, funcType)
+
+		if ctx != topType {
+			p.write(")")
+		}
+	case *ast.TupleType:
+		items := make([]ast.Node, len(t.Elems))
+		for i, elem := range t.Elems {
+			items[i] = elem
+		}
+
+		p.list("(", ")", t.Rparen, false, items, func(i int) {
+			p.typ(t.Elems[i], topType)
+		})
+	case *ast.RecordType:
+		p.recordType(t)
+	}
+}
+
+func (p *printer) recordType(t *ast.RecordType) {
+	if len(t.Fields) == 0 {
+		p.token(t.Rbrace, "{}")
+		return
+	}
+
+	multiline := p.multilineTypes && p.multiline(t)
+	items := make([]ast.Node, len(t.Fields))
+	for i, f := range t.Fields {
+		items[i] = f
+	}
+
+	field := func(i int) {
+		f := t.Fields[i]
+		p.ident(f.Name)
+		p.write(" :")
+		if multiline && p.multiline(f.Type) {
+			p.newline()
+			p.flush(f.Type.Pos())
+		} else {
+			p.write(" ")
+		}
+		p.typ(f.Type, topType)
+	}
+
+	if t.Extended == nil {
+		p.list("{", "}", t.Rbrace, multiline, items, field)
+		return
+	}
+
+	p.write("{ ")
+	p.ident(t.Extended.Ident)
+	p.extension(t.Rbrace, multiline, items, field)
+}
+
+// extension prints the fields of an extended record type or a record update
+// after the name of the record being extended. end is the position of the
+// closing brace.
+func (p *printer) extension(end token.Pos, multiline bool, items []ast.Node, field func(int)) {
+	p.indent++
+	for i := range items {
+		if multiline {
+			p.newline()
+			p.flush(items[i].Pos())
+		} else if i == 0 {
+			p.write(" ")
+		}
+
+		if i == 0 {
+			p.write("| ")
+		} else {
+			p.write(", ")
+		}
+
+		p.indent++
+		field(i)
+		p.indent--
+	}
+	p.indent--
+
+	if multiline {
+		p.newline()
+		p.flush(end)
+	} else {
+		p.write(" ")
+	}
+	p.token(end, "}")
+}
@@ -0,0 +1,73 @@
+package report
+
+// Code is a stable identifier of a kind of report. Codes never change, even
+// if the messages of the reports do, so they can be used to look up or
+// filter reports. Their first letter is the type of the report: S for syntax
+// errors, N for name errors, T for type errors, W for warnings, I for info
+// and E for any other error.
+type Code string
+
+// codes of the reports without a type of their own, by report type
+const (
+	codeOther  Code = "E000"
+	codeSyntax Code = "S000"
+	codeName   Code = "N000"
+	codeType   Code = "T000"
+	codeInfo   Code = "I000"
+	codeWarn   Code = "W000"
+)
+
+// codes of every kind of report, which must never be reused
+const (
+	codeUndefined         Code = "N001"
+	codeModuleNotImported Code = "N002"
+	codeImport            Code = "N003"
+	codeExport            Code = "N004"
+	codeExpectedUnion     Code = "N005"
+	codeExpectedCtor      Code = "N006"
+	codeRepeatedField     Code = "N007"
+	codeAlreadyDeclared   Code = "N008"
+	codeRepeatedVarType   Code = "N009"
+	codeRepeatedCtor      Code = "N010"
+	codeUnresolvedName    Code = "N011"
+	codeAmbiguousOperator Code = "N012"
+	codeCircularImport    Code = "N013"
+	codeModuleName        Code = "N014"
+	codeModuleNotExposed  Code = "N015"
+	codeAmbiguousModule   Code = "N016"
+	codeNativeModule      Code = "N017"
+	codeModuleConflict    Code = "N018"
+
+	codePort            Code = "S001"
+	codeExpectedType    Code = "S002"
+	codeUnexpectedEOF   Code = "S003"
+	codeUnexpectedToken Code = "S004"
+
+	codeTypeMismatch       Code = "T001"
+	codeAnnotationMismatch Code = "T002"
+	codeInfiniteType       Code = "T003"
+	codeCtorArgs           Code = "T004"
+	codeMissingPatterns    Code = "T005"
+	codeUntypedNative      Code = "T006"
+
+	codeRedundantPattern Code = "W001"
+)
+
+// code returns the code of the reports of the given type without a type of
+// their own.
+func (t ReportType) code() Code {
+	switch t {
+	case SyntaxError:
+		return codeSyntax
+	case NameError:
+		return codeName
+	case TypeError:
+		return codeType
+	case Info:
+		return codeInfo
+	case Warning:
+		return codeWarn
+	default:
+		return codeOther
+	}
+}
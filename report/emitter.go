@@ -2,9 +2,11 @@ package report
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/elm-tangram/tangram/source"
 )
@@ -15,6 +17,14 @@ type Emitter interface {
 	Emit(string, []*Diagnostic) error
 }
 
+// Flusher is an emitter that keeps the reports emitted and writes all of
+// them at once when it is flushed.
+type Flusher interface {
+	Emitter
+	// Flush writes all the reports emitted since the last flush.
+	Flush() error
+}
+
 // Errors is an emitter that emits Go errors with the reports.
 func Errors(warnings bool) Emitter {
 	return &errorEmitter{warnings}
@@ -26,7 +36,7 @@ type errorEmitter struct {
 
 func (e *errorEmitter) Emit(file string, diagnostics []*Diagnostic) error {
 	var buf bytes.Buffer
-	emitter := writerEmitter{&buf, e.warnings, false}
+	emitter := writerEmitter{&buf, WriterOptions{Warnings: e.warnings}}
 	if err := emitter.Emit(file, diagnostics); err != nil {
 		return err
 	}
@@ -34,10 +44,44 @@ func (e *errorEmitter) Emit(file string, diagnostics []*Diagnostic) error {
 	return fmt.Errorf("problems found at file: %s\n\n%s", file, buf.String())
 }
 
+// WriterOptions are the options of an emitter that writes the diagnostics
+// in a human readable format.
+type WriterOptions struct {
+	// Warnings reports whether warnings are emitted as well as errors.
+	Warnings bool
+	// Colors reports whether the output is colored.
+	Colors bool
+	// ContextLines is the number of lines of code shown before and after
+	// the region of every diagnostic.
+	ContextLines int
+	// MaxWidth is the maximum number of columns of the lines of code shown.
+	// Longer lines are cut around the region of the diagnostic. If it is 0,
+	// lines are never cut.
+	MaxWidth int
+}
+
+const (
+	// DefaultContextLines is the number of lines of code shown around the
+	// region of a diagnostic by default.
+	DefaultContextLines = 1
+	// DefaultMaxWidth is the maximum number of columns of the lines of code
+	// shown by default.
+	DefaultMaxWidth = 100
+)
+
+// Writer creates a new emitter that writes all diagnostics to the given
+// writer in a human readable format.
+func Writer(w io.Writer, opts WriterOptions) Emitter {
+	return &writerEmitter{w, opts}
+}
+
 type writerEmitter struct {
-	w        io.Writer
-	warnings bool
-	colors   bool
+	w io.Writer
+	WriterOptions
+}
+
+func (e *writerEmitter) contextLines() int {
+	return e.ContextLines
 }
 
 func hasErrors(diagnostics []*Diagnostic) bool {
@@ -50,7 +94,7 @@ func hasErrors(diagnostics []*Diagnostic) bool {
 }
 
 func (e *writerEmitter) Emit(file string, diagnostics []*Diagnostic) error {
-	if !e.warnings && !hasErrors(diagnostics) {
+	if !e.Warnings && !hasErrors(diagnostics) {
 		return nil
 	}
 
@@ -59,7 +103,7 @@ func (e *writerEmitter) Emit(file string, diagnostics []*Diagnostic) error {
 	}
 
 	for _, d := range diagnostics {
-		if e.warnings || d.Type != Warning {
+		if e.Warnings || d.Type != Warning {
 			if err := e.emitReport(file, d); err != nil {
 				return err
 			}
@@ -70,16 +114,40 @@ func (e *writerEmitter) Emit(file string, diagnostics []*Diagnostic) error {
 }
 
 func (e *writerEmitter) emitReport(file string, d *Diagnostic) error {
-	if err := e.printType(d.Type); err != nil {
+	if err := e.printType(d.Type, d.Code); err != nil {
 		return err
 	}
 
-	if err := e.print(d.Message); err != nil {
+	if err := e.print("%s", d.Message); err != nil {
 		return err
 	}
 
 	if d.Region != nil {
-		if err := e.printRegion(d.Type, d.Pos, d.Region); err != nil {
+		if err := e.printSpan(d.Type, d.shownSpan()); err != nil {
+			return err
+		}
+	}
+
+	for _, l := range d.Labels {
+		if err := e.print("\n%s\n", l.Message); err != nil {
+			return err
+		}
+
+		if l.Region != nil {
+			if err := e.printSpan(d.Type, l); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, n := range d.Notes {
+		if err := e.print("\nNote: %s\n", n); err != nil {
+			return err
+		}
+	}
+
+	for _, h := range d.Hints {
+		if err := e.print("\nHint: %s\n", h); err != nil {
 			return err
 		}
 	}
@@ -92,51 +160,393 @@ func (e *writerEmitter) print(msg string, args ...interface{}) error {
 	return err
 }
 
-func (e *writerEmitter) printType(typ ReportType) error {
+func (e *writerEmitter) printType(typ ReportType, code Code) error {
 	s := typ.String()
-	if e.colors {
+	if e.Colors {
 		s = typ.Color()(s)
 	}
-	return e.print("%s: ", s)
+
+	if code == "" {
+		return e.print("%s: ", s)
+	}
+	return e.print("%s [%s]: ", s, code)
 }
 
-func (e *writerEmitter) printRegion(typ ReportType, pos source.LinePos, region *source.Snippet) error {
-	var buf bytes.Buffer
-	if len(region.Lines) == 0 {
+// printSpan prints the lines of code of the given span, with its region
+// underlined.
+func (e *writerEmitter) printSpan(typ ReportType, span *Span) error {
+	ctx := span.Context
+	if ctx == nil || len(ctx.Lines) == 0 {
 		return nil
 	}
 
-	maxLine := region.Start + len(region.Lines) - 1
-	lastLineDigits := int64(len(fmt.Sprint(maxLine)))
-	lineFormat := "\n" + `%-` + fmt.Sprint(lastLineDigits) + "d | "
+	var (
+		lines  = make([][]rune, len(ctx.Lines))
+		widths = make([]int, len(ctx.Lines))
+	)
+	for i, l := range ctx.Lines {
+		lines[i] = []rune(l)
+		widths[i] = textWidth(lines[i])
+	}
+
+	start, end := span.Start, span.End
+	// the end of a region is exclusive, so a region that ends at the start
+	// of a line does not include that line
+	if end.Line > start.Line && end.Col <= 1 {
+		end.Line--
+		if i := end.Line - ctx.Start; i >= 0 && i < len(lines) {
+			end.Col = len(lines[i]) + 1
+		}
+	}
+
+	var focus int
+	if i := start.Line - ctx.Start; i >= 0 && i < len(lines) {
+		focus = textWidth(lines[i][:clampCol(start.Col-1, lines[i])])
+	}
+	w := e.window(widths, focus)
+
+	maxLine := ctx.Start + len(ctx.Lines) - 1
+	digits := len(fmt.Sprint(maxLine))
+	lineFormat := "\n" + `%-` + fmt.Sprint(digits) + "d | "
+	gutter := "\n" + strings.Repeat(" ", digits) + " | "
 
+	var buf bytes.Buffer
 	buf.WriteRune('\n')
-	for i, l := range region.Lines {
-		buf.WriteString(fmt.Sprintf(lineFormat, region.Start+i))
-		buf.WriteString(l)
-		if region.Start+i == pos.Line {
-			buf.WriteRune('\n')
-			for j := int64(0); j < int64(pos.Col)+3-1+lastLineDigits; j++ {
-				if e.colors {
-					buf.WriteString(typ.Color()("-"))
-				} else {
-					buf.WriteRune('-')
-				}
-			}
+	for i, line := range lines {
+		n := ctx.Start + i
+		buf.WriteString(fmt.Sprintf(lineFormat, n))
+		buf.WriteString(w.cut(line))
 
-			if e.colors {
-				buf.WriteString(typ.Color()("^"))
-			} else {
-				buf.WriteRune('^')
+		if n < start.Line || n > end.Line {
+			continue
+		}
+
+		from, to := 0, len(line)
+		if n == start.Line {
+			from = clampCol(start.Col-1, line)
+		} else {
+			from = len(line) - len([]rune(strings.TrimLeft(string(line), " ")))
+		}
+
+		if n == end.Line {
+			to = clampCol(end.Col-1, line)
+		}
+
+		if to <= from {
+			// regions that are empty in a line are only underlined in the
+			// line where they start
+			if n != start.Line {
+				continue
 			}
+			to = from + 1
+		}
+
+		pad, marks := w.underline(textWidth(line[:from]), textWidth(line[from:clampCol(to, line)]))
+		if marks == 0 {
+			marks = 1
+		}
+
+		carets := strings.Repeat("^", marks)
+		if e.Colors {
+			carets = typ.Color()(carets)
 		}
+
+		buf.WriteString(gutter)
+		buf.WriteString(strings.Repeat(" ", pad))
+		buf.WriteString(carets)
 	}
 	buf.WriteRune('\n')
 
-	return e.print(buf.String())
+	return e.print("%s", buf.String())
+}
+
+// shownSpan returns the span of the diagnostic that is shown. The region of
+// a syntax error is all the code being parsed up to the unexpected token,
+// which is the one shown, while the rest of the region is only context.
+func (d *Diagnostic) shownSpan() *Span {
+	span := d.span()
+	if d.Type == SyntaxError && before(span.Start, span.Pos) {
+		span.Start = span.Pos
+		if before(span.End, span.Pos) {
+			span.End = span.Pos
+		}
+	}
+	return span
+}
+
+// details returns the notes and hints of the diagnostic as a single text,
+// for the formats that have no place of their own for them.
+func (d *Diagnostic) details() string {
+	var parts []string
+	for _, n := range d.Notes {
+		parts = append(parts, "Note: "+n)
+	}
+	for _, h := range d.Hints {
+		parts = append(parts, "Hint: "+h)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// before reports whether the position a is before the position b.
+func before(a, b source.LinePos) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Col < b.Col)
+}
+
+// ellipsis marks the lines of code that have been cut.
+const ellipsis = "..."
+
+// window is the range of columns of the lines of code shown. All the lines
+// of a span are cut at the same columns, so their underlines are aligned.
+type window struct {
+	from, to int
+}
+
+// window returns the columns of lines with the given widths that are shown
+// so the column where the region starts is visible.
+func (e *writerEmitter) window(widths []int, focus int) window {
+	var longest int
+	for _, w := range widths {
+		if w > longest {
+			longest = w
+		}
+	}
+
+	if e.MaxWidth <= 0 || longest <= e.MaxWidth {
+		return window{0, longest}
+	}
+
+	// a bit of code before the region is kept, so it has some context
+	from := focus - e.MaxWidth/4
+	if from+e.MaxWidth > longest {
+		from = longest - e.MaxWidth
+	}
+
+	if from < 0 {
+		from = 0
+	}
+	return window{from, from + e.MaxWidth}
+}
+
+// cut returns the part of the line inside the window, with the parts
+// outside of it replaced by an ellipsis.
+func (w window) cut(line []rune) string {
+	var (
+		buf bytes.Buffer
+		col int
+	)
+	if w.from > 0 {
+		buf.WriteString(ellipsis)
+	}
+
+	for _, r := range line {
+		rw := runeWidth(r)
+		if col >= w.from && col+rw <= w.to {
+			buf.WriteRune(r)
+		}
+		col += rw
+	}
+
+	if col > w.to {
+		buf.WriteString(ellipsis)
+	}
+	return buf.String()
+}
+
+// underline returns the number of columns before the underline of the
+// region starting at the given column with the given width, and the width
+// of the underline, once the line has been cut.
+func (w window) underline(col, width int) (int, int) {
+	from, to := col, col+width
+	if from < w.from {
+		from = w.from
+	}
+
+	if to > w.to {
+		to = w.to
+	}
+
+	if from > w.to {
+		from = w.to
+	}
+
+	pad := from - w.from
+	if w.from > 0 {
+		pad += len(ellipsis)
+	}
+
+	if to < from {
+		to = from
+	}
+	return pad, to - from
+}
+
+// clampCol returns the given column as an index of the given line.
+func clampCol(col int, line []rune) int {
+	if col < 0 {
+		return 0
+	}
+
+	if col > len(line) {
+		return len(line)
+	}
+	return col
 }
 
 // Stderr creates a new emitter that will report to stderr all diagnostics.
 func Stderr(warnings, colors bool) Emitter {
-	return &writerEmitter{os.Stderr, warnings, colors}
+	return Writer(os.Stderr, WriterOptions{
+		Warnings:     warnings,
+		Colors:       colors,
+		ContextLines: DefaultContextLines,
+		MaxWidth:     DefaultMaxWidth,
+	})
+}
+
+// JSON creates a new emitter that writes all the diagnostics to the given
+// writer as JSON once it is flushed, in the same shape as the reports of
+// `elm-make --report=json`. The diagnostics of every file are written in an
+// array in a line of their own. If there are no diagnostics, an empty array
+// is written.
+func JSON(w io.Writer) Flusher {
+	return &jsonEmitter{w: w}
+}
+
+type jsonEmitter struct {
+	w     io.Writer
+	files [][]jsonDiagnostic
+}
+
+type jsonDiagnostic struct {
+	Type     string `json:"type"`
+	Tag      string `json:"tag"`
+	Code     Code   `json:"code"`
+	File     string `json:"file"`
+	Overview string `json:"overview"`
+	// Details are the notes and hints of the diagnostic in a single text,
+	// as elm-make has them, even if they also have fields of their own.
+	Details   string       `json:"details"`
+	Region    *jsonRegion  `json:"region"`
+	Subregion *jsonRegion  `json:"subregion"`
+	Snippet   *jsonSnippet `json:"snippet"`
+	Labels    []jsonLabel  `json:"labels"`
+	Notes     []string     `json:"notes"`
+	Hints     []string     `json:"hints"`
+}
+
+// jsonLabel is a secondary region of a diagnostic.
+type jsonLabel struct {
+	Message string       `json:"message"`
+	Region  *jsonRegion  `json:"region"`
+	Snippet *jsonSnippet `json:"snippet"`
+}
+
+type jsonRegion struct {
+	Start jsonPos `json:"start"`
+	End   jsonPos `json:"end"`
+}
+
+type jsonPos struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// jsonSnippet contains the lines of code affected by a diagnostic, starting
+// at the given line.
+type jsonSnippet struct {
+	Line  int      `json:"line"`
+	Lines []string `json:"lines"`
+}
+
+func (e *jsonEmitter) Emit(file string, diagnostics []*Diagnostic) error {
+	var result = make([]jsonDiagnostic, len(diagnostics))
+	for i, d := range diagnostics {
+		result[i] = jsonDiagnostic{
+			Type:     jsonType(d.Type),
+			Tag:      strings.ToUpper(d.Type.String()),
+			Code:     d.Code,
+			File:     file,
+			Overview: d.Message,
+			Details:  d.details(),
+			Region:   newJSONRegion(d.Pos, d.End),
+			Snippet:  newJSONSnippet(d.shownSpan()),
+			Labels:   []jsonLabel{},
+			Notes:    append([]string{}, d.Notes...),
+			Hints:    append([]string{}, d.Hints...),
+		}
+
+		for _, l := range d.Labels {
+			result[i].Labels = append(result[i].Labels, jsonLabel{
+				Message: l.Message,
+				Region:  newJSONRegion(l.Pos, l.End),
+				Snippet: newJSONSnippet(l),
+			})
+		}
+	}
+
+	e.files = append(e.files, result)
+	return nil
+}
+
+func (e *jsonEmitter) Flush() error {
+	if len(e.files) == 0 {
+		e.files = append(e.files, []jsonDiagnostic{})
+	}
+
+	enc := json.NewEncoder(e.w)
+	enc.SetEscapeHTML(false)
+	for _, diagnostics := range e.files {
+		if err := enc.Encode(diagnostics); err != nil {
+			return err
+		}
+	}
+
+	e.files = nil
+	return nil
+}
+
+func newJSONRegion(start, end source.LinePos) *jsonRegion {
+	// diagnostics without position have line 0
+	if start.Line == 0 {
+		return nil
+	}
+
+	return &jsonRegion{
+		jsonPos{start.Line, start.Col},
+		jsonPos{end.Line, end.Col},
+	}
+}
+
+// newJSONSnippet returns the whole lines of code of the given span, which
+// are the same lines underlined by the human readable emitter.
+func newJSONSnippet(span *Span) *jsonSnippet {
+	ctx := span.Context
+	if span.Region == nil || ctx == nil {
+		return nil
+	}
+
+	from, to := span.Start.Line, span.End.Line
+	// the end of a region is exclusive, so a region that ends at the start
+	// of a line does not include that line
+	if to > from && span.End.Col <= 1 {
+		to--
+	}
+
+	var lines = []string{}
+	for i, l := range ctx.Lines {
+		if n := ctx.Start + i; n >= from && n <= to {
+			lines = append(lines, l)
+		}
+	}
+	return &jsonSnippet{from, lines}
+}
+
+// jsonType returns the type of a diagnostic used by Elm, which only has
+// errors and warnings.
+func jsonType(typ ReportType) string {
+	switch typ {
+	case Warning, Info:
+		return "warning"
+	default:
+		return "error"
+	}
 }
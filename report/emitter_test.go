@@ -0,0 +1,252 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/elm-tangram/tangram/source"
+	"github.com/elm-tangram/tangram/token"
+	"github.com/stretchr/testify/require"
+)
+
+// emitRegion emits an error at the region between the first occurrence of
+// start and the end of the first occurrence of end after it in the given
+// source code, and returns the lines of code printed.
+func emitRegion(t *testing.T, src, start, end string, opts WriterOptions) string {
+	from := strings.Index(src, start)
+	to := from + strings.Index(src[from:], end) + len(end)
+	require.True(t, from >= 0 && to >= from, "region not found")
+
+	return emit(t, src, NewBaseReport(
+		SyntaxError,
+		token.Pos(from),
+		"message",
+		&Region{token.Pos(from), token.Pos(to)},
+	), opts)
+}
+
+// emit emits the given report, whose message must be "message", in the
+// given source code, and returns the lines of code printed.
+func emit(t *testing.T, src string, report Report, opts WriterOptions) string {
+	loader := source.NewMemLoader()
+	loader.Add("test", src)
+	cm := source.NewCodeMap(loader)
+	require.NoError(t, cm.Add("test"))
+
+	var buf bytes.Buffer
+	r := NewReporter(cm, Writer(&buf, opts))
+	r.Report("test", report)
+	require.NoError(t, r.Emit())
+
+	out := buf.String()
+	i := strings.Index(out, "message\n")
+	j := strings.LastIndex(out, "\nat test:")
+	require.True(t, i >= 0 && j > i, "unexpected output: %s", out)
+	return out[i+len("message\n") : j]
+}
+
+func TestWriterEmitterRegion(t *testing.T) {
+	const src = "module Foo exposing (..)\n\nfoo =\n    bar baz\n        qux\n\nmain = foo\n"
+
+	cases := []struct {
+		name       string
+		start, end string
+		context    int
+		expected   string
+	}{
+		{
+			"single line",
+			"bar", "baz", 0,
+			`
+4 |     bar baz
+  |     ^^^^^^^
+`,
+		},
+		{
+			"single line with context",
+			"bar", "bar", 1,
+			`
+3 | foo =
+4 |     bar baz
+  |     ^^^
+5 |         qux
+`,
+		},
+		{
+			"multiple lines",
+			"foo =", "qux", 0,
+			`
+3 | foo =
+  | ^^^^^
+4 |     bar baz
+  |     ^^^^^^^
+5 |         qux
+  |         ^^^
+`,
+		},
+		{
+			"context at the start of the file",
+			"Foo", "(..)", 2,
+			`
+1 | module Foo exposing (..)
+  |        ^^^^^^^^^^^^^^^^^
+2 | 
+3 | foo =
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out := emitRegion(t, src, c.start, c.end, WriterOptions{ContextLines: c.context})
+			require.Equal(t, c.expected, out)
+		})
+	}
+}
+
+func TestWriterEmitterRegionWidth(t *testing.T) {
+	t.Run("tabs", func(t *testing.T) {
+		out := emitRegion(t, "foo =\n\tbar baz\n", "baz", "baz", WriterOptions{})
+		require.Equal(t, "\n2 |     bar baz\n  |         ^^^\n", out)
+	})
+
+	t.Run("wide characters", func(t *testing.T) {
+		out := emitRegion(t, "foo = \"日本\" ++ bar\n", "bar", "bar", WriterOptions{})
+		require.Equal(t, "\n1 | foo = \"日本\" ++ bar\n  |                 ^^^\n", out)
+	})
+
+	t.Run("long lines", func(t *testing.T) {
+		src := "foo = " + strings.Repeat("a ", 50) + "bar" + strings.Repeat(" b", 50) + "\n"
+		out := emitRegion(t, src, "bar", "bar", WriterOptions{MaxWidth: 20})
+		require.Equal(t, "\n1 | ... a a bar b b b b b b...\n  |         ^^^\n", out)
+	})
+}
+
+func TestWriterEmitterSyntaxError(t *testing.T) {
+	const src = "module Foo exposing (..)\n\nfoo =\n    bar = baz\n\nqux = 1 ) 2\n"
+
+	cases := []struct {
+		name     string
+		start    string
+		token    string
+		typ      ReportType
+		expected string
+	}{
+		{
+			"in the middle of a line",
+			"qux", ")", SyntaxError,
+			`
+6 | qux = 1 ) 2
+  |         ^
+`,
+		},
+		{
+			"region in several lines",
+			"foo", "= baz", SyntaxError,
+			`
+3 | foo =
+4 |     bar = baz
+  |         ^
+`,
+		},
+		{
+			"other errors underline the whole region",
+			"qux", ")", NameError,
+			`
+6 | qux = 1 ) 2
+  | ^^^^^^^^^
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			from := strings.Index(src, c.start)
+			pos := from + strings.Index(src[from:], c.token)
+			out := emit(t, src, NewBaseReport(
+				c.typ,
+				token.Pos(pos),
+				"message",
+				&Region{token.Pos(from), token.Pos(pos + 1)},
+			), WriterOptions{})
+			require.Equal(t, c.expected, out)
+		})
+	}
+}
+
+func TestSARIFEmitterRegion(t *testing.T) {
+	require := require.New(t)
+	const src = "module Foo exposing (..)\n\nfoo =\n\tbar\tbaz\n\nmain = foo\n"
+
+	loader := source.NewMemLoader()
+	loader.Add("test", src)
+	cm := source.NewCodeMap(loader)
+	require.NoError(cm.Add("test"))
+
+	from := strings.Index(src, "foo =")
+	pos := strings.Index(src, "bar")
+	to := strings.Index(src, "baz") + len("baz")
+
+	var buf bytes.Buffer
+	r := NewReporter(cm, SARIF(&buf))
+	r.Report("test", NewBaseReport(
+		SyntaxError,
+		token.Pos(pos),
+		"message",
+		&Region{token.Pos(from), token.Pos(to)},
+	))
+	require.NoError(r.Emit())
+
+	var log sarifLog
+	require.NoError(json.Unmarshal(buf.Bytes(), &log), buf.String())
+	require.Len(log.Runs, 1)
+	require.Len(log.Runs[0].Results, 1)
+
+	// columns are counted in characters, so tabs are a single column, and
+	// the context has the lines as they are in the file
+	loc := log.Runs[0].Results[0].Locations[0].PhysicalLocation
+	require.Equal(&sarifRegion{StartLine: 4, StartColumn: 2, EndLine: 4, EndColumn: 9}, loc.Region)
+	require.Equal(&sarifRegion{
+		StartLine: 3,
+		EndLine:   4,
+		Snippet:   &sarifMessage{"foo =\n\tbar\tbaz"},
+	}, loc.ContextRegion)
+}
+
+func TestJSONEmitterSnippet(t *testing.T) {
+	require := require.New(t)
+	const src = "module Foo exposing (..)\n\nfoo =\n    bar = baz\n\nmain = foo\n"
+
+	loader := source.NewMemLoader()
+	loader.Add("test", src)
+	cm := source.NewCodeMap(loader)
+	require.NoError(cm.Add("test"))
+
+	from := strings.Index(src, "foo =")
+	pos := strings.Index(src, "= baz")
+	report := NewBaseReport(
+		SyntaxError,
+		token.Pos(pos),
+		"message",
+		&Region{token.Pos(from), token.Pos(pos + 1)},
+	)
+	report.AddNote("a note")
+	report.AddHint("a hint")
+
+	var buf bytes.Buffer
+	r := NewReporter(cm, JSON(&buf))
+	r.Report("test", report)
+	require.NoError(r.Emit())
+
+	var diagnostics []jsonDiagnostic
+	require.NoError(json.Unmarshal(buf.Bytes(), &diagnostics), buf.String())
+	require.Len(diagnostics, 1)
+
+	// the snippet of a syntax error only has the lines from the unexpected
+	// token, as the human readable output
+	d := diagnostics[0]
+	require.Equal(&jsonSnippet{4, []string{"    bar = baz"}}, d.Snippet)
+	require.Equal("Note: a note\n\nHint: a hint", d.Details)
+}
@@ -17,7 +17,7 @@ type UndefinedError struct {
 
 func NewUndefinedError(expr ast.Node, name *ast.Ident) *UndefinedError {
 	return &UndefinedError{
-		NewBaseReport(NameError, name.Pos(), "", RegionFromNode(expr)),
+		newReport(codeUndefined, NameError, name.Pos(), "", RegionFromNode(expr)),
 		name.Name,
 	}
 }
@@ -33,7 +33,7 @@ type ModuleNotImportedError struct {
 
 func NewModuleNotImportedError(expr ast.Node, name string) *ModuleNotImportedError {
 	return &ModuleNotImportedError{
-		NewBaseReport(NameError, expr.Pos(), "", RegionFromNode(expr)),
+		newReport(codeModuleNotImported, NameError, expr.Pos(), "", RegionFromNode(expr)),
 		name,
 	}
 }
@@ -50,12 +50,16 @@ type ImportError struct {
 
 func NewImportError(decl ast.Node, module string, name *ast.Ident) *ImportError {
 	return &ImportError{
-		NewBaseReport(NameError, name.Pos(), "", RegionFromNode(decl)),
+		newReport(codeImport, NameError, name.Pos(), "", RegionFromNode(decl)),
 		module,
 		name.Name,
 	}
 }
 
+func (e *ImportError) Message() string {
+	return fmt.Sprintf("I could not find %q in module %q.", e.Name, e.Module)
+}
+
 type ExportError struct {
 	BaseReport
 	Module string
@@ -64,7 +68,7 @@ type ExportError struct {
 
 func NewExportError(decl *ast.ModuleDecl, name *ast.Ident) *ExportError {
 	return &ExportError{
-		NewBaseReport(NameError, name.Pos(), "", RegionFromNode(decl)),
+		newReport(codeExport, NameError, name.Pos(), "", RegionFromNode(decl)),
 		decl.ModuleName(),
 		name.Name,
 	}
@@ -82,7 +86,7 @@ type ExpectedUnionError struct {
 
 func NewExpectedUnionError(decl ast.Decl, obj *ast.Object) *ExpectedUnionError {
 	return &ExpectedUnionError{
-		NewBaseReport(NameError, obj.Node.Pos(), "", RegionFromNode(decl)),
+		newReport(codeExpectedUnion, NameError, obj.Node.Pos(), "", RegionFromNode(decl)),
 		obj.Name,
 		obj.Kind,
 	}
@@ -100,7 +104,7 @@ type ExpectedCtorError struct {
 
 func NewExpectedCtorError(decl ast.Decl, obj *ast.Object) *ExpectedCtorError {
 	return &ExpectedCtorError{
-		NewBaseReport(NameError, obj.Node.Pos(), "", RegionFromNode(decl)),
+		newReport(codeExpectedCtor, NameError, obj.Node.Pos(), "", RegionFromNode(decl)),
 		obj.Name,
 		obj.Kind,
 	}
@@ -115,11 +119,13 @@ type RepeatedFieldError struct {
 	Field string
 }
 
-func NewRepeatedFieldError(record ast.Node, field *ast.Ident) *RepeatedFieldError {
-	return &RepeatedFieldError{
-		NewBaseReport(NameError, field.Pos(), "", RegionFromNode(record)),
+func NewRepeatedFieldError(record ast.Node, field, first *ast.Ident) *RepeatedFieldError {
+	e := &RepeatedFieldError{
+		newReport(codeRepeatedField, NameError, field.Pos(), "", RegionFromNode(record)),
 		field.Name,
 	}
+	e.AddLabel(RegionFromNode(first), fmt.Sprintf("The field %q was first used here:", field.Name))
+	return e
 }
 
 func (e *RepeatedFieldError) Message() string {
@@ -131,11 +137,13 @@ type AlreadyDeclaredError struct {
 	Name string
 }
 
-func NewAlreadyDeclaredError(decl ast.Decl, name *ast.Ident) *AlreadyDeclaredError {
-	return &AlreadyDeclaredError{
-		NewBaseReport(NameError, name.Pos(), "", RegionFromNode(decl)),
+func NewAlreadyDeclaredError(decl ast.Decl, name *ast.Ident, original ast.Node) *AlreadyDeclaredError {
+	e := &AlreadyDeclaredError{
+		newReport(codeAlreadyDeclared, NameError, name.Pos(), "", RegionFromNode(decl)),
 		name.Name,
 	}
+	e.AddLabel(RegionFromNode(original), fmt.Sprintf("The name %q was first declared here:", name.Name))
+	return e
 }
 
 func (e *AlreadyDeclaredError) Message() string {
@@ -147,11 +155,13 @@ type RepeatedVarTypeError struct {
 	Var string
 }
 
-func NewRepeatedVarTypeError(decl ast.Decl, name *ast.Ident) *RepeatedVarTypeError {
-	return &RepeatedVarTypeError{
-		NewBaseReport(NameError, name.Pos(), "", RegionFromNode(decl)),
+func NewRepeatedVarTypeError(decl ast.Decl, name, first *ast.Ident) *RepeatedVarTypeError {
+	e := &RepeatedVarTypeError{
+		newReport(codeRepeatedVarType, NameError, name.Pos(), "", RegionFromNode(decl)),
 		name.Name,
 	}
+	e.AddLabel(RegionFromNode(first), fmt.Sprintf("The variable type %q was first declared here:", name.Name))
+	return e
 }
 
 func (e RepeatedVarTypeError) Message() string {
@@ -163,15 +173,17 @@ type RepeatedCtorError struct {
 	Ctor string
 }
 
-func NewRepeatedCtorError(decl ast.Decl, name *ast.Ident) *RepeatedCtorError {
-	return &RepeatedCtorError{
-		NewBaseReport(NameError, name.Pos(), "", RegionFromNode(decl)),
+func NewRepeatedCtorError(decl ast.Decl, name, first *ast.Ident) *RepeatedCtorError {
+	e := &RepeatedCtorError{
+		newReport(codeRepeatedCtor, NameError, name.Pos(), "", RegionFromNode(decl)),
 		name.Name,
 	}
+	e.AddLabel(RegionFromNode(first), fmt.Sprintf("The constructor %q was first declared here:", name.Name))
+	return e
 }
 
 func (e RepeatedCtorError) Message() string {
-	return fmt.Sprintf("I found a repeated constructor in the same type union declaration. Constructor names must be unique.", e.Ctor)
+	return fmt.Sprintf("I found a repeated constructor %q in the same type union declaration. Constructor names must be unique.", e.Ctor)
 }
 
 type UnresolvedNameError struct {
@@ -181,7 +193,7 @@ type UnresolvedNameError struct {
 
 func NewUnresolvedNameError(name string, node *ast.Ident) *UnresolvedNameError {
 	return &UnresolvedNameError{
-		NewBaseReport(NameError, node.Pos(), "", nil),
+		newReport(codeUnresolvedName, NameError, node.Pos(), "", nil),
 		name,
 	}
 }
@@ -190,10 +202,281 @@ func (e *UnresolvedNameError) Message() string {
 	return fmt.Sprintf("I could not find any definition for %q.", e.Name)
 }
 
+type AmbiguousOperatorError struct {
+	BaseReport
+	Op      string
+	Modules []string
+}
+
+func NewAmbiguousOperatorError(op *ast.Ident, modules []string) *AmbiguousOperatorError {
+	return &AmbiguousOperatorError{
+		newReport(codeAmbiguousOperator, NameError, op.Pos(), "", RegionFromNode(op)),
+		op.Name,
+		modules,
+	}
+}
+
+func (e *AmbiguousOperatorError) Message() string {
+	return fmt.Sprintf("The operator %q is ambiguous, it is exposed by modules %s. Consider exposing it from only one of them.", e.Op, strings.Join(e.Modules, ", "))
+}
+
+type CircularImportError struct {
+	BaseReport
+	Module string
+	Cycle  []string
+}
+
+func NewCircularImportError(imp *ast.ImportDecl, cycle []string) *CircularImportError {
+	return &CircularImportError{
+		newReport(codeCircularImport, NameError, imp.Pos(), "", RegionFromNode(imp)),
+		imp.ModuleName(),
+		cycle,
+	}
+}
+
+func (e *CircularImportError) Message() string {
+	return fmt.Sprintf("I found a circular dependency in your code. Importing %q here forms this cycle of modules importing each other:\n\n    %s", e.Module, strings.Join(e.Cycle, " -> "))
+}
+
+type ModuleNameError struct {
+	BaseReport
+	Module   string
+	Expected string
+}
+
+func NewModuleNameError(decl *ast.ModuleDecl, expected string) *ModuleNameError {
+	return &ModuleNameError{
+		newReport(codeModuleName, NameError, decl.Name.Pos(), "", RegionFromNode(decl.Name)),
+		decl.ModuleName(),
+		expected,
+	}
+}
+
+func (e *ModuleNameError) Message() string {
+	return fmt.Sprintf("The module is named %q, but its file path says it should be named %q. The name of a module must match its path relative to the source directory it is in, so either rename the module or move its file.", e.Module, e.Expected)
+}
+
+type ModuleNotExposedError struct {
+	BaseReport
+	Module  string
+	Package string
+}
+
+func NewModuleNotExposedError(imp *ast.ImportDecl, pkg string) *ModuleNotExposedError {
+	return &ModuleNotExposedError{
+		newReport(codeModuleNotExposed, NameError, imp.Pos(), "", RegionFromNode(imp)),
+		imp.ModuleName(),
+		pkg,
+	}
+}
+
+func (e *ModuleNotExposedError) Message() string {
+	return fmt.Sprintf("I found module %q in package %s, but it can not be imported because the package does not expose it. Only the modules listed in the exposed-modules of a package can be imported from other packages.", e.Module, e.Package)
+}
+
+type AmbiguousModuleError struct {
+	BaseReport
+	Module   string
+	Packages []string
+}
+
+func NewAmbiguousModuleError(imp *ast.ImportDecl, packages []string) *AmbiguousModuleError {
+	return &AmbiguousModuleError{
+		newReport(codeAmbiguousModule, NameError, imp.Pos(), "", RegionFromNode(imp)),
+		imp.ModuleName(),
+		packages,
+	}
+}
+
+func (e *AmbiguousModuleError) Message() string {
+	return fmt.Sprintf("The module %q is ambiguous, it is exposed by packages %s. A module can only be imported if exactly one of your dependencies exposes it.", e.Module, strings.Join(e.Packages, ", "))
+}
+
+type ModuleConflictError struct {
+	BaseReport
+	Module string
+	Files  []string
+}
+
+func NewModuleConflictError(imp *ast.ImportDecl, files []string) *ModuleConflictError {
+	return &ModuleConflictError{
+		newReport(codeModuleConflict, NameError, imp.Pos(), "", RegionFromNode(imp)),
+		imp.ModuleName(),
+		files,
+	}
+}
+
+func (e *ModuleConflictError) Message() string {
+	return fmt.Sprintf("The module %q imported here is a different module than the one with the same name imported by other modules. The modules named %q are in these files:\n\n    %s\n\nModules of different packages with the same name can not be used in the same program, so rename one of them or stop importing it.", e.Module, e.Module, strings.Join(e.Files, "\n    "))
+}
+
+type PortError struct {
+	BaseReport
+	Module string
+	Name   string
+}
+
+func NewPortError(mod *ast.ModuleDecl, decl *ast.PortDecl) *PortError {
+	return &PortError{
+		newReport(codePort, SyntaxError, decl.Name.Pos(), "", RegionFromNode(decl)),
+		mod.ModuleName(),
+		decl.Name.Name,
+	}
+}
+
+func (e *PortError) Message() string {
+	return fmt.Sprintf("I found the port %q in module %q, but it is not a port module. Ports can only be declared in modules starting with `port module`.", e.Name, e.Module)
+}
+
+type NativeModuleError struct {
+	BaseReport
+	Module string
+	Reason string
+}
+
+func NewNativeModuleError(imp *ast.ImportDecl, module, reason string) *NativeModuleError {
+	return &NativeModuleError{
+		newReport(codeNativeModule, NameError, imp.Pos(), "", RegionFromNode(imp)),
+		module,
+		reason,
+	}
+}
+
+func (e *NativeModuleError) Message() string {
+	return fmt.Sprintf("I could not load native module %q. %s", e.Module, e.Reason)
+}
+
+// Type errors
+
+type TypeMismatchError struct {
+	BaseReport
+	Expected string
+	Actual   string
+}
+
+func NewTypeMismatchError(node ast.Node, expected, actual string) *TypeMismatchError {
+	return &TypeMismatchError{
+		newReport(codeTypeMismatch, TypeError, node.Pos(), "", RegionFromNode(node)),
+		expected,
+		actual,
+	}
+}
+
+func (e *TypeMismatchError) Message() string {
+	return fmt.Sprintf("I was expecting this to be of type %q, but it is of type %q instead.", e.Expected, e.Actual)
+}
+
+type AnnotationMismatchError struct {
+	BaseReport
+	Name       string
+	Annotation string
+	Inferred   string
+}
+
+func NewAnnotationMismatchError(decl *ast.Definition, annotation, inferred string) *AnnotationMismatchError {
+	return &AnnotationMismatchError{
+		newReport(codeAnnotationMismatch, TypeError, decl.Name.Pos(), "", RegionFromNode(decl.Annotation)),
+		decl.Name.Name,
+		annotation,
+		inferred,
+	}
+}
+
+func (e *AnnotationMismatchError) Message() string {
+	return fmt.Sprintf("The type annotation of %q says it is of type %q, but I inferred that it is of type %q.", e.Name, e.Annotation, e.Inferred)
+}
+
+type InfiniteTypeError struct {
+	BaseReport
+	Var      string
+	Infinite string
+}
+
+func NewInfiniteTypeError(node ast.Node, v, infinite string) *InfiniteTypeError {
+	return &InfiniteTypeError{
+		newReport(codeInfiniteType, TypeError, node.Pos(), "", RegionFromNode(node)),
+		v,
+		infinite,
+	}
+}
+
+func (e *InfiniteTypeError) Message() string {
+	return fmt.Sprintf("I cannot construct an infinite type. The type variable %q would need to be %q, which contains itself.", e.Var, e.Infinite)
+}
+
+type CtorArgsError struct {
+	BaseReport
+	Ctor     string
+	Expected int
+	Actual   int
+}
+
+func NewCtorArgsError(pattern *ast.CtorPattern, ctor string, expected int) *CtorArgsError {
+	return &CtorArgsError{
+		newReport(codeCtorArgs, TypeError, pattern.Pos(), "", RegionFromNode(pattern)),
+		ctor,
+		expected,
+		len(pattern.Args),
+	}
+}
+
+func (e *CtorArgsError) Message() string {
+	return fmt.Sprintf("Constructor %q expects %d arguments, but this pattern has %d.", e.Ctor, e.Expected, e.Actual)
+}
+
+type MissingPatternsError struct {
+	BaseReport
+	Example string
+}
+
+func NewMissingPatternsError(expr *ast.CaseExpr, example string) *MissingPatternsError {
+	return &MissingPatternsError{
+		newReport(codeMissingPatterns, TypeError, expr.Pos(), "", RegionFromNode(expr)),
+		example,
+	}
+}
+
+func (e *MissingPatternsError) Message() string {
+	return fmt.Sprintf("This case expression does not have branches for all the possible values. For example, this value is not matched by any branch:\n\n    %s\n\nAdd the branches needed or a branch with `_` to match the rest of values.", e.Example)
+}
+
+type UntypedNativeError struct {
+	BaseReport
+	Name string
+}
+
+func NewUntypedNativeError(ident *ast.Ident) *UntypedNativeError {
+	return &UntypedNativeError{
+		newReport(codeUntypedNative, TypeError, ident.Pos(), "", RegionFromNode(ident)),
+		ident.Name,
+	}
+}
+
+func (e *UntypedNativeError) Message() string {
+	return fmt.Sprintf("The native function %q takes or returns values of any type, so I cannot know its type. It can only be used as the whole body of a definition with a type annotation.", e.Name)
+}
+
+// Warnings
+
+type RedundantPatternWarning struct {
+	BaseReport
+}
+
+func NewRedundantPatternWarning(pattern ast.Pattern) *RedundantPatternWarning {
+	return &RedundantPatternWarning{
+		newReport(codeRedundantPattern, Warning, pattern.Pos(), "", RegionFromNode(pattern)),
+	}
+}
+
+func (e *RedundantPatternWarning) Message() string {
+	return "This branch will never be matched, because the previous branches already match all the values it matches."
+}
+
 // Parse errors
 
 func NewExpectedTypeError(pos token.Pos, region *Region) Report {
-	return NewBaseReport(
+	return newReport(
+		codeExpectedType,
 		SyntaxError,
 		pos,
 		"I was expecting a type, but I encountered what looks like a declaration instead.",
@@ -202,7 +485,8 @@ func NewExpectedTypeError(pos token.Pos, region *Region) Report {
 }
 
 func NewUnexpectedEOFError(pos token.Pos, region *Region) Report {
-	return NewBaseReport(
+	return newReport(
+		codeUnexpectedEOF,
 		SyntaxError,
 		pos,
 		"Unexpected end of file.",
@@ -218,7 +502,7 @@ type UnexpectedTokenError struct {
 
 func NewUnexpectedTokenError(tok *token.Token, region *Region, expected ...token.Type) *UnexpectedTokenError {
 	return &UnexpectedTokenError{
-		NewBaseReport(SyntaxError, tok.Offset, "", region),
+		newReport(codeUnexpectedToken, SyntaxError, tok.Offset, "", region),
 		tok,
 		expected,
 	}
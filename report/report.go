@@ -2,6 +2,10 @@ package report
 
 import (
 	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/elm-tangram/tangram/ast"
 	"github.com/elm-tangram/tangram/source"
@@ -37,6 +41,24 @@ func (t ReportType) String() string {
 	}
 }
 
+// kind returns the name of the report type as a Go identifier.
+func (t ReportType) kind() string {
+	switch t {
+	case SyntaxError:
+		return "SyntaxError"
+	case NameError:
+		return "NameError"
+	case TypeError:
+		return "TypeError"
+	case Info:
+		return "Info"
+	case Warning:
+		return "Warning"
+	default:
+		return "OtherError"
+	}
+}
+
 func (t ReportType) Color() func(string, ...interface{}) string {
 	switch t {
 	case Info:
@@ -50,36 +72,156 @@ func (t ReportType) Color() func(string, ...interface{}) string {
 
 type Report interface {
 	Type() ReportType
+	// Code is the stable code of the kind of report.
+	Code() Code
 	Message() string
 	Pos() token.Pos
 	Region() *Region
+	// Labels are the secondary regions of code related to the report.
+	Labels() []Label
+	// Notes are additional explanations of the report.
+	Notes() []string
+	// Hints are suggestions to fix the problem reported, such as names
+	// similar to one that could not be found.
+	Hints() []string
+}
+
+// Label is a secondary region of code of a report, with a message that
+// explains how it is related to the report.
+type Label struct {
+	Region  *Region
+	Message string
 }
 
 type BaseReport struct {
 	typ    ReportType
+	code   Code
 	pos    token.Pos
 	msg    string
 	region *Region
+	labels []Label
+	notes  []string
+	hints  []string
 }
 
 func NewBaseReport(typ ReportType, pos token.Pos, msg string, region *Region) BaseReport {
-	return BaseReport{typ, pos, msg, region}
+	return newReport(typ.code(), typ, pos, msg, region)
+}
+
+func newReport(code Code, typ ReportType, pos token.Pos, msg string, region *Region) BaseReport {
+	return BaseReport{typ: typ, code: code, pos: pos, msg: msg, region: region}
 }
 
 func (r BaseReport) Type() ReportType { return r.typ }
+func (r BaseReport) Code() Code       { return r.code }
 func (r BaseReport) Message() string  { return r.msg }
 func (r BaseReport) Pos() token.Pos   { return r.pos }
 func (r BaseReport) Region() *Region  { return r.region }
+func (r BaseReport) Labels() []Label  { return r.labels }
+func (r BaseReport) Notes() []string  { return r.notes }
+func (r BaseReport) Hints() []string  { return r.hints }
+
+// AddLabel adds a secondary region of code to the report.
+func (r *BaseReport) AddLabel(region *Region, msg string) {
+	r.labels = append(r.labels, Label{region, msg})
+}
+
+// AddNote adds a note to the report.
+func (r *BaseReport) AddNote(note string) {
+	r.notes = append(r.notes, note)
+}
+
+// AddHint adds a hint to the report.
+func (r *BaseReport) AddHint(hint string) {
+	r.hints = append(r.hints, hint)
+}
+
+// AddSuggestions adds a hint to the report suggesting the given names, which
+// are similar to a name that could not be found, from the most to the least
+// similar. Nothing is added if there are no names.
+func (r *BaseReport) AddSuggestions(names []string) {
+	if len(names) == 0 {
+		return
+	}
+
+	if len(names) == 1 {
+		r.AddHint(fmt.Sprintf("Did you mean %q?", names[0]))
+		return
+	}
+
+	var quoted = make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = strconv.Quote(n)
+	}
+	r.AddHint(fmt.Sprintf("Did you mean one of these? %s", strings.Join(quoted, ", ")))
+}
+
+// kindOf returns the kind of the given report.
+func kindOf(report Report) string {
+	t := reflect.TypeOf(report)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == reflect.TypeOf(BaseReport{}) || t.Name() == "" {
+		return report.Type().kind()
+	}
+	return t.Name()
+}
 
 func AsError(report Report) error {
 	return errors.New(report.Message())
 }
 
 type Diagnostic struct {
-	Type    ReportType
+	Type ReportType
+	// Kind is the kind of the report, which is the name of its type, such as
+	// "UndefinedError", or the name of its report type, such as
+	// "SyntaxError", for reports without a type of their own.
+	Kind    string
+	Message string
+	Pos     source.LinePos
+	// Start is the position where the region of the report starts, which
+	// is the same as Pos if the report has no region.
+	Start source.LinePos
+	// End is the position where the region of the report ends, which is
+	// the same as Pos if the report has no region.
+	End    source.LinePos
+	Region *source.Snippet
+	// Context are the whole lines of code of the region, along with the
+	// lines around it the emitter wants to show.
+	Context *source.Snippet
+	// Text are the whole lines of code of the region as they are in the
+	// source, without their tabs replaced.
+	Text   *source.Snippet
+	Code   Code
+	Labels []*Span
+	Notes  []string
+	Hints  []string
+}
+
+// span returns the main region of code of the diagnostic.
+func (d *Diagnostic) span() *Span {
+	return &Span{
+		Message: d.Message,
+		Pos:     d.Pos,
+		Start:   d.Start,
+		End:     d.End,
+		Region:  d.Region,
+		Context: d.Context,
+		Text:    d.Text,
+	}
+}
+
+// Span is a secondary region of code of a diagnostic.
+type Span struct {
 	Message string
 	Pos     source.LinePos
+	Start   source.LinePos
+	End     source.LinePos
 	Region  *source.Snippet
+	Context *source.Snippet
+	Text    *source.Snippet
 }
 
 type Region struct {
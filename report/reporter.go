@@ -1,35 +1,74 @@
 package report
 
 import (
+	"sort"
+	"sync"
+
 	"github.com/elm-tangram/tangram/source"
 	"github.com/elm-tangram/tangram/token"
 )
 
 // Reporter is in charge of reporting the diagnostics occurred during any of
-// the compilation steps to the user.
+// the compilation steps to the user. It is safe to use it from multiple
+// goroutines.
 type Reporter struct {
 	cm      *source.CodeMap
 	emitter Emitter
+	mut     sync.RWMutex
 	reports map[string][]Report
 }
 
 // NewReporter creates a new reporter.
 func NewReporter(cm *source.CodeMap, emitter Emitter) *Reporter {
-	return &Reporter{cm, emitter, make(map[string][]Report)}
+	return &Reporter{cm: cm, emitter: emitter, reports: make(map[string][]Report)}
 }
 
 // IsOK returns true if there are no diagnostics yet.
 func (r *Reporter) IsOK() bool {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
 	return len(r.reports) == 0
 }
 
+// HasErrors reports whether any of the reports is not a warning.
+func (r *Reporter) HasErrors() bool {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	for _, reports := range r.reports {
+		for _, report := range reports {
+			if report.Type() != Warning {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Reports returns the reports occurred at the given path.
 func (r *Reporter) Reports(path string) []Report {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
 	return r.reports[path]
 }
 
-// Emit writes all the reports using the reporter's emitter.
+// Paths returns the sorted paths of all the files with reports.
+func (r *Reporter) Paths() []string {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	var paths = make([]string, 0, len(r.reports))
+	for path := range r.reports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Emit writes all the reports using the reporter's emitter, sorted by path.
+// If the emitter is a Flusher, it is flushed after all the reports have
+// been emitted.
 func (r *Reporter) Emit() error {
-	for file, reports := range r.reports {
+	for _, file := range r.Paths() {
+		reports := r.Reports(file)
 		var ds = make([]*Diagnostic, 0, len(reports))
 		for _, report := range reports {
 			d, err := r.makeDiagnostic(file, report)
@@ -44,43 +83,116 @@ func (r *Reporter) Emit() error {
 			return err
 		}
 	}
+
+	if f, ok := r.emitter.(Flusher); ok {
+		return f.Flush()
+	}
 	return nil
 }
 
 // Report adds a new report occurred at some path.
 func (r *Reporter) Report(path string, report Report) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
 	r.reports[path] = append(r.reports[path], report)
 }
 
 // makeDiagnostic transforms a report into a diagnostic, with the affected
-// snippet of code, if there is any.
+// snippets of code, if there are any.
 func (r *Reporter) makeDiagnostic(path string, report Report) (*Diagnostic, error) {
+	d := &Diagnostic{
+		Type:    report.Type(),
+		Kind:    kindOf(report),
+		Code:    report.Code(),
+		Message: report.Message(),
+		Notes:   report.Notes(),
+		Hints:   report.Hints(),
+	}
+
 	if report.Pos() == token.NoPos {
-		return &Diagnostic{
-			Type:    report.Type(),
-			Message: report.Message(),
-		}, nil
+		return d, nil
 	}
 
 	src := r.cm.Source(path)
-	pos, err := src.LinePos(report.Pos())
+	span, err := r.makeSpan(src, report.Pos(), report.Region())
+	if err != nil {
+		return nil, err
+	}
+	d.Pos, d.Start, d.End = span.Pos, span.Start, span.End
+	d.Region, d.Context, d.Text = span.Region, span.Context, span.Text
+
+	for _, l := range report.Labels() {
+		if l.Region == nil {
+			d.Labels = append(d.Labels, &Span{Message: l.Message})
+			continue
+		}
+
+		span, err := r.makeSpan(src, l.Region.Start, l.Region)
+		if err != nil {
+			return nil, err
+		}
+		span.Message = l.Message
+		d.Labels = append(d.Labels, span)
+	}
+
+	return d, nil
+}
+
+// makeSpan returns the span of code of the given region that starts being
+// relevant at the given position.
+func (r *Reporter) makeSpan(src *source.Source, pos token.Pos, region *Region) (*Span, error) {
+	start, err := src.LinePos(pos)
 	if err != nil {
 		return nil, err
 	}
-	region := report.Region()
 
-	var snippet *source.Snippet
+	span := &Span{Pos: start, Start: start, End: start}
 	if region != nil {
-		snippet, err = src.Region(region.Start, region.End)
+		span.Region, err = src.Region(region.Start, region.End)
+		if err != nil {
+			return nil, err
+		}
+
+		span.Start, err = src.LinePos(region.Start)
+		if err != nil {
+			return nil, err
+		}
+
+		span.End, err = src.LinePos(region.End)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	return &Diagnostic{
-		Type:    report.Type(),
-		Message: report.Message(),
-		Pos:     pos,
-		Region:  snippet,
-	}, nil
+	var n int
+	if e, ok := r.emitter.(contextEmitter); ok {
+		n = e.contextLines()
+	}
+
+	from := span.Start.Line - n
+	if from < 1 {
+		from = 1
+	}
+
+	lines, err := src.Lines(from, span.End.Line+n)
+	if err != nil {
+		return nil, err
+	}
+	span.Context = &source.Snippet{Start: from, Lines: lines}
+
+	lines, err = src.Text(span.Start.Line, span.End.Line)
+	if err != nil {
+		return nil, err
+	}
+	span.Text = &source.Snippet{Start: span.Start.Line, Lines: lines}
+	return span, nil
+}
+
+// contextEmitter is an emitter that shows some lines of code around the
+// regions of the diagnostics.
+type contextEmitter interface {
+	Emitter
+	// contextLines returns the number of lines shown before and after a
+	// region.
+	contextLines() int
 }
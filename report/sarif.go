@@ -0,0 +1,251 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/elm-tangram/tangram/source"
+)
+
+// SARIF creates a new emitter that writes all the diagnostics of a run to
+// the given writer as a single SARIF 2.1.0 log once it is flushed. Every
+// kind of report is a rule of the log, identified by the kind.
+func SARIF(w io.Writer) Flusher {
+	return &sarifEmitter{w: w}
+}
+
+type sarifEmitter struct {
+	w       io.Writer
+	results []sarifResult
+}
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolName     = "tangram"
+	toolURI      = "https://github.com/elm-tangram/tangram"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool     `json:"tool"`
+	ColumnKind string        `json:"columnKind"`
+	Results    []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID         string          `json:"id"`
+	Properties sarifProperties `json:"properties"`
+}
+
+type sarifProperties struct {
+	Code Code `json:"code"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+	// RelatedLocations are the labels of the diagnostic.
+	RelatedLocations []sarifLocation `json:"relatedLocations,omitempty"`
+	code             Code
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	ID               int                   `json:"id,omitempty"`
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	Message          *sarifMessage         `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+	ContextRegion    *sarifRegion          `json:"contextRegion,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int           `json:"startLine"`
+	StartColumn int           `json:"startColumn,omitempty"`
+	EndLine     int           `json:"endLine,omitempty"`
+	EndColumn   int           `json:"endColumn,omitempty"`
+	Snippet     *sarifMessage `json:"snippet,omitempty"`
+}
+
+func (e *sarifEmitter) Emit(file string, diagnostics []*Diagnostic) error {
+	for _, d := range diagnostics {
+		// notes and hints do not have a place of their own in SARIF, so
+		// they are part of the message
+		msg := d.Message
+		if details := d.details(); details != "" {
+			msg += "\n\n" + details
+		}
+
+		result := sarifResult{
+			RuleID:  d.Kind,
+			Level:   sarifLevel(d.Type),
+			Message: sarifMessage{msg},
+			Locations: []sarifLocation{{
+				PhysicalLocation: newSARIFLocation(file, d.Pos, d.End, d.Text),
+			}},
+			code: d.Code,
+		}
+
+		for i, l := range d.Labels {
+			result.RelatedLocations = append(result.RelatedLocations, sarifLocation{
+				ID:               i + 1,
+				PhysicalLocation: newSARIFLocation(file, l.Pos, l.End, l.Text),
+				Message:          &sarifMessage{l.Message},
+			})
+		}
+
+		e.results = append(e.results, result)
+	}
+	return nil
+}
+
+// newSARIFLocation returns the location of the region between the given
+// positions, whose whole lines of code are the given text. The region is in
+// the context region of the text, which contains its lines as they are in
+// the file.
+func newSARIFLocation(file string, start, end source.LinePos, text *source.Snippet) sarifPhysicalLocation {
+	loc := sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{fileURI(file)},
+	}
+
+	// diagnostics without position have line 0
+	if start.Line > 0 {
+		loc.Region = &sarifRegion{
+			StartLine:   start.Line,
+			StartColumn: sarifColumn(start, text),
+			EndLine:     end.Line,
+			EndColumn:   sarifColumn(end, text),
+		}
+	}
+
+	if text != nil && len(text.Lines) > 0 {
+		loc.ContextRegion = &sarifRegion{
+			StartLine: text.Start,
+			EndLine:   text.Start + len(text.Lines) - 1,
+			Snippet:   &sarifMessage{strings.Join(text.Lines, "\n")},
+		}
+	}
+	return loc
+}
+
+// sarifColumn returns the column of the given position counted in
+// characters, as the columns of the log are, instead of counting tabs as
+// several columns.
+func sarifColumn(pos source.LinePos, text *source.Snippet) int {
+	if text == nil {
+		return pos.Col
+	}
+
+	i := pos.Line - text.Start
+	if i < 0 || i >= len(text.Lines) {
+		return pos.Col
+	}
+	return source.CharCol(text.Lines[i], pos.Col)
+}
+
+// Flush writes the log with all the diagnostics emitted. The rules are
+// sorted by their identifier, so the same diagnostics always produce the
+// same log.
+func (e *sarifEmitter) Flush() error {
+	var ids []string
+	var codes = make(map[string]Code)
+	for _, r := range e.results {
+		if _, ok := codes[r.RuleID]; !ok {
+			codes[r.RuleID] = r.code
+			ids = append(ids, r.RuleID)
+		}
+	}
+	sort.Strings(ids)
+
+	var rules = make([]sarifRule, len(ids))
+	var indexes = make(map[string]int, len(ids))
+	for i, id := range ids {
+		rules[i] = sarifRule{id, sarifProperties{codes[id]}}
+		indexes[id] = i
+	}
+
+	var results = make([]sarifResult, len(e.results))
+	for i, r := range e.results {
+		r.RuleIndex = indexes[r.RuleID]
+		results[i] = r
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{sarifDriver{
+				Name:           toolName,
+				InformationURI: toolURI,
+				Rules:          rules,
+			}},
+			// columns are counted in characters, not in UTF-16 code units
+			ColumnKind: "unicodeCodePoints",
+			Results:    results,
+		}},
+	}
+
+	e.results = nil
+	enc := json.NewEncoder(e.w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+// sarifLevel returns the SARIF level of the given report type.
+func sarifLevel(typ ReportType) string {
+	switch typ {
+	case Warning:
+		return "warning"
+	case Info:
+		return "note"
+	default:
+		return "error"
+	}
+}
+
+// fileURI returns the URI of the given file, which is relative if the path
+// of the file is relative.
+func fileURI(file string) string {
+	u := url.URL{Path: filepath.ToSlash(file)}
+	if filepath.IsAbs(file) {
+		u.Scheme = "file"
+		if !strings.HasPrefix(u.Path, "/") {
+			u.Path = "/" + u.Path
+		}
+	}
+	return u.String()
+}
@@ -0,0 +1,54 @@
+package report
+
+import "unicode"
+
+// wideRanges are the ranges of characters that take two columns in a
+// terminal, which are the East Asian wide and fullwidth characters and most
+// emojis.
+var wideRanges = []struct{ from, to rune }{
+	{0x1100, 0x115f},
+	{0x2e80, 0x303e},
+	{0x3041, 0x33ff},
+	{0x3400, 0x4dbf},
+	{0x4e00, 0x9fff},
+	{0xa000, 0xa4cf},
+	{0xac00, 0xd7a3},
+	{0xf900, 0xfaff},
+	{0xfe30, 0xfe4f},
+	{0xff00, 0xff60},
+	{0xffe0, 0xffe6},
+	{0x1f300, 0x1f64f},
+	{0x1f900, 0x1f9ff},
+	{0x20000, 0x2fffd},
+	{0x30000, 0x3fffd},
+}
+
+// runeWidth returns the number of columns the given character takes in a
+// terminal. Combining marks take no columns, since they are drawn over the
+// previous character.
+func runeWidth(r rune) int {
+	if unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) {
+		return 0
+	}
+
+	for _, rng := range wideRanges {
+		if r < rng.from {
+			break
+		}
+
+		if r <= rng.to {
+			return 2
+		}
+	}
+	return 1
+}
+
+// textWidth returns the number of columns the given text takes in a
+// terminal.
+func textWidth(text []rune) int {
+	var width int
+	for _, r := range text {
+		width += runeWidth(r)
+	}
+	return width
+}
@@ -589,6 +589,8 @@ func lexComment(l *Scanner) (stateFunc, error) {
 	}
 }
 
+// lexMultiLineComment scans a multi line comment, which is a documentation
+// comment if it starts with "{-|". The '{' delimiter has already been scanned.
 func lexMultiLineComment(l *Scanner) (stateFunc, error) {
 	for {
 		r, err := l.next()
@@ -603,7 +605,11 @@ func lexMultiLineComment(l *Scanner) (stateFunc, error) {
 			}
 
 			if nr == rightBrace {
-				l.emit(token.Comment)
+				if strings.HasPrefix(l.peekWord(), "{-|") {
+					l.emit(token.DocComment)
+				} else {
+					l.emit(token.Comment)
+				}
 				return lexExpr, nil
 			}
 		} else if isEOL(r) {
@@ -727,6 +733,7 @@ var keywords = map[string]token.Type{
 	"module":   token.Module,
 	"exposing": token.Exposing,
 	"import":   token.Import,
+	"port":     token.Port,
 	"True":     token.True,
 	"False":    token.False,
 }
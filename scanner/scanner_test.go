@@ -62,6 +62,32 @@ func TestLexRecord(t *testing.T) {
 	})
 }
 
+const testPortModule = `
+port module Foo exposing (..)
+
+port send : String -> Cmd msg
+`
+
+func TestLexPortModule(t *testing.T) {
+	testLex(t, testPortModule, []expectedToken{
+		{"port", token.Port},
+		{"module", token.Module},
+		{"Foo", token.Identifier},
+		{"exposing", token.Exposing},
+		{"(", token.LeftParen},
+		{"..", token.Range},
+		{")", token.RightParen},
+		{"port", token.Port},
+		{"send", token.Identifier},
+		{":", token.Colon},
+		{"String", token.Identifier},
+		{"->", token.Arrow},
+		{"Cmd", token.Identifier},
+		{"msg", token.Identifier},
+		{"\n", token.EOF},
+	})
+}
+
 const textFuncDecl = `
 foo : (Int -> Int) -> Int -> Int
 foo fn n =
@@ -193,15 +219,17 @@ const testMultiLineComment = `
     head [1,2,3] == Just 1
     head [] == Nothing
 -}
+{- not a doc comment -}
 `
 
 func TestMultiLineComment(t *testing.T) {
 	testLex(t, testMultiLineComment, []expectedToken{
-		{"{-|-}", token.Comment},
+		{"{-|-}", token.DocComment},
 		{`{-| Extract the first element of a list.
     head [1,2,3] == Just 1
     head [] == Nothing
--}`, token.Comment},
+-}`, token.DocComment},
+		{"{- not a doc comment -}", token.Comment},
 		{"\n", token.EOF},
 	})
 }
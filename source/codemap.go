@@ -5,27 +5,31 @@ import (
 	"bytes"
 	"io"
 	"strings"
+	"sync"
 	"unicode/utf8"
 
 	"github.com/elm-tangram/tangram/scanner"
 	"github.com/elm-tangram/tangram/token"
 )
 
-// CodeMap contains a set of source code files.
+// CodeMap contains a set of source code files. It is safe to use it from
+// multiple goroutines, but a single source must not be used by more than one
+// goroutine at the same time.
 type CodeMap struct {
 	loader Loader
+	mut    sync.RWMutex
 	files  map[string]*Source
 }
 
 // NewCodeMap returns a new code map.
 func NewCodeMap(loader Loader) *CodeMap {
-	return &CodeMap{loader, make(map[string]*Source)}
+	return &CodeMap{loader: loader, files: make(map[string]*Source)}
 }
 
 // Add includes a new file in the codemap. The path given must be a relative
 // path in the project.
 func (cm *CodeMap) Add(path string) error {
-	if _, ok := cm.files[path]; ok {
+	if cm.Source(path) != nil {
 		return nil
 	}
 
@@ -39,12 +43,41 @@ func (cm *CodeMap) Add(path string) error {
 		return err
 	}
 
+	cm.mut.Lock()
+	defer cm.mut.Unlock()
+	if _, ok := cm.files[path]; ok {
+		// the file was added by someone else in the meantime
+		if f, ok := src.(io.Closer); ok {
+			return f.Close()
+		}
+		return nil
+	}
+
 	cm.files[path] = source
 	return nil
 }
 
+// Remove removes the file at the given path from the codemap, closing it if
+// it implements io.Closer, so it is loaded again the next time it is added.
+func (cm *CodeMap) Remove(path string) error {
+	cm.mut.Lock()
+	defer cm.mut.Unlock()
+	f, ok := cm.files[path]
+	if !ok {
+		return nil
+	}
+
+	delete(cm.files, path)
+	if f, ok := f.Src.(io.Closer); ok {
+		return f.Close()
+	}
+	return nil
+}
+
 // Close closes all the source files that implement io.Closer.
 func (cm *CodeMap) Close() error {
+	cm.mut.Lock()
+	defer cm.mut.Unlock()
 	for _, f := range cm.files {
 		if f, ok := f.Src.(io.Closer); ok {
 			if err := f.Close(); err != nil {
@@ -57,6 +90,8 @@ func (cm *CodeMap) Close() error {
 
 // Source returns the source for the given path.
 func (cm *CodeMap) Source(path string) *Source {
+	cm.mut.RLock()
+	defer cm.mut.RUnlock()
 	return cm.files[path]
 }
 
@@ -102,7 +137,8 @@ func (s *Source) makeLineIndex() (err error) {
 	)
 
 	for {
-		r, _, err = reader.ReadRune()
+		var size int
+		r, size, err = reader.ReadRune()
 		if err == io.EOF {
 			err = nil
 			if start != pos {
@@ -115,7 +151,8 @@ func (s *Source) makeLineIndex() (err error) {
 			goto cleanup
 		}
 
-		pos++
+		// positions are offsets in bytes, as in the scanner
+		pos += token.Pos(size)
 		if r == '\n' || r == '\r' {
 			s.lineIndex = append(s.lineIndex, lineInfo{start, pos})
 			start = pos
@@ -224,6 +261,76 @@ func (s *Source) Region(start, end token.Pos) (*Snippet, error) {
 	return &snippet, nil
 }
 
+// Lines returns the lines of the source code between the given lines, both
+// included, with their tabs replaced. Lines are numbered from 1, and the
+// lines outside the source are ignored.
+func (s *Source) Lines(from, to int) ([]string, error) {
+	return s.lines(from, to, true)
+}
+
+// Text returns the lines of the source code between the given lines, both
+// included, as they are in the source. Lines are numbered from 1, and the
+// lines outside the source are ignored.
+func (s *Source) Text(from, to int) ([]string, error) {
+	return s.lines(from, to, false)
+}
+
+func (s *Source) lines(from, to int, replaceTabs bool) ([]string, error) {
+	if from < 1 {
+		from = 1
+	}
+
+	if to > len(s.lineIndex) {
+		to = len(s.lineIndex)
+	}
+
+	if from > to {
+		return nil, nil
+	}
+
+	start, end := s.lineIndex[from-1].start, s.lineIndex[to-1].end
+	if _, err := s.Src.Seek(int64(start), io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, io.LimitReader(s.Src, int64(end-start))); err != nil {
+		return nil, err
+	}
+
+	var (
+		content = buf.Bytes()
+		lines   = make([]string, 0, to-from+1)
+	)
+	for _, li := range s.lineIndex[from-1 : to] {
+		line := strings.TrimRight(string(content[li.start-start:li.end-start]), "\r\n")
+		if replaceTabs {
+			line = strings.Replace(line, "\t", tab, -1)
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// CharCol returns the column of the given line, counted in characters, of
+// the given column of a LinePos, which counts every tab as several columns.
+func CharCol(line string, col int) int {
+	var width, chars int
+	for _, r := range line {
+		if width >= col-1 {
+			break
+		}
+
+		if r == '\t' {
+			width += len(tab)
+		} else {
+			width++
+		}
+		chars++
+	}
+	return chars + 1
+}
+
 // Scanner returns a scanner for this source with all the tokens parsed.
 func (s *Source) Scanner() *scanner.Scanner {
 	if s.scanner == nil {
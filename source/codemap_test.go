@@ -118,3 +118,50 @@ func TestSource(t *testing.T) {
 		}
 	})
 }
+
+func TestCodeMapRemove(t *testing.T) {
+	require := require.New(t)
+	loader := NewOverlayLoader()
+	loader.Set("foo", []byte("foo = 1"))
+
+	cm := NewCodeMap(loader)
+	require.NoError(cm.Add("foo"))
+	require.NotNil(cm.Source("foo"))
+
+	require.NoError(cm.Remove("foo"))
+	require.Nil(cm.Source("foo"))
+	require.NoError(cm.Remove("foo"))
+
+	loader.Remove("foo")
+	require.Error(cm.Add("foo"))
+}
+
+func TestSourceLines(t *testing.T) {
+	require := require.New(t)
+	s, err := NewSource("foo", strings.NewReader("foo =\n\t\"ñandú\"\r\n\nbar = 1"))
+	require.NoError(err)
+
+	lines, err := s.Lines(0, 10)
+	require.NoError(err)
+	require.Equal([]string{"foo =", `    "ñandú"`, "", "", "bar = 1"}, lines)
+
+	lines, err = s.Lines(2, 2)
+	require.NoError(err)
+	require.Equal([]string{`    "ñandú"`}, lines)
+
+	lines, err = s.Lines(3, 2)
+	require.NoError(err)
+	require.Len(lines, 0)
+
+	lines, err = s.Text(1, 2)
+	require.NoError(err)
+	require.Equal([]string{"foo =", "\t\"ñandú\""}, lines)
+
+	// positions are offsets in bytes, but columns are counted in characters
+	p, err := s.LinePos(token.Pos(strings.Index("foo =\n\t\"ñandú\"", "ú") + 2))
+	require.NoError(err)
+	require.Equal(LinePos{Col: 11, Line: 2}, p)
+	require.Equal(8, CharCol(lines[1], p.Col))
+	require.Equal(1, CharCol(lines[1], 1))
+	require.Equal(2, CharCol(lines[1], 5))
+}
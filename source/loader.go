@@ -74,3 +74,46 @@ func (l *MemLoader) Load(path string) (io.ReadSeeker, error) {
 
 	return nil, os.ErrNotExist
 }
+
+// OverlayLoader is a loader that returns the content set in memory for some
+// files and loads the rest of them from the file system. It is meant for
+// editors, in which the files being edited may have not been saved yet.
+type OverlayLoader struct {
+	files map[string][]byte
+}
+
+// NewOverlayLoader returns a new overlay loader with no files in memory.
+func NewOverlayLoader() *OverlayLoader {
+	return &OverlayLoader{make(map[string][]byte)}
+}
+
+// Set sets the content of the file at the given path, which will be used
+// instead of the one in the file system.
+func (l *OverlayLoader) Set(path string, content []byte) {
+	l.files[path] = content
+}
+
+// Remove removes the content of the file at the given path, so it is loaded
+// from the file system again.
+func (l *OverlayLoader) Remove(path string) {
+	delete(l.files, path)
+}
+
+// AbsPath returns the absolute path of the given path.
+func (l *OverlayLoader) AbsPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	return abs
+}
+
+// Load retrieves the content of the given path, either from memory or from
+// the file system.
+func (l *OverlayLoader) Load(path string) (io.ReadSeeker, error) {
+	if content, ok := l.files[path]; ok {
+		return bytes.NewReader(content), nil
+	}
+
+	return os.Open(path)
+}
@@ -47,6 +47,8 @@ const (
 	EOF
 	// Comment is an user comment
 	Comment
+	// DocComment is a documentation comment "{-| ... -}"
+	DocComment
 	// LeftParen is the left parenthesis "("
 	LeftParen
 	// RightParen is the right parenthesis ")"
@@ -124,6 +126,8 @@ const (
 	Exposing
 	// Import is the "import" keyword
 	Import
+	// Port is the "port" keyword
+	Port
 	// Backslash is the "\" character
 	Backslash
 )
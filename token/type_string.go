@@ -8,6 +8,8 @@ func (t Type) String() string {
 		return "eof"
 	case Comment:
 		return "comment"
+	case DocComment:
+		return "doc comment"
 	case LeftParen:
 		return "("
 	case RightParen:
@@ -84,6 +86,8 @@ func (t Type) String() string {
 		return "exposing"
 	case Import:
 		return "import"
+	case Port:
+		return "port"
 	default:
 		return "invalid token"
 	}
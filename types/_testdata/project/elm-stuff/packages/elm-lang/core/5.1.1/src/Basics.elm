@@ -0,0 +1,100 @@
+module Basics exposing (..)
+
+import Native.Basics
+
+
+(+) : number -> number -> number
+(+) =
+    Native.Basics.add
+
+
+(-) : number -> number -> number
+(-) =
+    Native.Basics.sub
+
+
+(*) : number -> number -> number
+(*) =
+    Native.Basics.mul
+
+
+(/) : Float -> Float -> Float
+(/) =
+    Native.Basics.floatDiv
+
+
+(==) : a -> a -> Bool
+(==) =
+    Native.Basics.eq
+
+
+(<) : comparable -> comparable -> Bool
+(<) =
+    Native.Basics.lt
+
+
+(>) : comparable -> comparable -> Bool
+(>) =
+    Native.Basics.gt
+
+
+(++) : appendable -> appendable -> appendable
+(++) =
+    Native.Basics.append
+
+
+(&&) : Bool -> Bool -> Bool
+(&&) =
+    Native.Basics.and
+
+
+(||) : Bool -> Bool -> Bool
+(||) =
+    Native.Basics.or
+
+
+(|>) : a -> (a -> b) -> b
+(|>) x f =
+    f x
+
+
+(<|) : (a -> b) -> a -> b
+(<|) f x =
+    f x
+
+
+infixl 6 +
+infixl 6 -
+infixl 7 *
+infixl 7 /
+infix 4 ==
+infix 4 <
+infix 4 >
+infixr 5 ++
+infixr 3 &&
+infixr 2 ||
+infixl 0 |>
+infixr 0 <|
+
+
+not : Bool -> Bool
+not b =
+    if b then
+        False
+    else
+        True
+
+
+toFloat : Int -> Float
+toFloat =
+    Native.Basics.toFloat
+
+
+identity : a -> a
+identity x =
+    x
+
+
+always : a -> b -> a
+always a _ =
+    a
@@ -0,0 +1,6 @@
+module Debug exposing (..)
+
+
+placeholder : String
+placeholder =
+    "foo"
@@ -0,0 +1,31 @@
+module List exposing (..)
+
+import Native.List
+
+
+(::) : a -> List a -> List a
+(::) =
+    Native.List.cons
+
+
+infixr 5 ::
+
+
+map : (a -> b) -> List a -> List b
+map f list =
+    case list of
+        [] ->
+            []
+
+        x :: xs ->
+            f x :: map f xs
+
+
+foldl : (a -> b -> b) -> b -> List a -> b
+foldl f acc list =
+    case list of
+        [] ->
+            acc
+
+        x :: xs ->
+            foldl f (f x acc) xs
@@ -0,0 +1,16 @@
+module Maybe exposing (..)
+
+
+type Maybe a
+    = Just a
+    | Nothing
+
+
+withDefault : a -> Maybe a -> a
+withDefault default maybe =
+    case maybe of
+        Just value ->
+            value
+
+        Nothing ->
+            default
@@ -0,0 +1,82 @@
+package native
+
+import "elm/rt"
+
+func Add(a, b interface{}) interface{} {
+	if a, ok := a.(int); ok {
+		if b, ok := b.(int); ok {
+			return a + b
+		}
+	}
+	return toFloat(a) + toFloat(b)
+}
+
+func Sub(a, b interface{}) interface{} {
+	if a, ok := a.(int); ok {
+		if b, ok := b.(int); ok {
+			return a - b
+		}
+	}
+	return toFloat(a) - toFloat(b)
+}
+
+func Mul(a, b interface{}) interface{} {
+	if a, ok := a.(int); ok {
+		if b, ok := b.(int); ok {
+			return a * b
+		}
+	}
+	return toFloat(a) * toFloat(b)
+}
+
+func FloatDiv(a, b float64) float64 {
+	return a / b
+}
+
+func Eq(a, b interface{}) bool {
+	return rt.Eq(a, b)
+}
+
+func Lt(a, b interface{}) bool {
+	return rt.Compare(a, b) < 0
+}
+
+func Gt(a, b interface{}) bool {
+	return rt.Compare(a, b) > 0
+}
+
+func Append(a, b interface{}) interface{} {
+	if a, ok := a.(string); ok {
+		return a + b.(string)
+	}
+
+	var elems []interface{}
+	for l := a; !rt.IsEmpty(l); l = rt.Tail(l) {
+		elems = append(elems, rt.Head(l))
+	}
+
+	var result = b
+	for i := len(elems) - 1; i >= 0; i-- {
+		result = rt.Cons(elems[i], result)
+	}
+	return result
+}
+
+func And(a, b bool) bool {
+	return a && b
+}
+
+func Or(a, b bool) bool {
+	return a || b
+}
+
+func ToFloat(n int) float64 {
+	return float64(n)
+}
+
+func toFloat(n interface{}) float64 {
+	if n, ok := n.(int); ok {
+		return float64(n)
+	}
+	return n.(float64)
+}
@@ -0,0 +1,7 @@
+package native
+
+import "elm/rt"
+
+func Cons(head, tail interface{}) interface{} {
+	return rt.Cons(head, tail)
+}
@@ -0,0 +1,6 @@
+module Result exposing (..)
+
+
+type Result error value
+    = Ok value
+    | Err error
@@ -0,0 +1,6 @@
+module String exposing (..)
+
+
+placeholder : String
+placeholder =
+    "foo"
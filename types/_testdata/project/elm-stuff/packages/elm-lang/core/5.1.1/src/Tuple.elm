@@ -0,0 +1,6 @@
+module Tuple exposing (..)
+
+
+placeholder : String
+placeholder =
+    "foo"
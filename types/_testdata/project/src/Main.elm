@@ -0,0 +1,43 @@
+module Main exposing (..)
+
+import Shape exposing (Shape(..), area)
+
+
+type alias Model =
+    { shapes : List Shape
+    , name : String
+    }
+
+
+init : Model
+init =
+    { shapes = [ Circle 1.5, Rectangle 2 3 ]
+    , name = "shapes"
+    }
+
+
+totalArea : Model -> Float
+totalArea model =
+    List.foldl (\shape acc -> area shape + acc) 0 model.shapes
+
+
+rename : String -> Model -> Model
+rename name model =
+    { model | name = name }
+
+
+describe : Model -> String
+describe { name } =
+    case name of
+        "" ->
+            "unnamed"
+
+        _ ->
+            "shapes named " ++ name
+
+
+main : String
+main =
+    init
+        |> rename "figures"
+        |> describe
@@ -0,0 +1,6 @@
+module Mismatch exposing (..)
+
+
+count : Int
+count =
+    "one"
@@ -0,0 +1,16 @@
+module Shape exposing (Shape(..), area)
+
+
+type Shape
+    = Circle Float
+    | Rectangle Float Float
+
+
+area : Shape -> Float
+area shape =
+    case shape of
+        Circle radius ->
+            3.14 * radius * radius
+
+        Rectangle width height ->
+            width * height
@@ -0,0 +1,811 @@
+package types
+
+import (
+	"math"
+
+	"github.com/elm-tangram/tangram/ast"
+	"github.com/elm-tangram/tangram/native"
+	"github.com/elm-tangram/tangram/report"
+)
+
+// genericLevel is the level of the type variables that have been generalized
+// and need to be instantiated every time they are used.
+const genericLevel = math.MaxInt32
+
+// Info contains the types inferred during the type check.
+type Info struct {
+	// Types is a mapping between expressions, patterns and definition names
+	// and their types. Type variables in the types of definitions are
+	// generic.
+	Types map[ast.Node]Type
+}
+
+// TypeOf returns the type of the given node, or nil if it has no type.
+func (i *Info) TypeOf(node ast.Node) Type {
+	return i.Types[node]
+}
+
+// Check infers the types of all the definitions in the given package and
+// checks they match their type annotations, if any. All the type errors
+// found are reported to the given reporter. The package must have been
+// resolved before being checked. It returns the types inferred and whether
+// the package is well-typed or not.
+func Check(pkg *ast.Package, reporter *report.Reporter) (*Info, bool) {
+	c := newChecker(reporter)
+	for _, name := range pkg.Order {
+		if mod := pkg.Modules[name]; mod != nil {
+			c.collect(mod.Path, mod.Decls)
+		}
+	}
+
+	for _, name := range pkg.Order {
+		if mod := pkg.Modules[name]; mod != nil {
+			for _, decl := range mod.Decls {
+				c.checkDecl(decl)
+			}
+		}
+	}
+
+	// patterns of ill-typed case expressions cannot be analysed
+	if c.ok {
+		for _, name := range pkg.Order {
+			if mod := pkg.Modules[name]; mod != nil {
+				c.path = mod.Path
+				c.checkCases(mod.Decls)
+			}
+		}
+	}
+
+	for node, t := range c.info.Types {
+		c.info.Types[node] = resolve(t)
+	}
+	return c.info, c.ok
+}
+
+type declState byte
+
+const (
+	unchecked declState = iota
+	checking
+	checked
+)
+
+// pendingDecl is a declaration that defines values that can be referenced
+// before the declaration has been checked.
+type pendingDecl struct {
+	decl  ast.Decl
+	path  string
+	state declState
+}
+
+type checker struct {
+	reporter *report.Reporter
+	info     *Info
+	ok       bool
+	// path of the module being checked.
+	path string
+	// level is the current let level, used to know which type variables
+	// can be generalized.
+	level int
+
+	// env contains the types of all values already checked by the node
+	// of their object.
+	env map[ast.Node]Type
+	// decls contains the declarations of all values by the node of their
+	// object.
+	decls map[ast.Node]*pendingDecl
+	// unions contains the union declaration of every constructor.
+	unions map[*ast.Constructor]*ast.UnionDecl
+	// ctors contains the types of the constructors already used.
+	ctors map[*ast.Constructor]Type
+	// annotated is the identifier in the body of the annotated definition
+	// being checked if the body is just a name, which can be a native
+	// function with values of any type.
+	annotated *ast.Ident
+}
+
+func newChecker(reporter *report.Reporter) *checker {
+	return &checker{
+		reporter: reporter,
+		info:     &Info{make(map[ast.Node]Type)},
+		ok:       true,
+		env:      make(map[ast.Node]Type),
+		decls:    make(map[ast.Node]*pendingDecl),
+		unions:   make(map[*ast.Constructor]*ast.UnionDecl),
+		ctors:    make(map[*ast.Constructor]Type),
+	}
+}
+
+// collect registers all the given declarations of the module at the given
+// path so they can be checked as soon as they are referenced.
+func (c *checker) collect(path string, decls []ast.Decl) {
+	for _, decl := range decls {
+		switch decl := decl.(type) {
+		case *ast.Definition:
+			c.decls[decl.Name] = &pendingDecl{decl: decl, path: path}
+		case *ast.PortDecl:
+			c.decls[decl.Name] = &pendingDecl{decl: decl, path: path}
+		case *ast.DestructuringAssignment:
+			pending := &pendingDecl{decl: decl, path: path}
+			for _, node := range boundNodes(decl.Pattern) {
+				c.decls[node] = pending
+			}
+		case *ast.UnionDecl:
+			for _, ctor := range decl.Ctors {
+				c.unions[ctor] = decl
+			}
+		}
+	}
+}
+
+func (c *checker) checkDecl(decl ast.Decl) {
+	var node ast.Node
+	switch decl := decl.(type) {
+	case *ast.Definition:
+		node = decl.Name
+	case *ast.PortDecl:
+		node = decl.Name
+	case *ast.DestructuringAssignment:
+		nodes := boundNodes(decl.Pattern)
+		if len(nodes) == 0 {
+			c.checkDestructuring(decl)
+			return
+		}
+		node = nodes[0]
+	default:
+		return
+	}
+
+	pending, ok := c.decls[node]
+	if !ok || pending.state != unchecked {
+		return
+	}
+
+	path := c.path
+	c.path = pending.path
+	pending.state = checking
+	switch decl := decl.(type) {
+	case *ast.Definition:
+		c.checkDefinition(decl)
+	case *ast.PortDecl:
+		c.checkPort(decl)
+	case *ast.DestructuringAssignment:
+		c.checkDestructuring(decl)
+	}
+	pending.state = checked
+	c.path = path
+}
+
+func (c *checker) checkDefinition(def *ast.Definition) {
+	c.level++
+	if def.Annotation != nil {
+		annotation := c.newConverter(false, genericLevel).convert(def.Annotation.Type)
+		c.env[def.Name] = annotation
+		c.record(def.Name, annotation)
+
+		expected := c.newConverter(true, c.level).convert(def.Annotation.Type)
+		c.checkAnnotated(def, expected)
+		c.level--
+		return
+	}
+
+	v := c.newVar(Unconstrained)
+	c.env[def.Name] = v
+	inferred := c.inferFunc(def.Args, def.Body)
+	if err := unify(v, inferred); err != nil {
+		c.mismatch(def.Body, v, inferred, err)
+	}
+	c.level--
+
+	c.generalize(v)
+	c.record(def.Name, v)
+}
+
+// checkAnnotated checks the arguments and body of the given definition have
+// the types in its annotation.
+func (c *checker) checkAnnotated(def *ast.Definition, expected Type) {
+	t := expected
+	for _, arg := range def.Args {
+		fn, ok := prune(t).(*Func)
+		if !ok {
+			inferred := c.inferFunc(def.Args, def.Body)
+			p := newPrinter()
+			c.report(report.NewAnnotationMismatchError(def, p.print(expected), p.print(inferred)))
+			return
+		}
+
+		c.bindPattern(arg, fn.Arg)
+		t = fn.Return
+	}
+
+	annotated := c.annotated
+	c.annotated = lastIdent(def.Body)
+	body := c.infer(def.Body)
+	c.annotated = annotated
+	if err := unify(t, body); err != nil {
+		c.mismatch(def.Body, t, body, err)
+	}
+}
+
+// checkPort records the type of the given port, which is always the type it
+// was declared with.
+func (c *checker) checkPort(decl *ast.PortDecl) {
+	t := c.newConverter(false, genericLevel).convert(decl.Type)
+	c.env[decl.Name] = t
+	c.record(decl.Name, t)
+}
+
+func (c *checker) checkDestructuring(decl *ast.DestructuringAssignment) {
+	c.level++
+	t := c.infer(decl.Expr)
+	c.bindPattern(decl.Pattern, t)
+	c.level--
+
+	for _, node := range boundNodes(decl.Pattern) {
+		c.generalize(c.env[node])
+	}
+}
+
+// inferFunc infers the type of a function with the given arguments and
+// body. If there are no arguments, the type is the type of the body.
+func (c *checker) inferFunc(args []ast.Pattern, body ast.Expr) Type {
+	var argTypes = make([]Type, len(args))
+	for i, arg := range args {
+		argTypes[i] = c.newVar(Unconstrained)
+		c.bindPattern(arg, argTypes[i])
+	}
+
+	t := c.infer(body)
+	for i := len(argTypes) - 1; i >= 0; i-- {
+		t = &Func{argTypes[i], t}
+	}
+	return t
+}
+
+func (c *checker) infer(expr ast.Expr) Type {
+	t := c.inferExpr(expr)
+	c.record(expr, t)
+	return t
+}
+
+func (c *checker) inferExpr(expr ast.Expr) Type {
+	switch expr := expr.(type) {
+	case *ast.BasicLit:
+		return c.literalType(expr)
+	case *ast.Ident:
+		return c.inferIdent(expr)
+	case *ast.SelectorExpr:
+		return c.inferSelector(expr)
+	case *ast.TupleLit:
+		var elems = make([]Type, len(expr.Elems))
+		for i, el := range expr.Elems {
+			elems[i] = c.infer(el)
+		}
+		return &Tuple{elems}
+	case *ast.TupleCtor:
+		var elems = make([]Type, expr.Elems)
+		for i := range elems {
+			elems[i] = c.newVar(Unconstrained)
+		}
+
+		var t Type = &Tuple{elems}
+		for i := len(elems) - 1; i >= 0; i-- {
+			t = &Func{elems[i], t}
+		}
+		return t
+	case *ast.ListLit:
+		elem := c.newVar(Unconstrained)
+		for _, el := range expr.Elems {
+			t := c.infer(el)
+			if err := unify(elem, t); err != nil {
+				c.mismatch(el, elem, t, err)
+			}
+		}
+		return List(elem)
+	case *ast.FuncApp:
+		return c.apply(expr, c.infer(expr.Func), expr.Args)
+	case *ast.BinaryOp:
+		return c.apply(expr, c.infer(expr.Op), []ast.Expr{expr.Lhs, expr.Rhs})
+	case *ast.UnaryOp:
+		t := c.infer(expr.Expr)
+		number := c.newVar(Number)
+		if err := unify(number, t); err != nil {
+			c.mismatch(expr.Expr, number, t, err)
+		}
+		return t
+	case *ast.RecordLit:
+		var fields = make(map[string]Type)
+		for _, f := range expr.Fields {
+			fields[f.Field.Name] = c.infer(f.Expr)
+		}
+		return &Record{Fields: fields}
+	case *ast.RecordUpdate:
+		t := c.infer(expr.Record)
+		for _, f := range expr.Fields {
+			expected := &Record{
+				Fields: map[string]Type{f.Field.Name: c.infer(f.Expr)},
+				Ext:    c.newVar(Unconstrained),
+			}
+
+			if err := unify(expected, t); err != nil {
+				c.mismatch(f, expected, t, err)
+			}
+		}
+		return t
+	case *ast.AccessorExpr:
+		field := c.newVar(Unconstrained)
+		record := &Record{
+			Fields: map[string]Type{expr.Field.Name: field},
+			Ext:    c.newVar(Unconstrained),
+		}
+		return &Func{record, field}
+	case *ast.LetExpr:
+		c.collect(c.path, expr.Decls)
+		for _, decl := range expr.Decls {
+			c.checkDecl(decl)
+		}
+		return c.infer(expr.Body)
+	case *ast.IfExpr:
+		cond := c.infer(expr.Cond)
+		if err := unify(Bool, cond); err != nil {
+			c.mismatch(expr.Cond, Bool, cond, err)
+		}
+
+		then := c.infer(expr.ThenExpr)
+		els := c.infer(expr.ElseExpr)
+		if err := unify(then, els); err != nil {
+			c.mismatch(expr.ElseExpr, then, els, err)
+		}
+		return then
+	case *ast.CaseExpr:
+		t := c.infer(expr.Expr)
+		result := c.newVar(Unconstrained)
+		for _, b := range expr.Branches {
+			c.bindPattern(b.Pattern, t)
+			bt := c.infer(b.Expr)
+			if err := unify(result, bt); err != nil {
+				c.mismatch(b.Expr, result, bt, err)
+			}
+		}
+		return result
+	case *ast.Lambda:
+		return c.inferFunc(expr.Args, expr.Expr)
+	case *ast.ParensExpr:
+		return c.infer(expr.Expr)
+	}
+
+	return c.newVar(Unconstrained)
+}
+
+func (c *checker) literalType(lit *ast.BasicLit) Type {
+	switch lit.Type {
+	case ast.Int:
+		return c.newVar(Number)
+	case ast.Float:
+		return Float
+	case ast.String:
+		return String
+	case ast.Char:
+		return Char
+	case ast.Bool:
+		return Bool
+	}
+	return c.newVar(Unconstrained)
+}
+
+// apply returns the type of applying the given arguments to a function of
+// the given type.
+func (c *checker) apply(node ast.Node, fn Type, args []ast.Expr) Type {
+	for i, arg := range args {
+		t := c.infer(arg)
+		if f, ok := prune(fn).(*Func); ok {
+			if err := unify(f.Arg, t); err != nil {
+				c.mismatch(arg, f.Arg, t, err)
+			}
+			fn = f.Return
+			continue
+		}
+
+		ret := c.newVar(Unconstrained)
+		expected := &Func{t, ret}
+		if err := unify(expected, fn); err != nil {
+			c.mismatch(node, expected, fn, err)
+			for _, arg := range args[i+1:] {
+				c.infer(arg)
+			}
+			return c.newVar(Unconstrained)
+		}
+		fn = ret
+	}
+	return fn
+}
+
+func (c *checker) inferIdent(ident *ast.Ident) Type {
+	var t Type
+	if ident.Obj == nil {
+		t = c.newVar(Unconstrained)
+	} else {
+		switch ident.Obj.Kind {
+		case ast.Var:
+			if fn, ok := ident.Obj.Data.(*native.Func); ok {
+				t = c.nativeType(fn)
+				if ident != c.annotated && isAny(fn) {
+					c.report(report.NewUntypedNativeError(ident))
+				}
+			} else {
+				t = c.lookup(ident.Obj.Node)
+			}
+		case ast.Ctor:
+			t = c.instantiate(c.ctorType(ident.Obj.Node.(*ast.Constructor)))
+		default:
+			t = c.newVar(Unconstrained)
+		}
+	}
+
+	c.record(ident, t)
+	return t
+}
+
+// inferSelector infers the type of a selector expression, which can be
+// either a qualified name, the access to a field of a record or both.
+func (c *checker) inferSelector(expr *ast.SelectorExpr) Type {
+	idents := selectorIdents(expr)
+	for i, ident := range idents {
+		if ident.Obj != nil {
+			switch ident.Obj.Kind {
+			case ast.Mod:
+				continue
+			case ast.NativeMod:
+				return c.inferIdent(idents[len(idents)-1])
+			}
+		}
+
+		t := c.inferIdent(ident)
+		for _, field := range idents[i+1:] {
+			t = c.access(expr, t, field)
+		}
+		return t
+	}
+
+	return c.newVar(Unconstrained)
+}
+
+// access returns the type of the given field in a record of the given type.
+func (c *checker) access(node ast.Node, t Type, field *ast.Ident) Type {
+	ft := c.newVar(Unconstrained)
+	expected := &Record{
+		Fields: map[string]Type{field.Name: ft},
+		Ext:    c.newVar(Unconstrained),
+	}
+
+	if err := unify(expected, t); err != nil {
+		c.mismatch(node, expected, t, err)
+	}
+
+	c.record(field, ft)
+	return ft
+}
+
+// lookup returns the type of the value defined by the given node. If the
+// value has not been checked yet, it will be checked right away.
+func (c *checker) lookup(node ast.Node) Type {
+	if t, ok := c.env[node]; ok {
+		return c.instantiate(t)
+	}
+
+	if pending, ok := c.decls[node]; ok && pending.state == unchecked {
+		c.checkDecl(pending.decl)
+		if t, ok := c.env[node]; ok {
+			return c.instantiate(t)
+		}
+	}
+
+	// natives and values whose declaration is being checked
+	return c.newVar(Unconstrained)
+}
+
+// ctorType returns the generic type of the given constructor, which is a
+// function from its arguments to its union type.
+func (c *checker) ctorType(ctor *ast.Constructor) Type {
+	if t, ok := c.ctors[ctor]; ok {
+		return t
+	}
+
+	union, ok := c.unions[ctor]
+	if !ok {
+		return c.newVar(Unconstrained)
+	}
+
+	cv := c.newConverter(false, genericLevel)
+	var args = make([]Type, len(union.Args))
+	for i, arg := range union.Args {
+		args[i] = &Var{Name: arg.Name, level: genericLevel}
+		cv.declare(arg, args[i])
+	}
+
+	var t Type = &Named{Name: union.Name.Name, Decl: union, Args: args}
+	for i := len(ctor.Args) - 1; i >= 0; i-- {
+		t = &Func{cv.convert(ctor.Args[i]), t}
+	}
+
+	c.ctors[ctor] = t
+	return t
+}
+
+// bindPattern checks the given pattern matches values of the given type
+// and adds to the environment all the variables bound in the pattern.
+func (c *checker) bindPattern(pattern ast.Pattern, t Type) {
+	pt := c.patternType(pattern)
+	if err := unify(t, pt); err != nil {
+		c.mismatch(pattern, t, pt, err)
+	}
+}
+
+func (c *checker) patternType(pattern ast.Pattern) Type {
+	t := c.inferPattern(pattern)
+	c.record(pattern, t)
+	return t
+}
+
+func (c *checker) inferPattern(pattern ast.Pattern) Type {
+	switch pattern := pattern.(type) {
+	case *ast.VarPattern:
+		t := c.newVar(Unconstrained)
+		c.env[pattern] = t
+		return t
+	case *ast.AliasPattern:
+		t := c.patternType(pattern.Pattern)
+		c.env[pattern] = t
+		return t
+	case *ast.LiteralPattern:
+		return c.literalType(pattern.Literal)
+	case *ast.TuplePattern:
+		var elems = make([]Type, len(pattern.Elems))
+		for i, el := range pattern.Elems {
+			elems[i] = c.patternType(el)
+		}
+		return &Tuple{elems}
+	case *ast.ListPattern:
+		elem := c.newVar(Unconstrained)
+		for _, el := range pattern.Elems {
+			c.bindPattern(el, elem)
+		}
+		return List(elem)
+	case *ast.RecordPattern:
+		var fields = make(map[string]Type)
+		for _, f := range pattern.Fields {
+			if v, ok := f.(*ast.VarPattern); ok {
+				fields[v.Name.Name] = c.patternType(v)
+			}
+		}
+		return &Record{fields, c.newVar(Unconstrained)}
+	case *ast.CtorPattern:
+		return c.inferCtorPattern(pattern)
+	}
+
+	return c.newVar(Unconstrained)
+}
+
+func (c *checker) inferCtorPattern(pattern *ast.CtorPattern) Type {
+	ident := lastIdent(pattern.Ctor)
+	if ident == nil || ident.Obj == nil {
+		return c.newVar(Unconstrained)
+	}
+
+	switch ident.Obj.Kind {
+	case ast.Var:
+		if ident.Name != "::" || len(pattern.Args) != 2 {
+			break
+		}
+
+		elem := c.newVar(Unconstrained)
+		c.bindPattern(pattern.Args[0], elem)
+		c.bindPattern(pattern.Args[1], List(elem))
+		return List(elem)
+	case ast.Ctor:
+		ctor := ident.Obj.Node.(*ast.Constructor)
+		t := c.instantiate(c.ctorType(ctor))
+		if len(pattern.Args) != len(ctor.Args) {
+			c.report(report.NewCtorArgsError(pattern, ident.Name, len(ctor.Args)))
+			for f, ok := prune(t).(*Func); ok; f, ok = prune(t).(*Func) {
+				t = f.Return
+			}
+			return t
+		}
+
+		for _, arg := range pattern.Args {
+			f := prune(t).(*Func)
+			c.bindPattern(arg, f.Arg)
+			t = f.Return
+		}
+		return t
+	}
+
+	return c.newVar(Unconstrained)
+}
+
+func (c *checker) newVar(kind VarKind) *Var {
+	return &Var{Kind: kind, level: c.level}
+}
+
+// instantiate returns a copy of the given type replacing all the generic
+// type variables with new type variables.
+func (c *checker) instantiate(t Type) Type {
+	return c.copyType(t, make(map[*Var]*Var))
+}
+
+func (c *checker) copyType(t Type, vars map[*Var]*Var) Type {
+	switch t := prune(t).(type) {
+	case *Var:
+		if t.level != genericLevel {
+			return t
+		}
+
+		if v, ok := vars[t]; ok {
+			return v
+		}
+
+		v := &Var{Name: t.Name, Kind: t.Kind, level: c.level}
+		vars[t] = v
+		return v
+	case *Named:
+		if len(t.Args) == 0 {
+			return t
+		}
+
+		var args = make([]Type, len(t.Args))
+		for i, arg := range t.Args {
+			args[i] = c.copyType(arg, vars)
+		}
+		return &Named{t.Name, t.Decl, args}
+	case *Func:
+		return &Func{c.copyType(t.Arg, vars), c.copyType(t.Return, vars)}
+	case *Tuple:
+		var elems = make([]Type, len(t.Elems))
+		for i, el := range t.Elems {
+			elems[i] = c.copyType(el, vars)
+		}
+		return &Tuple{elems}
+	case *Record:
+		var fields = make(map[string]Type)
+		for name, f := range t.Fields {
+			fields[name] = c.copyType(f, vars)
+		}
+
+		var ext Type
+		if t.Ext != nil {
+			ext = c.copyType(t.Ext, vars)
+		}
+		return &Record{fields, ext}
+	default:
+		return t
+	}
+}
+
+// generalize makes generic all the type variables in the given type that
+// were created at a deeper level than the current one.
+func (c *checker) generalize(t Type) {
+	switch t := prune(t).(type) {
+	case *Var:
+		if !t.Rigid && t.level > c.level {
+			t.level = genericLevel
+		}
+	case *Named:
+		for _, arg := range t.Args {
+			c.generalize(arg)
+		}
+	case *Func:
+		c.generalize(t.Arg)
+		c.generalize(t.Return)
+	case *Tuple:
+		for _, el := range t.Elems {
+			c.generalize(el)
+		}
+	case *Record:
+		for _, f := range t.Fields {
+			c.generalize(f)
+		}
+
+		if t.Ext != nil {
+			c.generalize(t.Ext)
+		}
+	}
+}
+
+func (c *checker) record(node ast.Node, t Type) {
+	c.info.Types[node] = t
+}
+
+// mismatch reports that a node of the actual type was found where a node of
+// the expected type was needed.
+func (c *checker) mismatch(node ast.Node, expected, actual Type, err error) {
+	p := newPrinter()
+	if err, ok := err.(infiniteTypeError); ok {
+		c.report(report.NewInfiniteTypeError(node, p.print(err.Var), p.print(err.Type)))
+		return
+	}
+
+	c.report(report.NewTypeMismatchError(node, p.print(expected), p.print(actual)))
+}
+
+func (c *checker) report(r report.Report) {
+	c.ok = false
+	c.reporter.Report(c.path, r)
+}
+
+// resolve returns the given type with all the bound type variables replaced
+// by the types they are bound to.
+func resolve(t Type) Type {
+	switch t := prune(t).(type) {
+	case *Named:
+		if len(t.Args) == 0 {
+			return t
+		}
+
+		var args = make([]Type, len(t.Args))
+		for i, arg := range t.Args {
+			args[i] = resolve(arg)
+		}
+		return &Named{t.Name, t.Decl, args}
+	case *Func:
+		return &Func{resolve(t.Arg), resolve(t.Return)}
+	case *Tuple:
+		var elems = make([]Type, len(t.Elems))
+		for i, el := range t.Elems {
+			elems[i] = resolve(el)
+		}
+		return &Tuple{elems}
+	case *Record:
+		fields, ext := flattenRecord(t)
+		for name, f := range fields {
+			fields[name] = resolve(f)
+		}
+		return &Record{fields, ext}
+	default:
+		return t
+	}
+}
+
+// boundNodes returns the nodes of all the variables bound in the given
+// pattern.
+func boundNodes(pattern ast.Pattern) []ast.Node {
+	switch pattern := pattern.(type) {
+	case *ast.VarPattern:
+		return []ast.Node{pattern}
+	case *ast.AliasPattern:
+		return append(boundNodes(pattern.Pattern), pattern)
+	case *ast.TuplePattern:
+		return boundNodesOf(pattern.Elems)
+	case *ast.ListPattern:
+		return boundNodesOf(pattern.Elems)
+	case *ast.RecordPattern:
+		return boundNodesOf(pattern.Fields)
+	case *ast.CtorPattern:
+		return boundNodesOf(pattern.Args)
+	}
+	return nil
+}
+
+func boundNodesOf(patterns []ast.Pattern) []ast.Node {
+	var nodes []ast.Node
+	for _, p := range patterns {
+		nodes = append(nodes, boundNodes(p)...)
+	}
+	return nodes
+}
+
+// selectorIdents returns all the identifiers in a selector expression.
+func selectorIdents(expr *ast.SelectorExpr) []*ast.Ident {
+	var idents = []*ast.Ident{expr.Selector}
+	for {
+		switch e := expr.Expr.(type) {
+		case *ast.Ident:
+			return append(idents, e)
+		case *ast.SelectorExpr:
+			idents = append(idents, e.Selector)
+			expr = e
+		default:
+			return idents
+		}
+	}
+}
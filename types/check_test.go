@@ -0,0 +1,306 @@
+package types
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/elm-tangram/tangram/ast"
+	"github.com/elm-tangram/tangram/package"
+	"github.com/elm-tangram/tangram/parser"
+	"github.com/elm-tangram/tangram/report"
+	"github.com/elm-tangram/tangram/source"
+	"github.com/stretchr/testify/require"
+)
+
+var projectDir = filepath.Join("_testdata", "project")
+
+func TestCheckProject(t *testing.T) {
+	require := require.New(t)
+	pkg, info, reporter, ok := check(t, filepath.Join(projectDir, "src", "Main.elm"))
+	require.True(ok, "%v", reporter.Reports(pkg.Modules["Main"].Path))
+
+	assertDefType(t, pkg, info, "Main", "init", "{ name : String, shapes : List Shape }")
+	assertDefType(t, pkg, info, "Main", "totalArea", "{ name : String, shapes : List Shape } -> Float")
+	assertDefType(t, pkg, info, "Main", "main", "String")
+	assertDefType(t, pkg, info, "Shape", "area", "Shape -> Float")
+	assertDefType(t, pkg, info, "List", "map", "(a -> b) -> List a -> List b")
+}
+
+func TestCheck(t *testing.T) {
+	cases := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{"identity", `f x = x`, "a -> a"},
+		{"const", `f a b = a`, "a -> b -> a"},
+		{"number", `f x y = x + y`, "number -> number -> number"},
+		{"float", `f x = x / 2`, "Float -> Float"},
+		{"compose", `f g h x = g (h x)`, "(a -> b) -> (c -> a) -> c -> b"},
+		{"tuple", `f = (1, "a", 'c')`, "(number, String, Char)"},
+		{"tuple ctor", `f = (,) True`, "a -> (Bool, a)"},
+		{"list", `f = [ 1.5, 2 ]`, "List Float"},
+		{"empty list", `f = []`, "List a"},
+		{"cons", `f x = x :: [ "a" ]`, "String -> List String"},
+		{"comparable", `f a b = a < b`, "comparable -> comparable -> Bool"},
+		{"appendable", `f a = a ++ a`, "appendable -> appendable"},
+		{"compappend", `f a b = (a ++ b) < b`, "compappend -> compappend -> Bool"},
+		{"negate", `f x = -x`, "number -> number"},
+		{"lambda", `f = \x -> x ++ "!"`, "String -> String"},
+		{"if", `f x = if x then 1 else 2`, "Bool -> number"},
+		{"constructor", `f = Just`, "a -> Maybe a"},
+		{"record", `f = { x = 1, y = "a" }`, "{ x : number, y : String }"},
+		{"field access", `f r = r.x`, "{ a | x : b } -> b"},
+		{"nested field access", `f r = r.x.y`, "{ a | x : { b | y : c } } -> c"},
+		{"accessor", `f = .name`, "{ a | name : b } -> b"},
+		{"record update", `f r = { r | x = 1 }`, "{ a | x : number } -> { a | x : number }"},
+		{"record pattern", `f { x, y } = x + y`, "{ a | x : number, y : number } -> number"},
+		{"qualified", `f = Maybe.withDefault 1`, "Maybe number -> number"},
+		{"pipe", `f x = x |> toFloat |> (*) 2`, "Int -> Float"},
+		{"recursion", "f l =\n    case l of\n        [] -> 0\n        _ :: xs -> 1 + f xs", "List a -> number"},
+		{"let polymorphism", "f =\n    let\n        id x = x\n    in\n        (id 1, id \"a\")", "(number, String)"},
+		{"let destructuring", "f =\n    let\n        (a, b) = (1, \"a\")\n    in\n        b", "String"},
+		{"case", "f m =\n    case m of\n        Just (x, _) -> x\n        Nothing -> \"\"", "Maybe (String, a) -> String"},
+		{"case literals", "f x =\n    case x of\n        True -> 'a'\n        False -> 'b'", "Bool -> Char"},
+		{"alias pattern", "f m =\n    case m of\n        (Just _) as x -> x\n        Nothing -> Just 1", "Maybe number -> Maybe number"},
+		{"annotated", "f : List comparable -> comparable -> Bool\nf l x = l == [x]", "List comparable -> comparable -> Bool"},
+		{"annotated polymorphic recursion", "f : a -> List a\nf x = [x]\n\ng = (f 1, f \"a\")", "a -> List a"},
+		{"extended record", "f : { a | x : Int } -> Int\nf r = r.x", "{ a | x : Int } -> Int"},
+		{"extended record alias", "type alias Named a = { a | name : String }\n\nf : Named { age : Int } -> String\nf r = r.name", "{ age : Int, name : String } -> String"},
+		{"alias", "type alias Pair a = (a, a)\n\nf : a -> Pair a\nf x = (x, x)", "a -> (a, a)"},
+		{"union", "type Tree a = Leaf | Node (Tree a) a (Tree a)\n\nf x = Node Leaf x Leaf", "a -> Tree a"},
+		{"reference", "g x = x ++ \"a\"\n\nf = g \"b\"", "String"},
+		{"native", "import Native.Basics\n\nf = Native.Basics.floatDiv", "Float -> Float -> Float"},
+		{"native any", "import Native.Basics\n\nf : Int -> Int -> Int\nf = Native.Basics.add", "Int -> Int -> Int"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			pkg, info, reporter, ok := checkSource(t, c.source)
+			require.True(t, ok, "%v", reporter.Reports(pkg.Modules["Main"].Path))
+			assertDefType(t, pkg, info, "Main", "f", c.expected)
+		})
+	}
+}
+
+func TestCheckErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		source string
+		report report.Report
+	}{
+		{
+			"annotation",
+			"f : Int\nf = \"a\"",
+			&report.TypeMismatchError{Expected: "Int", Actual: "String"},
+		},
+		{
+			"rigid type variable",
+			"f : a -> Int\nf x = x + 1",
+			&report.TypeMismatchError{Expected: "number", Actual: "a"},
+		},
+		{
+			"too many arguments",
+			"f : Int\nf x = x",
+			&report.AnnotationMismatchError{Name: "f", Annotation: "Int", Inferred: "a -> a"},
+		},
+		{
+			"argument",
+			`f = toFloat 1.5`,
+			&report.TypeMismatchError{Expected: "Int", Actual: "Float"},
+		},
+		{
+			"not a function",
+			`f = "a" 1`,
+			&report.TypeMismatchError{Expected: "number -> a", Actual: "String"},
+		},
+		{
+			"if condition",
+			`f = if "a" then 1 else 2`,
+			&report.TypeMismatchError{Expected: "Bool", Actual: "String"},
+		},
+		{
+			"if branches",
+			`f = if True then 1 else "a"`,
+			&report.TypeMismatchError{Expected: "number", Actual: "String"},
+		},
+		{
+			"list elements",
+			`f = [ 'a', 'b', "c" ]`,
+			&report.TypeMismatchError{Expected: "Char", Actual: "String"},
+		},
+		{
+			"case branches",
+			"f x =\n    case x of\n        1 -> \"a\"\n        _ -> 'b'",
+			&report.TypeMismatchError{Expected: "String", Actual: "Char"},
+		},
+		{
+			"case pattern",
+			"f x =\n    case x of\n        Just 1 -> 1\n        'a' -> 2",
+			&report.TypeMismatchError{Expected: "Maybe number", Actual: "Char"},
+		},
+		{
+			"constructor arguments",
+			"f x =\n    case x of\n        Just -> 1\n        Nothing -> 2",
+			&report.CtorArgsError{Ctor: "Just", Expected: 1, Actual: 0},
+		},
+		{
+			"not comparable",
+			`f = Just 1 < 2`,
+			&report.TypeMismatchError{Expected: "comparable", Actual: "Maybe number"},
+		},
+		{
+			"not a number",
+			`f = "a" + 1`,
+			&report.TypeMismatchError{Expected: "number", Actual: "String"},
+		},
+		{
+			"missing field",
+			"g r = r.y\n\nf = g { x = 1 }",
+			&report.TypeMismatchError{Expected: "{ a | y : b }", Actual: "{ x : number }"},
+		},
+		{
+			"native result",
+			"import Native.Basics\n\nf : Int -> Int\nf = Native.Basics.toFloat",
+			&report.TypeMismatchError{Expected: "Int -> Int", Actual: "Int -> Float"},
+		},
+		{
+			"native arity",
+			"import Native.Basics\n\nf : Int -> Int -> Float\nf = Native.Basics.toFloat",
+			&report.TypeMismatchError{Expected: "Int -> Int -> Float", Actual: "Int -> Float"},
+		},
+		{
+			"native any without annotation",
+			"import Native.Basics\n\nf = Native.Basics.add",
+			&report.UntypedNativeError{Name: "add"},
+		},
+		{
+			"native any applied",
+			"import Native.Basics\n\nf : Int -> Int\nf x = Native.Basics.add x 1",
+			&report.UntypedNativeError{Name: "add"},
+		},
+		{
+			"infinite type",
+			`f x = x x`,
+			&report.InfiniteTypeError{Var: "a", Infinite: "a -> b"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require := require.New(t)
+			pkg, _, reporter, ok := checkSource(t, c.source)
+			require.False(ok)
+
+			reports := reporter.Reports(pkg.Modules["Main"].Path)
+			require.Len(reports, 1)
+			require.Equal(report.TypeError, reports[0].Type())
+			require.IsType(c.report, reports[0])
+
+			switch expected := c.report.(type) {
+			case *report.TypeMismatchError:
+				r := reports[0].(*report.TypeMismatchError)
+				require.Equal(expected.Expected, r.Expected)
+				require.Equal(expected.Actual, r.Actual)
+			case *report.AnnotationMismatchError:
+				r := reports[0].(*report.AnnotationMismatchError)
+				require.Equal(expected.Name, r.Name)
+				require.Equal(expected.Annotation, r.Annotation)
+				require.Equal(expected.Inferred, r.Inferred)
+			case *report.CtorArgsError:
+				r := reports[0].(*report.CtorArgsError)
+				require.Equal(expected.Ctor, r.Ctor)
+				require.Equal(expected.Expected, r.Expected)
+				require.Equal(expected.Actual, r.Actual)
+			case *report.InfiniteTypeError:
+				r := reports[0].(*report.InfiniteTypeError)
+				require.Equal(expected.Var, r.Var)
+				require.Equal(expected.Infinite, r.Infinite)
+			case *report.UntypedNativeError:
+				require.Equal(expected.Name, reports[0].(*report.UntypedNativeError).Name)
+			}
+		})
+	}
+}
+
+func TestCheckReportsInDeclaringModule(t *testing.T) {
+	require := require.New(t)
+	path := filepath.Join(projectDir, "src", "Mismatch.elm")
+	pkg, _, reporter, ok := check(t, path)
+	require.False(ok)
+	require.Len(reporter.Reports(pkg.Modules["Mismatch"].Path), 1)
+}
+
+func assertDefType(t *testing.T, pkg *ast.Package, info *Info, module, name, expected string) {
+	mod := pkg.Modules[module]
+	require.NotNil(t, mod, "module %s not found", module)
+
+	for _, d := range mod.Decls {
+		if def, ok := d.(*ast.Definition); ok && def.Name.Name == name {
+			typ := info.TypeOf(def.Name)
+			require.NotNil(t, typ, "no type for %s.%s", module, name)
+			require.Equal(t, expected, typ.String(), "type of %s.%s", module, name)
+			return
+		}
+	}
+
+	require.FailNow(t, "definition not found", "%s.%s", module, name)
+}
+
+// checkSource type checks the given source code, which will be the content
+// of the Main module in a copy of the test project.
+func checkSource(t *testing.T, src string) (*ast.Package, *Info, *report.Reporter, bool) {
+	root, err := ioutil.TempDir("", "tangram-types")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	require.NoError(t, copyDir(projectDir, root))
+
+	path := filepath.Join(root, "src", "Main.elm")
+	src = "module Main exposing (..)\n\n" + src + "\n"
+	require.NoError(t, ioutil.WriteFile(path, []byte(src), 0644))
+	return check(t, path)
+}
+
+func check(t *testing.T, path string) (*ast.Package, *Info, *report.Reporter, bool) {
+	p, err := pkg.Load(filepath.Dir(path))
+	require.NoError(t, err)
+
+	cm := source.NewCodeMap(source.NewFsLoader(p))
+	defer cm.Close()
+
+	reporter := report.NewReporter(cm, report.Errors(true))
+	sess := parser.NewSession(reporter, cm, parser.NewOperatorTable(parser.FullParse))
+	result := parser.ParsePackage(sess, p, path, parser.FullParse)
+	require.NotNil(t, result, "%v", sess.Emit())
+	require.False(t, reporter.HasErrors(), "%v", sess.Emit())
+
+	info, ok := Check(result, reporter)
+	return result, info, reporter, ok
+}
+
+func copyDir(from, to string) error {
+	return filepath.Walk(from, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(to, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, content, 0644)
+	})
+}
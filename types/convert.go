@@ -0,0 +1,203 @@
+package types
+
+import (
+	"github.com/elm-tangram/tangram/ast"
+	"github.com/elm-tangram/tangram/native"
+)
+
+// converter transforms the types written by the user in type annotations and
+// declarations into types.
+type converter struct {
+	c *checker
+	// vars are the type variables already found, by the node they were
+	// declared in and by name, for the type variables not resolved.
+	vars map[interface{}]Type
+	// rigid will make all new type variables rigid.
+	rigid bool
+	// level of all new type variables.
+	level int
+	// aliases contains the aliases being expanded, to avoid expanding
+	// recursive aliases forever.
+	aliases map[*ast.AliasDecl]struct{}
+}
+
+func (c *checker) newConverter(rigid bool, level int) *converter {
+	return &converter{
+		c:       c,
+		vars:    make(map[interface{}]Type),
+		rigid:   rigid,
+		level:   level,
+		aliases: make(map[*ast.AliasDecl]struct{}),
+	}
+}
+
+func (cv *converter) convert(typ ast.Type) Type {
+	switch typ := typ.(type) {
+	case *ast.NamedType:
+		var args = make([]Type, len(typ.Args))
+		for i, arg := range typ.Args {
+			args[i] = cv.convert(arg)
+		}
+		return cv.convertNamed(lastIdent(typ.Name), args)
+	case *ast.VarType:
+		if v, ok := cv.vars[varKey(typ)]; ok {
+			return v
+		}
+
+		v := &Var{
+			Name:  typ.Name,
+			Kind:  kindOf(typ.Name),
+			Rigid: cv.rigid,
+			level: cv.level,
+		}
+		cv.vars[varKey(typ)] = v
+		return v
+	case *ast.FuncType:
+		t := cv.convert(typ.Return)
+		for i := len(typ.Args) - 1; i >= 0; i-- {
+			t = &Func{cv.convert(typ.Args[i]), t}
+		}
+		return t
+	case *ast.RecordType:
+		var fields = make(map[string]Type)
+		for _, f := range typ.Fields {
+			fields[f.Name.Name] = cv.convert(f.Type)
+		}
+
+		var ext Type
+		if typ.Extended != nil {
+			ext = cv.convert(typ.Extended)
+		}
+		return &Record{fields, ext}
+	case *ast.TupleType:
+		var elems = make([]Type, len(typ.Elems))
+		for i, el := range typ.Elems {
+			elems[i] = cv.convert(el)
+		}
+		return &Tuple{elems}
+	}
+
+	return cv.fresh()
+}
+
+func (cv *converter) convertNamed(name *ast.Ident, args []Type) Type {
+	if name == nil || name.Obj == nil {
+		return cv.fresh()
+	}
+
+	switch name.Obj.Kind {
+	case ast.BuiltinTyp:
+		return &Named{Name: name.Name, Args: args}
+	case ast.Typ:
+		switch decl := name.Obj.Node.(type) {
+		case *ast.UnionDecl:
+			return &Named{Name: decl.Name.Name, Decl: decl, Args: args}
+		case *ast.AliasDecl:
+			return cv.expandAlias(decl, args)
+		}
+	}
+
+	return cv.fresh()
+}
+
+// expandAlias returns the type aliased by the given declaration with the
+// given arguments.
+func (cv *converter) expandAlias(decl *ast.AliasDecl, args []Type) Type {
+	if _, ok := cv.aliases[decl]; ok {
+		return cv.fresh()
+	}
+
+	alias := &converter{
+		c:       cv.c,
+		vars:    make(map[interface{}]Type),
+		rigid:   cv.rigid,
+		level:   cv.level,
+		aliases: cv.aliases,
+	}
+
+	for i, arg := range decl.Args {
+		if i < len(args) {
+			alias.declare(arg, args[i])
+		}
+	}
+
+	cv.aliases[decl] = struct{}{}
+	defer delete(cv.aliases, decl)
+	return alias.convert(decl.Type)
+}
+
+// declare sets the type of the type variable declared by the given
+// argument of a declaration.
+func (cv *converter) declare(arg *ast.Ident, t Type) {
+	cv.vars[arg] = t
+	cv.vars[arg.Name] = t
+}
+
+// varKey returns the key of the given type variable in the variables of a
+// converter, which is the node of the type variable it is bound to by the
+// resolver or, if it is not bound, its name.
+func varKey(v *ast.VarType) interface{} {
+	if v.Obj != nil && v.Obj.Kind == ast.VarTyp {
+		return v.Obj.Node
+	}
+	return v.Name
+}
+
+func (cv *converter) fresh() Type {
+	return &Var{level: cv.level}
+}
+
+// lastIdent returns the last identifier of a qualified name, which is the
+// identifier that is resolved to an object.
+func lastIdent(expr ast.Expr) *ast.Ident {
+	for {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			return e
+		case *ast.SelectorExpr:
+			expr = e.Expr
+		default:
+			return nil
+		}
+	}
+}
+
+// nativeType returns the type of the given native function. Parameters and
+// results that can be any value get a new type variable each, so the type
+// is only sound if it is unified with a type annotation.
+func (c *checker) nativeType(fn *native.Func) Type {
+	t := c.nativeKindType(fn.Result)
+	for i := len(fn.Params) - 1; i >= 0; i-- {
+		t = &Func{c.nativeKindType(fn.Params[i]), t}
+	}
+	return t
+}
+
+// isAny reports whether any parameter or the result of the given native
+// function can be any value.
+func isAny(fn *native.Func) bool {
+	for _, kind := range fn.Params {
+		if kind == native.Any {
+			return true
+		}
+	}
+	return fn.Result == native.Any
+}
+
+func (c *checker) nativeKindType(kind native.Kind) Type {
+	switch kind {
+	case native.Int:
+		return Int
+	case native.Float:
+		return Float
+	case native.String:
+		return String
+	case native.Char:
+		return Char
+	case native.Bool:
+		return Bool
+	case native.Unit:
+		return Unit
+	}
+	return c.newVar(Unconstrained)
+}
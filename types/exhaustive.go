@@ -0,0 +1,331 @@
+package types
+
+import (
+	"strings"
+
+	"github.com/elm-tangram/tangram/ast"
+	"github.com/elm-tangram/tangram/report"
+)
+
+// The exhaustiveness and redundancy of case expressions is checked using
+// pattern matrices, as described in "Warnings for pattern matching" by Luc
+// Maranget. Patterns are simplified to constructors applied to other
+// patterns, or wildcards. Tuples are types with a single constructor, lists
+// are types with the [] and :: constructors, booleans are types with the
+// True and False constructors and the rest of literals are constructors of
+// types with infinite constructors.
+
+// ctor is a constructor in a simplified pattern.
+type ctor struct {
+	name  string
+	arity int
+	// all contains all the constructors of the type the constructor
+	// belongs to. It is nil if the type has infinite constructors.
+	all []*ctor
+}
+
+// pat is a simplified pattern. A pattern without constructor is a wildcard,
+// which matches everything.
+type pat struct {
+	ctor *ctor
+	args []*pat
+}
+
+var wildcard = new(pat)
+
+var (
+	nilCtor   = &ctor{name: "[]"}
+	consCtor  = &ctor{name: "::", arity: 2}
+	trueCtor  = &ctor{name: "True"}
+	falseCtor = &ctor{name: "False"}
+)
+
+func init() {
+	nilCtor.all = []*ctor{nilCtor, consCtor}
+	consCtor.all = nilCtor.all
+	trueCtor.all = []*ctor{trueCtor, falseCtor}
+	falseCtor.all = trueCtor.all
+}
+
+// checkCases checks all the case expressions in the given declarations.
+func (c *checker) checkCases(decls []ast.Decl) {
+	for _, decl := range decls {
+		ast.WalkFunc(decl, func(node ast.Node) bool {
+			if expr, ok := node.(*ast.CaseExpr); ok {
+				c.checkCase(expr)
+			}
+			return true
+		})
+	}
+}
+
+// checkCase reports the branches of the given case expression that will
+// never be matched and an example of the values not matched by any branch,
+// if there are any.
+func (c *checker) checkCase(expr *ast.CaseExpr) {
+	var matrix [][]*pat
+	for _, b := range expr.Branches {
+		row := []*pat{c.simplify(b.Pattern)}
+		if !useful(matrix, row) {
+			c.reporter.Report(c.path, report.NewRedundantPatternWarning(b.Pattern))
+		}
+		matrix = append(matrix, row)
+	}
+
+	if missing := missing(matrix, 1); missing != nil {
+		c.report(report.NewMissingPatternsError(expr, missing[0].String()))
+	}
+}
+
+// simplify returns the simplified version of the given pattern.
+func (c *checker) simplify(pattern ast.Pattern) *pat {
+	switch pattern := pattern.(type) {
+	case *ast.AliasPattern:
+		return c.simplify(pattern.Pattern)
+	case *ast.LiteralPattern:
+		lit := pattern.Literal
+		if lit.Type == ast.Bool {
+			if lit.Value == trueCtor.name {
+				return &pat{ctor: trueCtor}
+			}
+			return &pat{ctor: falseCtor}
+		}
+		return &pat{ctor: &ctor{name: lit.Value}}
+	case *ast.TuplePattern:
+		t := &ctor{name: "(,)", arity: len(pattern.Elems)}
+		t.all = []*ctor{t}
+		return &pat{ctor: t, args: c.simplifyAll(pattern.Elems)}
+	case *ast.ListPattern:
+		var result = &pat{ctor: nilCtor}
+		for i := len(pattern.Elems) - 1; i >= 0; i-- {
+			result = &pat{
+				ctor: consCtor,
+				args: []*pat{c.simplify(pattern.Elems[i]), result},
+			}
+		}
+		return result
+	case *ast.CtorPattern:
+		return c.simplifyCtor(pattern)
+	}
+
+	// variables, _ and records, which just bind their fields
+	return wildcard
+}
+
+func (c *checker) simplifyAll(patterns []ast.Pattern) []*pat {
+	var result = make([]*pat, len(patterns))
+	for i, p := range patterns {
+		result[i] = c.simplify(p)
+	}
+	return result
+}
+
+func (c *checker) simplifyCtor(pattern *ast.CtorPattern) *pat {
+	ident := lastIdent(pattern.Ctor)
+	if ident == nil || ident.Obj == nil {
+		return wildcard
+	}
+
+	if ident.Obj.Kind == ast.Var && ident.Name == consCtor.name {
+		return &pat{ctor: consCtor, args: c.simplifyAll(pattern.Args)}
+	}
+
+	node, ok := ident.Obj.Node.(*ast.Constructor)
+	if !ok {
+		return wildcard
+	}
+
+	union := c.unions[node]
+	if union == nil {
+		return wildcard
+	}
+
+	var result *ctor
+	var all = make([]*ctor, len(union.Ctors))
+	for i, ct := range union.Ctors {
+		all[i] = &ctor{name: ct.Name.Name, arity: len(ct.Args), all: all}
+		if ct == node {
+			result = all[i]
+		}
+	}
+
+	return &pat{ctor: result, args: c.simplifyAll(pattern.Args)}
+}
+
+// useful reports whether the given row matches values that are not matched
+// by any of the rows of the given matrix.
+func useful(matrix [][]*pat, row []*pat) bool {
+	if len(row) == 0 {
+		return len(matrix) == 0
+	}
+
+	if head := row[0]; head.ctor != nil {
+		return useful(specialize(matrix, head.ctor), specializeRow(row, head.ctor))
+	}
+
+	if all := complete(matrix); all != nil {
+		for _, c := range all {
+			if useful(specialize(matrix, c), specializeRow(row, c)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return useful(defaultMatrix(matrix), row[1:])
+}
+
+// missing returns an example of n values that are not matched by any of the
+// rows of the given matrix, or nil if all values are matched.
+func missing(matrix [][]*pat, n int) []*pat {
+	if n == 0 {
+		if len(matrix) == 0 {
+			return []*pat{}
+		}
+		return nil
+	}
+
+	if all := complete(matrix); all != nil {
+		for _, c := range all {
+			if rest := missing(specialize(matrix, c), c.arity+n-1); rest != nil {
+				head := &pat{ctor: c, args: rest[:c.arity]}
+				return append([]*pat{head}, rest[c.arity:]...)
+			}
+		}
+		return nil
+	}
+
+	rest := missing(defaultMatrix(matrix), n-1)
+	if rest == nil {
+		return nil
+	}
+
+	head := wildcard
+	if c := unusedCtor(matrix); c != nil {
+		head = &pat{ctor: c, args: make([]*pat, c.arity)}
+		for i := range head.args {
+			head.args[i] = wildcard
+		}
+	}
+	return append([]*pat{head}, rest...)
+}
+
+// headCtors returns the names of the constructors at the head of the rows
+// of the given matrix and the type they belong to.
+func headCtors(matrix [][]*pat) (map[string]struct{}, []*ctor) {
+	var names = make(map[string]struct{})
+	var all []*ctor
+	for _, row := range matrix {
+		if c := row[0].ctor; c != nil {
+			names[c.name] = struct{}{}
+			all = c.all
+		}
+	}
+	return names, all
+}
+
+// complete returns all the constructors of the type of the first column of
+// the given matrix if all of them are used at the head of its rows.
+func complete(matrix [][]*pat) []*ctor {
+	names, all := headCtors(matrix)
+	if len(names) == 0 || all == nil {
+		return nil
+	}
+
+	for _, c := range all {
+		if _, ok := names[c.name]; !ok {
+			return nil
+		}
+	}
+	return all
+}
+
+// unusedCtor returns a constructor of the type of the first column of the
+// given matrix that is not used at the head of any of its rows, if the type
+// has a finite number of constructors.
+func unusedCtor(matrix [][]*pat) *ctor {
+	names, all := headCtors(matrix)
+	for _, c := range all {
+		if _, ok := names[c.name]; !ok {
+			return c
+		}
+	}
+	return nil
+}
+
+// specialize returns the matrix of the arguments of the rows whose head
+// matches the given constructor.
+func specialize(matrix [][]*pat, c *ctor) [][]*pat {
+	var result [][]*pat
+	for _, row := range matrix {
+		if r := specializeRow(row, c); r != nil {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// specializeRow returns the arguments of the head of the given row followed
+// by the rest of the row, or nil if the head does not match the given
+// constructor.
+func specializeRow(row []*pat, c *ctor) []*pat {
+	head := row[0]
+	var result = make([]*pat, 0, c.arity+len(row)-1)
+	switch {
+	case head.ctor == nil:
+		for i := 0; i < c.arity; i++ {
+			result = append(result, wildcard)
+		}
+	case head.ctor.name == c.name:
+		result = append(result, head.args...)
+	default:
+		return nil
+	}
+	return append(result, row[1:]...)
+}
+
+// defaultMatrix returns the rest of the rows whose head is a wildcard.
+func defaultMatrix(matrix [][]*pat) [][]*pat {
+	var result [][]*pat
+	for _, row := range matrix {
+		if row[0].ctor == nil {
+			result = append(result, row[1:])
+		}
+	}
+	return result
+}
+
+func (p *pat) String() string {
+	return p.write(false)
+}
+
+func (p *pat) write(parens bool) string {
+	if p.ctor == nil {
+		return "_"
+	}
+
+	var s string
+	switch {
+	case p.ctor.name == "(,)":
+		var elems = make([]string, len(p.args))
+		for i, arg := range p.args {
+			elems[i] = arg.write(false)
+		}
+		return "(" + strings.Join(elems, ", ") + ")"
+	case p.ctor == consCtor:
+		s = p.args[0].write(true) + " :: " + p.args[1].write(false)
+	case len(p.args) == 0:
+		return p.ctor.name
+	default:
+		var parts = []string{p.ctor.name}
+		for _, arg := range p.args {
+			parts = append(parts, arg.write(true))
+		}
+		s = strings.Join(parts, " ")
+	}
+
+	if parens {
+		return "(" + s + ")"
+	}
+	return s
+}
@@ -0,0 +1,75 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/elm-tangram/tangram/report"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckMissingPatterns(t *testing.T) {
+	cases := []struct {
+		name    string
+		source  string
+		example string
+	}{
+		{"union", "f x =\n    case x of\n        Just _ -> 1", "Nothing"},
+		{"union argument", "f x =\n    case x of\n        Just 1 -> 1\n        Nothing -> 2", "Just _"},
+		{"nested union", "f x =\n    case x of\n        Just (Just _) -> 1\n        Nothing -> 2", "Just Nothing"},
+		{"bool", "f x =\n    case x of\n        True -> 1", "False"},
+		{"literal", "f x =\n    case x of\n        1 -> 1\n        2 -> 2", "_"},
+		{"empty list", "f x =\n    case x of\n        _ :: _ -> 1", "[]"},
+		{"non empty list", "f x =\n    case x of\n        [] -> 1", "_ :: _"},
+		{"list patterns", "f x =\n    case x of\n        [] -> 1\n        [ _ ] -> 2", "_ :: _ :: _"},
+		{"list of unions", "f x =\n    case x of\n        [] -> 1\n        Nothing :: _ -> 2", "(Just _) :: _"},
+		{"tuple", "f x =\n    case x of\n        (True, _) -> 1\n        (_, Nothing) -> 2", "(False, Just _)"},
+		{"alias", "f x =\n    case x of\n        (Just _) as y -> 1", "Nothing"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require := require.New(t)
+			pkg, _, reporter, ok := checkSource(t, c.source)
+			require.False(ok)
+
+			reports := reporter.Reports(pkg.Modules["Main"].Path)
+			require.Len(reports, 1)
+			require.IsType(new(report.MissingPatternsError), reports[0])
+			require.Equal(c.example, reports[0].(*report.MissingPatternsError).Example)
+		})
+	}
+}
+
+func TestCheckRedundantPatterns(t *testing.T) {
+	cases := []struct {
+		name      string
+		source    string
+		redundant int
+	}{
+		{"exhaustive union", "f x =\n    case x of\n        Just _ -> 1\n        Nothing -> 2", 0},
+		{"wildcard", "f x =\n    case x of\n        _ -> 1", 0},
+		{"exhaustive lists", "f x =\n    case x of\n        [] -> 1\n        [ _ ] -> 2\n        _ :: _ :: _ -> 3", 0},
+		{"records", "f x =\n    case x of\n        { a } -> a", 0},
+		{"after wildcard", "f x =\n    case x of\n        _ -> 1\n        Nothing -> 2", 1},
+		{"repeated", "f x =\n    case x of\n        Nothing -> 1\n        Nothing -> 2\n        Just _ -> 3", 1},
+		{"covered", "f x =\n    case x of\n        Just _ -> 1\n        Nothing -> 2\n        Just 1 -> 3", 1},
+		{"repeated literal", "f x =\n    case x of\n        1 -> 1\n        1 -> 2\n        _ -> 3", 1},
+		{"covered tuple", "f x =\n    case x of\n        (True, _) -> 1\n        (False, _) -> 2\n        (_, 1) -> 3", 1},
+		{"many", "f x =\n    case x of\n        _ -> 1\n        True -> 2\n        False -> 3", 2},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require := require.New(t)
+			pkg, _, reporter, ok := checkSource(t, c.source)
+			require.True(ok, "%v", reporter.Reports(pkg.Modules["Main"].Path))
+
+			reports := reporter.Reports(pkg.Modules["Main"].Path)
+			require.Len(reports, c.redundant)
+			for _, r := range reports {
+				require.IsType(new(report.RedundantPatternWarning), r)
+				require.Equal(report.Warning, r.Type())
+			}
+		})
+	}
+}
@@ -0,0 +1,326 @@
+// Package types implements the type inference and checking of Elm packages.
+// Types are inferred using the Hindley-Milner algorithm and then unified with
+// the type annotations written by the user, if any.
+package types
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/elm-tangram/tangram/ast"
+)
+
+// Type is the type of an expression, pattern or definition.
+type Type interface {
+	fmt.Stringer
+	isType()
+}
+
+// VarKind is the constraint a type variable has. Elm has some special type
+// variables that can only be certain types.
+type VarKind byte
+
+const (
+	// Unconstrained type variables can be any type.
+	Unconstrained VarKind = iota
+	// Number type variables can only be Int or Float.
+	Number
+	// Comparable type variables can only be Int, Float, Char, String or lists
+	// and tuples of comparable values.
+	Comparable
+	// Appendable type variables can only be String or lists.
+	Appendable
+	// CompAppend type variables can only be String or lists of comparable
+	// values.
+	CompAppend
+)
+
+var varKindStrings = [...]string{
+	"a",
+	"number",
+	"comparable",
+	"appendable",
+	"compappend",
+}
+
+func (k VarKind) String() string {
+	return varKindStrings[k]
+}
+
+// kindOf returns the kind of a type variable with the given name. Just like
+// in Elm, the kind is given by the prefix of the name.
+func kindOf(name string) VarKind {
+	for k := len(varKindStrings) - 1; k > 0; k-- {
+		if strings.HasPrefix(name, varKindStrings[k]) {
+			return VarKind(k)
+		}
+	}
+	return Unconstrained
+}
+
+// Var is a type variable.
+type Var struct {
+	// Name of the variable, only if it comes from a type annotation.
+	Name string
+	// Kind is the constraint the variable has.
+	Kind VarKind
+	// Rigid is true if the variable comes from a type annotation and it
+	// cannot be unified with any other type than itself.
+	Rigid bool
+	// Ref is the type this variable has been unified with, if any.
+	Ref Type
+
+	level int
+}
+
+func (*Var) isType()          {}
+func (v *Var) String() string { return typeString(v) }
+
+// Named is a type with a name and optional arguments, such as Int or
+// Maybe a.
+type Named struct {
+	// Name of the type.
+	Name string
+	// Decl is the union declaration of the type. Builtin types do not have
+	// a declaration.
+	Decl *ast.UnionDecl
+	// Args of the type.
+	Args []Type
+}
+
+func (*Named) isType()          {}
+func (t *Named) String() string { return typeString(t) }
+
+// Func is the type of a function with only one argument. Functions with
+// more arguments are represented as functions returning other functions.
+type Func struct {
+	// Arg is the type of the argument.
+	Arg Type
+	// Return is the type of the returned value.
+	Return Type
+}
+
+func (*Func) isType()          {}
+func (t *Func) String() string { return typeString(t) }
+
+// Tuple is the type of a tuple. A tuple with no elements is the unit type.
+type Tuple struct {
+	// Elems are the types of the tuple elements.
+	Elems []Type
+}
+
+func (*Tuple) isType()          {}
+func (t *Tuple) String() string { return typeString(t) }
+
+// Record is the type of a record.
+type Record struct {
+	// Fields of the record and their types.
+	Fields map[string]Type
+	// Ext is the type being extended by the record. If it is nil, the
+	// record is closed and can not have more fields than those in Fields.
+	Ext Type
+}
+
+func (*Record) isType()          {}
+func (t *Record) String() string { return typeString(t) }
+
+// Builtin types.
+var (
+	Int    = &Named{Name: "Int"}
+	Float  = &Named{Name: "Float"}
+	Bool   = &Named{Name: "Bool"}
+	String = &Named{Name: "String"}
+	Char   = &Named{Name: "Char"}
+)
+
+// List returns the type of a list of elements of the given type.
+func List(elem Type) *Named {
+	return &Named{Name: "List", Args: []Type{elem}}
+}
+
+// Unit is the type of the empty tuple.
+var Unit = new(Tuple)
+
+// prune returns the type the given type is bound to, skipping all the bound
+// type variables in between.
+func prune(t Type) Type {
+	for {
+		v, ok := t.(*Var)
+		if !ok || v.Ref == nil {
+			return t
+		}
+		t = v.Ref
+	}
+}
+
+// sameNamed reports whether two named types are the same type, not taking
+// into account their arguments.
+func sameNamed(a, b *Named) bool {
+	if a.Decl != nil || b.Decl != nil {
+		return a.Decl == b.Decl
+	}
+	return a.Name == b.Name
+}
+
+// typeString returns the string representation of all the given types with
+// the same names for the unnamed type variables, separated by a comma.
+func typeString(types ...Type) string {
+	p := newPrinter()
+	var strs = make([]string, len(types))
+	for i, t := range types {
+		strs[i] = p.print(t)
+	}
+	return strings.Join(strs, ", ")
+}
+
+// printer prints types giving names to the unnamed type variables. A printer
+// must be used to print all the types that need to be read together so the
+// same variable has always the same name.
+type printer struct {
+	names map[*Var]string
+	used  map[string]struct{}
+}
+
+func newPrinter() *printer {
+	return &printer{
+		make(map[*Var]string),
+		make(map[string]struct{}),
+	}
+}
+
+func (p *printer) print(t Type) string {
+	var buf bytes.Buffer
+	p.write(&buf, t, false)
+	return buf.String()
+}
+
+func (p *printer) write(buf *bytes.Buffer, t Type, parens bool) {
+	switch t := prune(t).(type) {
+	case *Var:
+		buf.WriteString(p.varName(t))
+	case *Named:
+		if parens && len(t.Args) > 0 {
+			buf.WriteRune('(')
+			defer buf.WriteRune(')')
+		}
+
+		buf.WriteString(t.Name)
+		for _, arg := range t.Args {
+			buf.WriteRune(' ')
+			p.write(buf, arg, true)
+		}
+	case *Func:
+		if parens {
+			buf.WriteRune('(')
+			defer buf.WriteRune(')')
+		}
+
+		_, isFunc := prune(t.Arg).(*Func)
+		p.write(buf, t.Arg, isFunc)
+		buf.WriteString(" -> ")
+		p.write(buf, t.Return, false)
+	case *Tuple:
+		buf.WriteRune('(')
+		for i, el := range t.Elems {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			p.write(buf, el, false)
+		}
+		buf.WriteRune(')')
+	case *Record:
+		fields, ext := flattenRecord(t)
+		if len(fields) == 0 && ext == nil {
+			buf.WriteString("{}")
+			return
+		}
+
+		buf.WriteRune('{')
+		if ext != nil {
+			buf.WriteRune(' ')
+			p.write(buf, ext, false)
+			buf.WriteString(" |")
+		}
+
+		for i, name := range sortedFields(fields) {
+			if i > 0 {
+				buf.WriteRune(',')
+			}
+			buf.WriteString(" " + name + " : ")
+			p.write(buf, fields[name], false)
+		}
+		buf.WriteString(" }")
+	}
+}
+
+func (p *printer) varName(v *Var) string {
+	if name, ok := p.names[v]; ok {
+		return name
+	}
+
+	name := v.Name
+	if name == "" && v.Kind != Unconstrained {
+		name = v.Kind.String()
+	}
+
+	if name == "" {
+		for i := 0; ; i++ {
+			name = string(rune('a' + i%26))
+			if i >= 26 {
+				name += fmt.Sprint(i / 26)
+			}
+
+			if _, ok := p.used[name]; !ok {
+				break
+			}
+		}
+	} else {
+		base := name
+		for i := 1; ; i++ {
+			if _, ok := p.used[name]; !ok {
+				break
+			}
+			name = fmt.Sprint(base, i)
+		}
+	}
+
+	p.names[v] = name
+	p.used[name] = struct{}{}
+	return name
+}
+
+// flattenRecord returns all the fields of a record, including the fields of
+// the records it extends, and the type being extended that is not a record.
+func flattenRecord(r *Record) (map[string]Type, Type) {
+	var fields = make(map[string]Type)
+	var ext Type = r
+	for {
+		rec, ok := prune(ext).(*Record)
+		if !ok {
+			break
+		}
+
+		for name, t := range rec.Fields {
+			if _, ok := fields[name]; !ok {
+				fields[name] = t
+			}
+		}
+		ext = rec.Ext
+	}
+
+	if ext != nil {
+		ext = prune(ext)
+	}
+	return fields, ext
+}
+
+func sortedFields(fields map[string]Type) []string {
+	var names = make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
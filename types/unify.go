@@ -0,0 +1,348 @@
+package types
+
+// mismatchError is returned when two types can not be unified.
+type mismatchError struct{}
+
+func (mismatchError) Error() string { return "types do not match" }
+
+// infiniteTypeError is returned when a type variable would need to be
+// unified with a type that contains itself.
+type infiniteTypeError struct {
+	Var  *Var
+	Type Type
+}
+
+func (infiniteTypeError) Error() string { return "infinite type" }
+
+// unify makes the two given types the same type, binding type variables if
+// needed. An error is returned if they cannot be unified.
+func unify(a, b Type) error {
+	a, b = prune(a), prune(b)
+	if a == b {
+		return nil
+	}
+
+	if v, ok := a.(*Var); ok && !v.Rigid {
+		return bindVar(v, b)
+	}
+
+	if v, ok := b.(*Var); ok && !v.Rigid {
+		return bindVar(v, a)
+	}
+
+	switch a := a.(type) {
+	case *Named:
+		if b, ok := b.(*Named); ok && sameNamed(a, b) && len(a.Args) == len(b.Args) {
+			for i := range a.Args {
+				if err := unify(a.Args[i], b.Args[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case *Func:
+		if b, ok := b.(*Func); ok {
+			if err := unify(a.Arg, b.Arg); err != nil {
+				return err
+			}
+			return unify(a.Return, b.Return)
+		}
+	case *Tuple:
+		if b, ok := b.(*Tuple); ok && len(a.Elems) == len(b.Elems) {
+			for i := range a.Elems {
+				if err := unify(a.Elems[i], b.Elems[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case *Record:
+		if b, ok := b.(*Record); ok {
+			return unifyRecords(a, b)
+		}
+	}
+
+	return mismatchError{}
+}
+
+// bindVar binds the flexible type variable v to the given type, as long as
+// the type satisfies the constraints of the variable.
+func bindVar(v *Var, t Type) error {
+	if other, ok := t.(*Var); ok {
+		if other.Rigid {
+			if !kindSatisfies(other.Kind, v.Kind) {
+				return mismatchError{}
+			}
+		} else {
+			kind, ok := mergeKinds(v.Kind, other.Kind)
+			if !ok {
+				return mismatchError{}
+			}
+			other.Kind = kind
+			if v.level < other.level {
+				other.level = v.level
+			}
+		}
+
+		v.Ref = t
+		return nil
+	}
+
+	if occurs(v, t) {
+		return infiniteTypeError{v, t}
+	}
+
+	if err := constrain(t, v.Kind); err != nil {
+		return err
+	}
+
+	adjustLevels(t, v.level)
+	v.Ref = t
+	return nil
+}
+
+// mergeKinds returns the kind a type variable must have to satisfy the two
+// given kinds at the same time.
+func mergeKinds(a, b VarKind) (VarKind, bool) {
+	if a == b || b == Unconstrained {
+		return a, true
+	}
+
+	if a == Unconstrained {
+		return b, true
+	}
+
+	switch {
+	case kindSatisfies(a, b):
+		return a, true
+	case kindSatisfies(b, a):
+		return b, true
+	case a == Comparable && b == Appendable, a == Appendable && b == Comparable:
+		return CompAppend, true
+	}
+
+	return Unconstrained, false
+}
+
+// kindSatisfies reports whether all the types of the given kind satisfy the
+// required kind.
+func kindSatisfies(kind, required VarKind) bool {
+	switch required {
+	case Unconstrained:
+		return true
+	case Comparable:
+		return kind == Comparable || kind == Number || kind == CompAppend
+	case Appendable:
+		return kind == Appendable || kind == CompAppend
+	default:
+		return kind == required
+	}
+}
+
+// constrain checks that the given type satisfies the given kind, applying
+// the constraint to the type variables inside it if needed.
+func constrain(t Type, kind VarKind) error {
+	if kind == Unconstrained {
+		return nil
+	}
+
+	switch t := prune(t).(type) {
+	case *Var:
+		if t.Rigid {
+			if !kindSatisfies(t.Kind, kind) {
+				return mismatchError{}
+			}
+			return nil
+		}
+
+		k, ok := mergeKinds(t.Kind, kind)
+		if !ok {
+			return mismatchError{}
+		}
+		t.Kind = k
+		return nil
+	case *Named:
+		if t.Decl != nil {
+			break
+		}
+
+		switch t.Name {
+		case "Int", "Float":
+			if kind == Number || kind == Comparable {
+				return nil
+			}
+		case "Char":
+			if kind == Comparable {
+				return nil
+			}
+		case "String":
+			if kind != Number {
+				return nil
+			}
+		case "List":
+			switch kind {
+			case Appendable:
+				return nil
+			case Comparable, CompAppend:
+				return constrain(t.Args[0], Comparable)
+			}
+		}
+	case *Tuple:
+		if kind == Comparable && len(t.Elems) > 0 {
+			for _, el := range t.Elems {
+				if err := constrain(el, Comparable); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	return mismatchError{}
+}
+
+// unifyRecords unifies two record types. Fields that are only present in
+// one of the records need to be absorbed by the type the other one extends.
+func unifyRecords(a, b *Record) error {
+	fieldsA, extA := flattenRecord(a)
+	fieldsB, extB := flattenRecord(b)
+
+	var onlyA = make(map[string]Type)
+	var onlyB = make(map[string]Type)
+	for name, t := range fieldsA {
+		if other, ok := fieldsB[name]; ok {
+			if err := unify(t, other); err != nil {
+				return err
+			}
+		} else {
+			onlyA[name] = t
+		}
+	}
+
+	for name, t := range fieldsB {
+		if _, ok := fieldsA[name]; !ok {
+			onlyB[name] = t
+		}
+	}
+
+	if extA != nil && extA == extB && (len(onlyA) > 0 || len(onlyB) > 0) {
+		return mismatchError{}
+	}
+
+	switch {
+	case len(onlyA) == 0 && len(onlyB) == 0:
+		return unifyExt(extA, extB)
+	case len(onlyA) == 0:
+		if extA == nil {
+			return mismatchError{}
+		}
+		return unify(extA, &Record{onlyB, extB})
+	case len(onlyB) == 0:
+		if extB == nil {
+			return mismatchError{}
+		}
+		return unify(extB, &Record{onlyA, extA})
+	}
+
+	if extA == nil || extB == nil {
+		return mismatchError{}
+	}
+
+	rest := &Var{level: minLevel(extA, extB)}
+	if err := unify(extA, &Record{onlyB, rest}); err != nil {
+		return err
+	}
+	return unify(extB, &Record{onlyA, rest})
+}
+
+// unifyExt unifies the types extended by two records with the same fields.
+// A nil type means the record is closed, so the other one can only be closed
+// as well.
+func unifyExt(a, b Type) error {
+	switch {
+	case a == nil && b == nil:
+		return nil
+	case a == nil:
+		a, b = b, a
+	}
+
+	if b == nil {
+		if v, ok := a.(*Var); ok && !v.Rigid {
+			v.Ref = &Record{Fields: make(map[string]Type)}
+			return nil
+		}
+		return mismatchError{}
+	}
+
+	return unify(a, b)
+}
+
+func minLevel(a, b Type) int {
+	var level = genericLevel
+	for _, t := range []Type{a, b} {
+		if v, ok := t.(*Var); ok && v.level < level {
+			level = v.level
+		}
+	}
+	return level
+}
+
+// occurs reports whether the type variable appears inside the given type.
+func occurs(v *Var, t Type) bool {
+	switch t := prune(t).(type) {
+	case *Var:
+		return t == v
+	case *Named:
+		for _, arg := range t.Args {
+			if occurs(v, arg) {
+				return true
+			}
+		}
+	case *Func:
+		return occurs(v, t.Arg) || occurs(v, t.Return)
+	case *Tuple:
+		for _, el := range t.Elems {
+			if occurs(v, el) {
+				return true
+			}
+		}
+	case *Record:
+		for _, f := range t.Fields {
+			if occurs(v, f) {
+				return true
+			}
+		}
+		return t.Ext != nil && occurs(v, t.Ext)
+	}
+	return false
+}
+
+// adjustLevels lowers the level of all the unbound type variables in the
+// given type to the given level, so they are not generalized at deeper
+// levels than the variable they are bound to.
+func adjustLevels(t Type, level int) {
+	switch t := prune(t).(type) {
+	case *Var:
+		if t.level > level {
+			t.level = level
+		}
+	case *Named:
+		for _, arg := range t.Args {
+			adjustLevels(arg, level)
+		}
+	case *Func:
+		adjustLevels(t.Arg, level)
+		adjustLevels(t.Return, level)
+	case *Tuple:
+		for _, el := range t.Elems {
+			adjustLevels(el, level)
+		}
+	case *Record:
+		for _, f := range t.Fields {
+			adjustLevels(f, level)
+		}
+		if t.Ext != nil {
+			adjustLevels(t.Ext, level)
+		}
+	}
+}